@@ -3,11 +3,14 @@ package ast
 import (
 	"bytes"
 	"strings"
+
+	"github.com/vpaulo/seda/lexer"
 )
 
 // Node represents any node in the AST
 type Node interface {
 	String() string
+	Position() lexer.Token // where the node starts in the source
 }
 
 // Statement represents statement nodes
@@ -27,6 +30,14 @@ type Program struct {
 	Statements []Statement
 }
 
+// Position returns the position of the first statement
+func (p *Program) Position() lexer.Token {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Position()
+	}
+	return lexer.Token{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 	for _, s := range p.Statements {
@@ -37,13 +48,16 @@ func (p *Program) String() string {
 
 // Variable Declaration
 type VarStatement struct {
+	Token      lexer.Token   // the var or const token
 	Names      []*Identifier // support multiple variable assignment (backward compatible with single variable)
+	Pattern    Expression    // set instead of Names for destructuring, e.g. var [a, b] = pair
 	Type       *TypeAnnotation
 	Value      Expression
 	IsConstant bool
 }
 
-func (vs *VarStatement) statementNode() {}
+func (vs *VarStatement) statementNode()        {}
+func (vs *VarStatement) Position() lexer.Token { return vs.Token }
 func (vs *VarStatement) String() string {
 	var out bytes.Buffer
 	if vs.IsConstant {
@@ -56,6 +70,9 @@ func (vs *VarStatement) String() string {
 	for _, name := range vs.Names {
 		names = append(names, name.String())
 	}
+	if vs.Pattern != nil {
+		names = append(names, vs.Pattern.String())
+	}
 	out.WriteString(strings.Join(names, ", "))
 	if vs.Type != nil {
 		out.WriteString(": ")
@@ -70,15 +87,18 @@ func (vs *VarStatement) String() string {
 
 // Function Declaration
 type FnStatement struct {
+	Token      lexer.Token // the fn token
 	Name       *Identifier
 	Parameters []*Parameter
 	ReturnType *TypeAnnotation
 	Body       *BlockStatement
 	WhereBlock *WhereBlock
 	Receiver   *TypeAnnotation // for methods like Person.greet()
+	Generator  bool            // true when the body yields
 }
 
-func (fs *FnStatement) statementNode() {}
+func (fs *FnStatement) statementNode()        {}
+func (fs *FnStatement) Position() lexer.Token { return fs.Token }
 func (fs *FnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("fn ")
@@ -109,11 +129,13 @@ func (fs *FnStatement) String() string {
 // TODO: still not sure about struct
 // Struct Declaration
 type StructStatement struct {
+	Token  lexer.Token // the struct token
 	Name   *Identifier
 	Fields []*StructField
 }
 
-func (ss *StructStatement) statementNode() {}
+func (ss *StructStatement) statementNode()        {}
+func (ss *StructStatement) Position() lexer.Token { return ss.Token }
 func (ss *StructStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("struct ")
@@ -129,30 +151,36 @@ func (ss *StructStatement) String() string {
 
 // Type Declaration
 type TypeStatement struct {
-	Name *Identifier
-	Type *TypeAnnotation
+	Token    lexer.Token // the type token
+	Name     *Identifier
+	Type     *TypeAnnotation // the aliased type, nil for sum types
+	Variants []*Variant      // the cases of a sum type like Circle(radius) | Rect(w, h)
 }
 
 // Module Declaration
 type ModuleStatement struct {
-	Name *Identifier
-	Body *BlockStatement
+	Token lexer.Token // the module token
+	Name  *Identifier
+	Body  *BlockStatement
 }
 
 // Using Statement (for external module imports)
 type UsingStatement struct {
+	Token lexer.Token // the using token
 	Path  *StringLiteral
 	Alias *Identifier // Optional alias for module
 }
 
 // Component Declaration (UI component)
 type ComponentStatement struct {
+	Token      lexer.Token // the component token
 	Name       *Identifier
 	Parameters []*Parameter
 	Body       *ComponentBody
 }
 
-func (cs *ComponentStatement) statementNode() {}
+func (cs *ComponentStatement) statementNode()        {}
+func (cs *ComponentStatement) Position() lexer.Token { return cs.Token }
 func (cs *ComponentStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("component ")
@@ -173,10 +201,12 @@ func (cs *ComponentStatement) String() string {
 
 // ComponentBody holds both state (var declarations) and UI tree
 type ComponentBody struct {
+	Token      lexer.Token // the first token of the body
 	Statements []Statement // var declarations, assignments
 	Root       *UIElement  // root UI element (e.g., Window)
 }
 
+func (cb *ComponentBody) Position() lexer.Token { return cb.Token }
 func (cb *ComponentBody) String() string {
 	var out bytes.Buffer
 	for _, stmt := range cb.Statements {
@@ -192,12 +222,14 @@ func (cb *ComponentBody) String() string {
 
 // UIElement represents a UI element (Window, VBox, Text, Button, etc.)
 type UIElement struct {
-	Type       *Identifier            // Window, VBox, Button, etc.
-	Properties map[string]Expression  // title: "Hello", width: 400px, onClick: fn() :: ... end
-	Children   []*UIElement           // nested UI elements
+	Token      lexer.Token           // the element name token
+	Type       *Identifier           // Window, VBox, Button, etc.
+	Properties map[string]Expression // title: "Hello", width: 400px, onClick: fn() :: ... end
+	Children   []*UIElement          // nested UI elements
 }
 
-func (ue *UIElement) expressionNode() {}
+func (ue *UIElement) expressionNode()       {}
+func (ue *UIElement) Position() lexer.Token { return ue.Token }
 func (ue *UIElement) String() string {
 	var out bytes.Buffer
 	out.WriteString(ue.Type.String())
@@ -226,17 +258,27 @@ func (ue *UIElement) String() string {
 	return out.String()
 }
 
-func (ts *TypeStatement) statementNode() {}
+func (ts *TypeStatement) statementNode()        {}
+func (ts *TypeStatement) Position() lexer.Token { return ts.Token }
 func (ts *TypeStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("type ")
 	out.WriteString(ts.Name.String())
 	out.WriteString(" = ")
-	out.WriteString(ts.Type.String())
+	if ts.Variants != nil {
+		variants := []string{}
+		for _, variant := range ts.Variants {
+			variants = append(variants, variant.String())
+		}
+		out.WriteString(strings.Join(variants, " | "))
+	} else {
+		out.WriteString(ts.Type.String())
+	}
 	return out.String()
 }
 
-func (ms *ModuleStatement) statementNode() {}
+func (ms *ModuleStatement) statementNode()        {}
+func (ms *ModuleStatement) Position() lexer.Token { return ms.Token }
 func (ms *ModuleStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("module ")
@@ -249,7 +291,8 @@ func (ms *ModuleStatement) String() string {
 	return out.String()
 }
 
-func (us *UsingStatement) statementNode() {}
+func (us *UsingStatement) statementNode()        {}
+func (us *UsingStatement) Position() lexer.Token { return us.Token }
 func (us *UsingStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("using ")
@@ -265,13 +308,15 @@ func (us *UsingStatement) String() string {
 
 // If Statement
 type IfStatement struct {
+	Token     lexer.Token // the if token
 	Condition Expression
 	ThenBlock *BlockStatement
 	ElseIfs   []*ElseIfClause
 	ElseBlock *BlockStatement
 }
 
-func (ifs *IfStatement) statementNode() {}
+func (ifs *IfStatement) statementNode()        {}
+func (ifs *IfStatement) Position() lexer.Token { return ifs.Token }
 func (ifs *IfStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("if ")
@@ -294,11 +339,13 @@ func (ifs *IfStatement) String() string {
 
 // Case Statement
 type CaseStatement struct {
+	Token      lexer.Token // the case token
 	Expression Expression
 	Branches   []*CaseBranch
 }
 
-func (cs *CaseStatement) statementNode() {}
+func (cs *CaseStatement) statementNode()        {}
+func (cs *CaseStatement) Position() lexer.Token { return cs.Token }
 func (cs *CaseStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("case ")
@@ -315,11 +362,13 @@ func (cs *CaseStatement) String() string {
 // TODO: case statement and case expression have the code maybe i can remove this duplication
 // Case Expression (like case statement but returns a value)
 type CaseExpression struct {
+	Token      lexer.Token // the case token
 	Expression Expression
 	Branches   []*CaseBranch
 }
 
-func (ce *CaseExpression) expressionNode() {}
+func (ce *CaseExpression) expressionNode()       {}
+func (ce *CaseExpression) Position() lexer.Token { return ce.Token }
 func (ce *CaseExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("case ")
@@ -335,21 +384,33 @@ func (ce *CaseExpression) String() string {
 
 // For Statement
 type ForStatement struct {
+	Token    lexer.Token // the for token
 	Variable *Identifier
+	Pattern  Expression  // set instead of Variable to destructure each element, e.g. for [k, v] in pairs
 	Index    *Identifier // optional, for index, value syntax
 	Iterable Expression
 	Body     *BlockStatement
+	Label    *Identifier // optional, names the loop for labeled break and continue
 }
 
-func (fs *ForStatement) statementNode() {}
+func (fs *ForStatement) statementNode()        {}
+func (fs *ForStatement) Position() lexer.Token { return fs.Token }
 func (fs *ForStatement) String() string {
 	var out bytes.Buffer
+	if fs.Label != nil {
+		out.WriteString(fs.Label.String())
+		out.WriteString(": ")
+	}
 	out.WriteString("for ")
 	if fs.Index != nil {
 		out.WriteString(fs.Index.String())
 		out.WriteString(", ")
 	}
-	out.WriteString(fs.Variable.String())
+	if fs.Pattern != nil {
+		out.WriteString(fs.Pattern.String())
+	} else {
+		out.WriteString(fs.Variable.String())
+	}
 	out.WriteString(" in ")
 	out.WriteString(fs.Iterable.String())
 	out.WriteString(" ::")
@@ -358,14 +419,40 @@ func (fs *ForStatement) String() string {
 	return out.String()
 }
 
+// While Statement
+type WhileStatement struct {
+	Token     lexer.Token // the while token
+	Condition Expression
+	Body      *BlockStatement
+	Label     *Identifier // optional, names the loop for labeled break and continue
+}
+
+func (ws *WhileStatement) statementNode()        {}
+func (ws *WhileStatement) Position() lexer.Token { return ws.Token }
+func (ws *WhileStatement) String() string {
+	var out bytes.Buffer
+	if ws.Label != nil {
+		out.WriteString(ws.Label.String())
+		out.WriteString(": ")
+	}
+	out.WriteString("while ")
+	out.WriteString(ws.Condition.String())
+	out.WriteString(" ::")
+	out.WriteString(ws.Body.String())
+	out.WriteString("end")
+	return out.String()
+}
+
 // Check Block
 type CheckStatement struct {
-	Statements []Statement  // statements (e.g. var declarations) before assertions
+	Token      lexer.Token // the check token
+	Statements []Statement // statements (e.g. var declarations) before assertions
 	Assertions []*Assertion
 	Label      string // optional label for test group
 }
 
-func (cs *CheckStatement) statementNode() {}
+func (cs *CheckStatement) statementNode()        {}
+func (cs *CheckStatement) Position() lexer.Token { return cs.Token }
 func (cs *CheckStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("check")
@@ -389,10 +476,12 @@ func (cs *CheckStatement) String() string {
 
 // Return Statement
 type ReturnStatement struct {
+	Token  lexer.Token  // the return token
 	Values []Expression // support multiple return values (backward compatible with single value)
 }
 
-func (rs *ReturnStatement) statementNode() {}
+func (rs *ReturnStatement) statementNode()        {}
+func (rs *ReturnStatement) Position() lexer.Token { return rs.Token }
 func (rs *ReturnStatement) String() string {
 	var out bytes.Buffer
 	out.WriteString("return")
@@ -409,19 +498,101 @@ func (rs *ReturnStatement) String() string {
 
 // Break Statement
 type BreakStatement struct {
+	Token lexer.Token // the break token
+	Label *Identifier // optional, the loop to break out of
 }
 
-func (bs *BreakStatement) statementNode() {}
+func (bs *BreakStatement) statementNode()        {}
+func (bs *BreakStatement) Position() lexer.Token { return bs.Token }
 func (bs *BreakStatement) String() string {
+	if bs.Label != nil {
+		return "break " + bs.Label.String()
+	}
 	return "break"
 }
 
+// Continue Statement
+type ContinueStatement struct {
+	Token lexer.Token // the continue token
+	Label *Identifier // optional, the loop to continue
+}
+
+func (cs *ContinueStatement) statementNode()        {}
+func (cs *ContinueStatement) Position() lexer.Token { return cs.Token }
+func (cs *ContinueStatement) String() string {
+	if cs.Label != nil {
+		return "continue " + cs.Label.String()
+	}
+	return "continue"
+}
+
+// Try Statement
+type TryStatement struct {
+	Token       lexer.Token // the try token
+	Body        *BlockStatement
+	ErrorName   *Identifier // name bound to the caught error, nil when catch has no name
+	CatchBlock  *BlockStatement
+	EnsureBlock *BlockStatement
+}
+
+func (ts *TryStatement) statementNode()        {}
+func (ts *TryStatement) Position() lexer.Token { return ts.Token }
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+	out.WriteString("try ::")
+	out.WriteString(ts.Body.String())
+	if ts.CatchBlock != nil {
+		out.WriteString("catch ")
+		if ts.ErrorName != nil {
+			out.WriteString(ts.ErrorName.String())
+			out.WriteString(" ")
+		}
+		out.WriteString("::")
+		out.WriteString(ts.CatchBlock.String())
+	}
+	if ts.EnsureBlock != nil {
+		out.WriteString("ensure ::")
+		out.WriteString(ts.EnsureBlock.String())
+	}
+	out.WriteString("end")
+	return out.String()
+}
+
+// Throw Statement
+type ThrowStatement struct {
+	Token lexer.Token // the throw token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()        {}
+func (ts *ThrowStatement) Position() lexer.Token { return ts.Token }
+func (ts *ThrowStatement) String() string {
+	return "throw " + ts.Value.String()
+}
+
+// YieldStatement hands a value to whoever is iterating a generator, pausing the function until the next one is needed
+type YieldStatement struct {
+	Token lexer.Token // the yield token
+	Value Expression  // nil yields null
+}
+
+func (ys *YieldStatement) statementNode()        {}
+func (ys *YieldStatement) Position() lexer.Token { return ys.Token }
+func (ys *YieldStatement) String() string {
+	if ys.Value == nil {
+		return "yield"
+	}
+	return "yield " + ys.Value.String()
+}
+
 // Expression Statement
 type ExpressionStatement struct {
+	Token      lexer.Token // the first token of the expression
 	Expression Expression
 }
 
-func (es *ExpressionStatement) statementNode() {}
+func (es *ExpressionStatement) statementNode()        {}
+func (es *ExpressionStatement) Position() lexer.Token { return es.Token }
 func (es *ExpressionStatement) String() string {
 	if es.Expression != nil {
 		return es.Expression.String()
@@ -431,10 +602,12 @@ func (es *ExpressionStatement) String() string {
 
 // Block Statement
 type BlockStatement struct {
+	Token      lexer.Token // the :: token that opens the block
 	Statements []Statement
 }
 
-func (bs *BlockStatement) statementNode() {}
+func (bs *BlockStatement) statementNode()        {}
+func (bs *BlockStatement) Position() lexer.Token { return bs.Token }
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 	for _, s := range bs.Statements {
@@ -448,52 +621,200 @@ func (bs *BlockStatement) String() string {
 
 // Parameter represents function parameters
 type Parameter struct {
-	Name *Identifier
-	Type *TypeAnnotation
+	Token   lexer.Token     // the parameter name token, or ... for rest parameters
+	Name    *Identifier     // for destructured parameters, the source of the pattern
+	Pattern Expression      // optional, destructures the argument, e.g. fn f([x, y])
+	Type    *TypeAnnotation // for rest parameters, the type of each collected argument
+	Default Expression      // optional, evaluated when the argument is left out
+	Rest    bool            // set for ...name, which collects the remaining arguments into an array
 }
 
+func (p *Parameter) Position() lexer.Token { return p.Token }
 func (p *Parameter) String() string {
 	var out bytes.Buffer
+	if p.Rest {
+		out.WriteString("...")
+	}
 	out.WriteString(p.Name.String())
 	if p.Type != nil {
 		out.WriteString(": ")
 		out.WriteString(p.Type.String())
 	}
+	if p.Default != nil {
+		out.WriteString(" = ")
+		out.WriteString(p.Default.String())
+	}
 	return out.String()
 }
 
 // StructField represents struct field definitions
 type StructField struct {
-	Name *Identifier
-	Type *TypeAnnotation
+	Token lexer.Token // the field name token
+	Name  *Identifier
+	Type  *TypeAnnotation
 }
 
+func (sf *StructField) Position() lexer.Token { return sf.Token }
 func (sf *StructField) String() string {
+	// Variant fields may leave out their type
+	if sf.Type == nil {
+		return sf.Name.String()
+	}
 	return sf.Name.String() + ": " + sf.Type.String()
 }
 
+// Variant represents one case of a sum type, with fields declared like struct fields
+type Variant struct {
+	Token  lexer.Token // the variant name token
+	Name   *Identifier
+	Fields []*StructField // nil for variants without parentheses
+}
+
+func (v *Variant) Position() lexer.Token { return v.Token }
+func (v *Variant) String() string {
+	if v.Fields == nil {
+		return v.Name.String()
+	}
+	fields := []string{}
+	for _, field := range v.Fields {
+		fields = append(fields, field.String())
+	}
+	return v.Name.String() + "(" + strings.Join(fields, ", ") + ")"
+}
+
 // ElseIfClause represents else if clauses
 type ElseIfClause struct {
+	Token     lexer.Token // the else token
 	Condition Expression
 	Block     *BlockStatement
 }
 
 // CaseBranch represents case statement branches
 type CaseBranch struct {
-	Pattern Expression // could be literal, identifier, or wildcard
+	Token   lexer.Token // the first token of the pattern
+	Pattern Expression  // a value to compare with, the _ wildcard, a binding name or a structural pattern
+	Guard   Expression  // optional, the condition after if
 	Result  Expression
 }
 
+func (cb *CaseBranch) Position() lexer.Token { return cb.Token }
 func (cb *CaseBranch) String() string {
+	if cb.Guard != nil {
+		return cb.Pattern.String() + " if " + cb.Guard.String() + " => " + cb.Result.String()
+	}
 	return cb.Pattern.String() + " => " + cb.Result.String()
 }
 
+// Case patterns. A bare identifier in a pattern binds the matched value to that name.
+// Array and map patterns, with defaults, also destructure values in var, for and parameters.
+
+// ArrayPattern matches arrays element by element, e.g. [first, ...rest]
+type ArrayPattern struct {
+	Token    lexer.Token  // the [ token
+	Elements []Expression // patterns, with at most one RestPattern
+}
+
+func (ap *ArrayPattern) expressionNode()       {}
+func (ap *ArrayPattern) Position() lexer.Token { return ap.Token }
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// RestPattern collects the remaining array elements, e.g. ...rest
+type RestPattern struct {
+	Token lexer.Token // the ... token
+	Name  *Identifier
+}
+
+func (rp *RestPattern) expressionNode()       {}
+func (rp *RestPattern) Position() lexer.Token { return rp.Token }
+func (rp *RestPattern) String() string        { return "..." + rp.Name.String() }
+
+// MapPattern matches maps that contain the listed keys, e.g. {"type": "circle", "r": r}
+type MapPattern struct {
+	Token  lexer.Token // the { token
+	Keys   []Expression
+	Values []Expression // patterns for the values, in the same order as Keys
+}
+
+func (mp *MapPattern) expressionNode()       {}
+func (mp *MapPattern) Position() lexer.Token { return mp.Token }
+func (mp *MapPattern) String() string {
+	pairs := []string{}
+	for i, key := range mp.Keys {
+		pairs = append(pairs, key.String()+": "+mp.Values[i].String())
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// DefaultPattern gives a destructured name or pattern a value to use when the part is missing or nil,
+// e.g. the port = 80 in var {host, port = 80} = config
+type DefaultPattern struct {
+	Token   lexer.Token // the = token
+	Target  Expression
+	Default Expression
+}
+
+func (dp *DefaultPattern) expressionNode()       {}
+func (dp *DefaultPattern) Position() lexer.Token { return dp.Token }
+func (dp *DefaultPattern) String() string        { return dp.Target.String() + " = " + dp.Default.String() }
+
+// StructPattern matches struct instances by type and fields, e.g. Point(x, y: 0)
+type StructPattern struct {
+	Token     lexer.Token // the struct name token
+	Name      *Identifier
+	Arguments []Expression // patterns matched against fields in order, or NamedArguments naming the field
+}
+
+func (sp *StructPattern) expressionNode()       {}
+func (sp *StructPattern) Position() lexer.Token { return sp.Token }
+func (sp *StructPattern) String() string {
+	args := []string{}
+	for _, arg := range sp.Arguments {
+		args = append(args, arg.String())
+	}
+	return sp.Name.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+// TypePattern matches values of a type and binds them, e.g. n: number
+type TypePattern struct {
+	Token lexer.Token // the name token
+	Name  *Identifier
+	Type  *TypeAnnotation
+}
+
+func (tp *TypePattern) expressionNode()       {}
+func (tp *TypePattern) Position() lexer.Token { return tp.Token }
+func (tp *TypePattern) String() string        { return tp.Name.String() + ": " + tp.Type.String() }
+
+// AlternativePattern matches when any of its patterns does, e.g. 1 | 2
+type AlternativePattern struct {
+	Token        lexer.Token // the first token of the first alternative
+	Alternatives []Expression
+}
+
+func (ap *AlternativePattern) expressionNode()       {}
+func (ap *AlternativePattern) Position() lexer.Token { return ap.Token }
+func (ap *AlternativePattern) String() string {
+	alternatives := []string{}
+	for _, alt := range ap.Alternatives {
+		alternatives = append(alternatives, alt.String())
+	}
+	return strings.Join(alternatives, " | ")
+}
+
 // WhereBlock represents function test blocks
 type WhereBlock struct {
-	Statements []Statement  // statements (e.g. var declarations) before assertions
+	Token      lexer.Token // the where token
+	Statements []Statement // statements (e.g. var declarations) before assertions
 	Assertions []*Assertion
 }
 
+func (wb *WhereBlock) Position() lexer.Token { return wb.Token }
 func (wb *WhereBlock) String() string {
 	var out bytes.Buffer
 	out.WriteString("where ::")
@@ -511,11 +832,13 @@ func (wb *WhereBlock) String() string {
 
 // Assertion represents test assertions
 type Assertion struct {
+	Token    lexer.Token // the assertion operator token
 	Left     Expression
 	Operator string // "is", "isA", "contains", etc.
 	Right    Expression
 }
 
+func (a *Assertion) Position() lexer.Token { return a.Token }
 func (a *Assertion) String() string {
 	if a.Right != nil {
 		return a.Left.String() + " " + a.Operator + " " + a.Right.String()
@@ -528,34 +851,42 @@ func (a *Assertion) String() string {
 
 // Identifier
 type Identifier struct {
+	Token lexer.Token // the IDENT token
 	Value string
 }
 
-func (i *Identifier) expressionNode() {}
-func (i *Identifier) String() string  { return i.Value }
+func (i *Identifier) expressionNode()       {}
+func (i *Identifier) Position() lexer.Token { return i.Token }
+func (i *Identifier) String() string        { return i.Value }
 
 // Number Literal
 type NumberLiteral struct {
+	Token lexer.Token // the NUMBER token
 	Value string
 }
 
-func (nl *NumberLiteral) expressionNode() {}
-func (nl *NumberLiteral) String() string  { return nl.Value }
+func (nl *NumberLiteral) expressionNode()       {}
+func (nl *NumberLiteral) Position() lexer.Token { return nl.Token }
+func (nl *NumberLiteral) String() string        { return nl.Value }
 
 // String Literal
 type StringLiteral struct {
+	Token lexer.Token // the STRING token
 	Value string
 }
 
-func (sl *StringLiteral) expressionNode() {}
-func (sl *StringLiteral) String() string  { return "\"" + sl.Value + "\"" }
+func (sl *StringLiteral) expressionNode()       {}
+func (sl *StringLiteral) Position() lexer.Token { return sl.Token }
+func (sl *StringLiteral) String() string        { return "\"" + sl.Value + "\"" }
 
 // Interpolated String - string with embedded expressions like "Hello #{name}"
 type InterpolatedString struct {
+	Token lexer.Token  // the STRING token
 	Parts []Expression // Mix of StringLiteral and other expressions
 }
 
-func (is *InterpolatedString) expressionNode() {}
+func (is *InterpolatedString) expressionNode()       {}
+func (is *InterpolatedString) Position() lexer.Token { return is.Token }
 func (is *InterpolatedString) String() string {
 	var out bytes.Buffer
 	out.WriteString("\"")
@@ -574,10 +905,12 @@ func (is *InterpolatedString) String() string {
 
 // Boolean Literal
 type BooleanLiteral struct {
+	Token lexer.Token // the true or false token
 	Value bool
 }
 
-func (bl *BooleanLiteral) expressionNode() {}
+func (bl *BooleanLiteral) expressionNode()       {}
+func (bl *BooleanLiteral) Position() lexer.Token { return bl.Token }
 func (bl *BooleanLiteral) String() string {
 	if bl.Value {
 		return "true"
@@ -586,19 +919,24 @@ func (bl *BooleanLiteral) String() string {
 }
 
 // Nil Literal
-type NilLiteral struct{}
+type NilLiteral struct {
+	Token lexer.Token // the nil token
+}
 
-func (nl *NilLiteral) expressionNode() {}
+func (nl *NilLiteral) expressionNode()       {}
+func (nl *NilLiteral) Position() lexer.Token { return nl.Token }
 func (nl *NilLiteral) String() string {
 	return "nil"
 }
 
 // Array Literal
 type ArrayLiteral struct {
+	Token    lexer.Token // the [ token
 	Elements []Expression
 }
 
-func (al *ArrayLiteral) expressionNode() {}
+func (al *ArrayLiteral) expressionNode()       {}
+func (al *ArrayLiteral) Position() lexer.Token { return al.Token }
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 	elements := []string{}
@@ -613,6 +951,7 @@ func (al *ArrayLiteral) String() string {
 
 // Map Literal
 type MapLiteral struct {
+	Token lexer.Token // the { token
 	Pairs []MapPair
 }
 
@@ -621,7 +960,8 @@ type MapPair struct {
 	Value Expression
 }
 
-func (ml *MapLiteral) expressionNode() {}
+func (ml *MapLiteral) expressionNode()       {}
+func (ml *MapLiteral) Position() lexer.Token { return ml.Token }
 func (ml *MapLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
@@ -634,13 +974,34 @@ func (ml *MapLiteral) String() string {
 	return out.String()
 }
 
+// Set Literal: {a, b, c}, told apart from a map literal by the missing colons
+type SetLiteral struct {
+	Token    lexer.Token // the { token
+	Elements []Expression
+}
+
+func (sl *SetLiteral) expressionNode()       {}
+func (sl *SetLiteral) Position() lexer.Token { return sl.Token }
+func (sl *SetLiteral) String() string {
+	elements := []string{}
+	for _, e := range sl.Elements {
+		elements = append(elements, e.String())
+	}
+	return "{" + strings.Join(elements, ", ") + "}"
+}
+
 // Function Literal (anonymous functions)
 type FunctionLiteral struct {
+	Token      lexer.Token // the fn token, or => for arrow functions
 	Parameters []*Parameter
+	ReturnType *TypeAnnotation
 	Body       *BlockStatement
+	Arrow      bool // x => body, whose Body returns the expression
+	Generator  bool // true when the body yields
 }
 
-func (fl *FunctionLiteral) expressionNode() {}
+func (fl *FunctionLiteral) expressionNode()       {}
+func (fl *FunctionLiteral) Position() lexer.Token { return fl.Token }
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 	params := []string{}
@@ -649,7 +1010,19 @@ func (fl *FunctionLiteral) String() string {
 	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") ::")
+	out.WriteString(")")
+	if fl.ReturnType != nil {
+		out.WriteString(": ")
+		out.WriteString(fl.ReturnType.String())
+	}
+	if fl.Arrow {
+		out.WriteString(" =>")
+		for _, stmt := range fl.Body.Statements {
+			out.WriteString(strings.TrimPrefix(stmt.String(), "return"))
+		}
+		return out.String()
+	}
+	out.WriteString(" ::")
 	out.WriteString(fl.Body.String())
 	out.WriteString("end")
 	return out.String()
@@ -657,11 +1030,13 @@ func (fl *FunctionLiteral) String() string {
 
 // Prefix Expression
 type PrefixExpression struct {
+	Token    lexer.Token // the prefix operator token
 	Operator string
 	Right    Expression
 }
 
-func (pe *PrefixExpression) expressionNode() {}
+func (pe *PrefixExpression) expressionNode()       {}
+func (pe *PrefixExpression) Position() lexer.Token { return pe.Token }
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -673,12 +1048,14 @@ func (pe *PrefixExpression) String() string {
 
 // Infix Expression
 type InfixExpression struct {
+	Token    lexer.Token // the operator token
 	Left     Expression
 	Operator string
 	Right    Expression
 }
 
-func (ie *InfixExpression) expressionNode() {}
+func (ie *InfixExpression) expressionNode()       {}
+func (ie *InfixExpression) Position() lexer.Token { return ie.Token }
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
@@ -691,11 +1068,14 @@ func (ie *InfixExpression) String() string {
 
 // Call Expression
 type CallExpression struct {
+	Token     lexer.Token // the ( token
 	Function  Expression
 	Arguments []Expression
+	Optional  bool // f?.() evaluates to null instead of calling a null function
 }
 
-func (ce *CallExpression) expressionNode() {}
+func (ce *CallExpression) expressionNode()       {}
+func (ce *CallExpression) Position() lexer.Token { return ce.Token }
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 	args := []string{}
@@ -703,23 +1083,45 @@ func (ce *CallExpression) String() string {
 		args = append(args, a.String())
 	}
 	out.WriteString(ce.Function.String())
+	if ce.Optional {
+		out.WriteString("?.")
+	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
 	out.WriteString(")")
 	return out.String()
 }
 
+// Named Argument (name: value inside a call)
+type NamedArgument struct {
+	Token lexer.Token // the argument name token
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) expressionNode()       {}
+func (na *NamedArgument) Position() lexer.Token { return na.Token }
+func (na *NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
+}
+
 // Index Expression
 type IndexExpression struct {
-	Left  Expression
-	Index Expression
+	Token    lexer.Token // the [ or ?[ token
+	Left     Expression
+	Index    Expression
+	Optional bool // a?[i] evaluates to null when a is null
 }
 
-func (ie *IndexExpression) expressionNode() {}
+func (ie *IndexExpression) expressionNode()       {}
+func (ie *IndexExpression) Position() lexer.Token { return ie.Token }
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString("[")
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
@@ -728,14 +1130,20 @@ func (ie *IndexExpression) String() string {
 
 // Dot Expression (property access)
 type DotExpression struct {
+	Token    lexer.Token // the . or ?. token
 	Left     Expression
 	Property *Identifier
+	Optional bool // a?.b evaluates to null when a is null
 }
 
-func (de *DotExpression) expressionNode() {}
+func (de *DotExpression) expressionNode()       {}
+func (de *DotExpression) Position() lexer.Token { return de.Token }
 func (de *DotExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString(de.Left.String())
+	if de.Optional {
+		out.WriteString("?")
+	}
 	out.WriteString(".")
 	out.WriteString(de.Property.String())
 	return out.String()
@@ -743,27 +1151,59 @@ func (de *DotExpression) String() string {
 
 // Assignment Expression
 type AssignmentExpression struct {
-	Left  Expression
-	Value Expression
+	Token    lexer.Token // the = or compound assignment token
+	Left     Expression
+	Operator string // "=", or a compound operator like "+=" that combines with the current value
+	Value    Expression
+}
+
+// IsCompound reports whether the assignment combines the value with the target's current value
+func (ae *AssignmentExpression) IsCompound() bool {
+	return ae.Operator != "" && ae.Operator != "="
+}
+
+// BinaryOperator returns the infix operator a compound assignment applies, like "+" for +=
+func (ae *AssignmentExpression) BinaryOperator() string {
+	return strings.TrimSuffix(ae.Operator, "=")
 }
 
-func (ae *AssignmentExpression) expressionNode() {}
+func (ae *AssignmentExpression) expressionNode()       {}
+func (ae *AssignmentExpression) Position() lexer.Token { return ae.Token }
 func (ae *AssignmentExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString(ae.Left.String())
-	out.WriteString(" = ")
+	if ae.IsCompound() {
+		out.WriteString(" " + ae.Operator + " ")
+	} else {
+		out.WriteString(" = ")
+	}
 	out.WriteString(ae.Value.String())
 	return out.String()
 }
 
+// Pipeline Expression (value |> f or value |> f(args))
+type PipelineExpression struct {
+	Token lexer.Token // the |> token
+	Left  Expression  // the value passed as the first argument
+	Right Expression  // a function, or a call that receives Left before its own arguments
+}
+
+func (pe *PipelineExpression) expressionNode()       {}
+func (pe *PipelineExpression) Position() lexer.Token { return pe.Token }
+func (pe *PipelineExpression) String() string {
+	return "(" + pe.Left.String() + " |> " + pe.Right.String() + ")"
+}
+
 // Range Expression
 type RangeExpression struct {
+	Token     lexer.Token // the range operator token
 	Start     Expression
 	End       Expression
 	Inclusive bool // true for ..., false for ..
 }
 
-func (re *RangeExpression) expressionNode() {}
+func (re *RangeExpression) expressionNode()       {}
+func (re *RangeExpression) Position() lexer.Token { return re.Token }
 func (re *RangeExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString(re.Start.String())
@@ -778,10 +1218,12 @@ func (re *RangeExpression) String() string {
 
 // Type Annotation
 type TypeAnnotation struct {
+	Token      lexer.Token // the type name token
 	Name       string
 	Parameters []*TypeAnnotation // for generic types like Array[String]
 }
 
+func (ta *TypeAnnotation) Position() lexer.Token { return ta.Token }
 func (ta *TypeAnnotation) String() string {
 	var out bytes.Buffer
 	out.WriteString(ta.Name)
@@ -0,0 +1,272 @@
+package checker
+
+// global_functions are the functions available in every program
+var global_functions = map[string]*static_type{
+	"print":   function_type,
+	"println": function_type,
+	"isNull":  function_type,
+	"error":   function_type,
+	"decimal": function_type,
+	"Set":     function_type,
+	"Tuple":   function_type,
+}
+
+// global_objects are the built-in modules and type registries
+var global_objects = map[string]*static_type{
+	"Math":   module_type,
+	"File":   module_type,
+	"JSON":   module_type,
+	"OS":     module_type,
+	"Time":   module_type,
+	"UI":     module_type,
+	"Seq":    module_type,
+	"Object": module_type,
+	"Array":  map_type,
+	"String": map_type,
+	"Number": map_type,
+	"Map":    map_type,
+}
+
+// builtin_methods lists the methods each built-in type provides, with the type they return.
+// Keep in sync with the method switches in evaluator/objects_methods.go.
+var builtin_methods = map[string]map[string]*static_type{
+	"string": {
+		"length":        number_type,
+		"upper":         string_type,
+		"lower":         string_type,
+		"substr":        string_type,
+		"split":         array_of(string_type),
+		"trim":          string_type,
+		"replace":       string_type,
+		"starts_with":   boolean_type,
+		"ends_with":     boolean_type,
+		"index_of":      number_type,
+		"char_at":       string_type,
+		"trim_left":     string_type,
+		"trim_right":    string_type,
+		"contains":      boolean_type,
+		"last_index_of": number_type,
+		"count":         number_type,
+		"replace_first": string_type,
+		"reverse":       string_type,
+		"repeat":        string_type,
+		"lines":         array_of(string_type),
+		"chars":         array_of(string_type),
+		"words":         array_of(string_type),
+		"capitalize":    string_type,
+		"title_case":    string_type,
+		"is_empty":      boolean_type,
+		"is_blank":      boolean_type,
+		"is_numeric":    boolean_type,
+		"is_alpha":      boolean_type,
+		"pad_left":      string_type,
+		"pad_right":     string_type,
+	},
+	"array": {
+		"length":         number_type,
+		"push":           any_type,
+		"pop":            any_type,
+		"first":          any_type,
+		"last":           any_type,
+		"rest":           array_type,
+		"map":            array_type,
+		"filter":         array_type,
+		"reduce":         any_type,
+		"each":           any_type,
+		"map_with_index": array_type,
+		"find":           any_type,
+		"find_index":     number_type,
+		"any":            boolean_type,
+		"all":            boolean_type,
+		"none":           boolean_type,
+		"count":          number_type,
+		"sort":           array_type,
+		"sort_by":        array_type,
+		"reverse":        array_type,
+		"unique":         array_type,
+		"slice":          array_type,
+		"take":           array_type,
+		"drop":           array_type,
+		"concat":         array_type,
+		"flatten":        array_type,
+		"flat_map":       array_type,
+		"contains":       boolean_type,
+		"index_of":       number_type,
+		"last_index_of":  number_type,
+		"join":           string_type,
+		"sum":            number_type,
+		"average":        number_type,
+		"min":            any_type,
+		"max":            any_type,
+		"chunk":          array_type,
+		"partition":      array_type,
+		"zip":            array_type,
+		"compact":        array_type,
+	},
+	"number": {
+		"to_string":  string_type,
+		"abs":        number_type,
+		"floor":      number_type,
+		"ceil":       number_type,
+		"round":      number_type,
+		"sqrt":       number_type,
+		"is_integer": boolean_type,
+	},
+	"boolean": {
+		"to_string": string_type,
+	},
+	"error": {
+		"to_string": string_type,
+		"message":   string_type,
+		"file":      string_type,
+		"line":      number_type,
+		"traceback": string_type,
+		"kind":      string_type,
+		"cause":     any_type,
+		"data":      map_type,
+		"wrap":      error_type,
+		"is":        boolean_type,
+	},
+	"iterator": {
+		"next":       any_type,
+		"close":      null_type,
+		"to_array":   array_type,
+		"map":        iterator_type,
+		"filter":     iterator_type,
+		"take":       iterator_type,
+		"drop":       iterator_type,
+		"take_while": iterator_type,
+		"zip":        iterator_type,
+		"chunk":      iterator_type,
+		"window":     iterator_type,
+		"enumerate":  iterator_type,
+		"flat_map":   iterator_type,
+		"collect":    array_type,
+		"reduce":     any_type,
+		"count":      number_type,
+		"first":      any_type,
+		"find":       any_type,
+		"find_index": number_type,
+		"any":        boolean_type,
+		"all":        boolean_type,
+		"none":       boolean_type,
+		"contains":   boolean_type,
+		"index_of":   number_type,
+	},
+	"map": {
+		"length":     number_type,
+		"is_empty":   boolean_type,
+		"keys":       array_type,
+		"values":     array_type,
+		"entries":    array_type,
+		"to_array":   array_type,
+		"has":        boolean_type,
+		"get":        any_type,
+		"delete":     any_type,
+		"clear":      map_type,
+		"merge":      map_type,
+		"deep_merge": map_type,
+		"filter":     any_type, // an iterator on maps with a next or iter method
+		"map_values": map_type,
+		"invert":     map_type,
+		"group_by":   map_type,
+	},
+	"set": {
+		"length":               number_type,
+		"is_empty":             boolean_type,
+		"contains":             boolean_type,
+		"add":                  set_type,
+		"remove":               boolean_type,
+		"union":                set_type,
+		"intersection":         set_type,
+		"difference":           set_type,
+		"symmetric_difference": set_type,
+		"is_subset":            boolean_type,
+		"is_superset":          boolean_type,
+		"is_disjoint":          boolean_type,
+		"filter":               set_type,
+		"map":                  set_type,
+		"to_array":             array_type,
+	},
+	"tuple": {
+		"length":   number_type,
+		"to_array": array_type,
+	},
+	"time": {
+		"format":      string_type,
+		"to_string":   string_type,
+		"unix":        number_type,
+		"unix_millis": number_type,
+		"year":        number_type,
+		"month":       number_type,
+		"day":         number_type,
+		"hour":        number_type,
+		"minute":      number_type,
+		"second":      number_type,
+		"weekday":     number_type,
+		"add_seconds": time_type,
+		"add_minutes": time_type,
+		"add_hours":   time_type,
+		"add_days":    time_type,
+		"diff":        number_type,
+		"is_before":   boolean_type,
+		"is_after":    boolean_type,
+	},
+}
+
+// type_display_names match the receiver names used by runtime method errors
+var type_display_names = map[string]string{
+	"string":   "String",
+	"array":    "Array",
+	"number":   "Number",
+	"boolean":  "Boolean",
+	"error":    "Error",
+	"time":     "Time",
+	"iterator": "Iterator",
+	"map":      "Map",
+	"set":      "Set",
+	"tuple":    "Tuple",
+}
+
+// collected_methods are the array methods sets and tuples run over their elements.
+// Keep in sync with collected_methods in evaluator.
+var collected_methods = []string{
+	"length", "first", "last", "rest", "each", "map_with_index", "find", "find_index", "any", "all", "none",
+	"sort", "sort_by", "reverse", "unique", "slice", "concat", "flatten", "contains", "index_of",
+	"last_index_of", "join", "sum", "average", "min", "max", "partition", "compact",
+}
+
+// operator_methods names the method an infix operator calls on a struct instance, map or array.
+// Keep in sync with operator_methods in evaluator.
+var operator_methods = map[string]string{
+	"+":  "__add",
+	"-":  "__sub",
+	"*":  "__mul",
+	"/":  "__div",
+	"%":  "__mod",
+	"==": "__eq",
+	"<":  "__lt",
+	"<=": "__le",
+	">":  "__gt",
+	">=": "__ge",
+}
+
+// Iterators run the other array methods over their collected values, except the ones that modify the array
+// in place. Keep in sync with sequence_methods and collected_methods in evaluator.
+func init() {
+	iterator := builtin_methods["iterator"]
+	for name, result := range builtin_methods["array"] {
+		if _, lazy := iterator[name]; !lazy && name != "push" && name != "pop" {
+			iterator[name] = result
+		}
+	}
+
+	for _, receiver := range []string{"set", "tuple"} {
+		methods := builtin_methods[receiver]
+		for _, name := range collected_methods {
+			if _, own := methods[name]; !own {
+				methods[name] = builtin_methods["array"][name]
+			}
+		}
+	}
+}
@@ -0,0 +1,1041 @@
+// Package checker finds type errors in Seda programs without running them
+package checker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/vpaulo/seda/ast"
+	"github.com/vpaulo/seda/lexer"
+	"github.com/vpaulo/seda/parser"
+)
+
+// Diagnostic is a problem found in a program before it runs
+type Diagnostic struct {
+	Line    int
+	Column  int
+	Message string
+	Warning bool // set for likely mistakes that do not stop the program from running
+}
+
+func (d Diagnostic) String() string {
+	if d.Warning {
+		return fmt.Sprintf("line %d:%d: warning: %s", d.Line, d.Column, d.Message)
+	}
+	return fmt.Sprintf("line %d:%d: %s", d.Line, d.Column, d.Message)
+}
+
+// symbol is a name visible in a scope
+type symbol struct {
+	typ         *static_type
+	annotation  *ast.TypeAnnotation // declared type of typed variables
+	alias       *ast.TypeAnnotation // set for type aliases
+	is_constant bool
+}
+
+type scope struct {
+	symbols map[string]*symbol
+	outer   *scope
+}
+
+func new_scope(outer *scope) *scope {
+	return &scope{symbols: make(map[string]*symbol), outer: outer}
+}
+
+func (s *scope) lookup(name string) *symbol {
+	for current := s; current != nil; current = current.outer {
+		if sym, ok := current.symbols[name]; ok {
+			return sym
+		}
+	}
+	return nil
+}
+
+func (s *scope) define(name string, sym *symbol) {
+	s.symbols[name] = sym
+}
+
+// Checker walks a program and collects diagnostics
+type Checker struct {
+	source_dir  string
+	diagnostics []Diagnostic
+	scope       *scope
+	function    *function_info  // function whose body is being checked
+	properties  map[string]bool // property names assigned anywhere, callable as methods on any value
+	lenient     bool            // set when a using statement could not be inspected
+	quiet       int             // diagnostics are dropped while positive
+	rehearsing  int             // positive during the silent walk of a loop body
+	position    *lexer.Token    // overrides node positions inside string interpolations
+	loops       []string        // labels of the enclosing loops, innermost last; "" when unlabeled
+	deferred    []func()
+}
+
+// Check type checks a parsed program. source_dir is used to inspect local using statements.
+func Check(program *ast.Program, source_dir string) []Diagnostic {
+	// A silent first pass collects the property names assigned anywhere in the program,
+	// since custom methods may be attached after the code that calls them
+	properties := make(map[string]bool)
+	rehearsal := &Checker{source_dir: source_dir, properties: properties, quiet: 1}
+	rehearsal.run(program)
+
+	c := &Checker{source_dir: source_dir, properties: properties}
+	c.run(program)
+
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		if c.diagnostics[i].Line != c.diagnostics[j].Line {
+			return c.diagnostics[i].Line < c.diagnostics[j].Line
+		}
+		return c.diagnostics[i].Column < c.diagnostics[j].Column
+	})
+	return c.diagnostics
+}
+
+func (c *Checker) run(program *ast.Program) {
+	c.scope = new_scope(nil)
+	c.block(program.Statements)
+
+	// Function bodies and check blocks run after the code around them, so they see every declaration
+	for len(c.deferred) > 0 {
+		next := c.deferred[0]
+		c.deferred = c.deferred[1:]
+		next()
+	}
+}
+
+// report records a diagnostic at the position of node
+func (c *Checker) report(node ast.Node, format string, a ...interface{}) {
+	c.add(node, false, fmt.Sprintf(format, a...))
+}
+
+// warn records a warning at the position of node
+func (c *Checker) warn(node ast.Node, format string, a ...interface{}) {
+	c.add(node, true, fmt.Sprintf(format, a...))
+}
+
+func (c *Checker) add(node ast.Node, warning bool, message string) {
+	if c.quiet > 0 {
+		return
+	}
+
+	token := position_of(node)
+	if c.position != nil {
+		token = *c.position
+	}
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Line:    token.Line,
+		Column:  token.Column,
+		Message: message,
+		Warning: warning,
+	})
+}
+
+// later queues work to run once the enclosing program has been checked
+func (c *Checker) later(s *scope, fn *function_info, work func()) {
+	// Loop bodies are walked twice; only the second walk schedules work
+	if c.rehearsing > 0 {
+		return
+	}
+
+	position, quiet := c.position, c.quiet
+	c.deferred = append(c.deferred, func() {
+		saved_scope, saved_function, saved_position, saved_quiet := c.scope, c.function, c.position, c.quiet
+		c.scope, c.function, c.position, c.quiet = s, fn, position, quiet
+		// Deferred bodies run outside any loop that encloses their declaration
+		saved_loops := c.loops
+		c.loops = nil
+		work()
+		c.loops = saved_loops
+		c.scope, c.function, c.position, c.quiet = saved_scope, saved_function, saved_position, saved_quiet
+	})
+}
+
+// with_scope runs work in a new scope nested in the current one
+func (c *Checker) with_scope(work func()) {
+	saved := c.scope
+	c.scope = new_scope(saved)
+	work()
+	c.scope = saved
+}
+
+// position_of finds the token that best locates a node in a diagnostic.
+// Operators and calls are reported where their left operand starts.
+func position_of(node ast.Node) lexer.Token {
+	switch n := node.(type) {
+	case *ast.InfixExpression:
+		return position_of(n.Left)
+	case *ast.CallExpression:
+		return position_of(n.Function)
+	case *ast.IndexExpression:
+		return position_of(n.Left)
+	case *ast.DotExpression:
+		return n.Property.Token
+	case *ast.AssignmentExpression:
+		return position_of(n.Left)
+	case *ast.RangeExpression:
+		return position_of(n.Start)
+	case *ast.ExpressionStatement:
+		return position_of(n.Expression)
+	case nil:
+		return lexer.Token{}
+	}
+	return node.Position()
+}
+
+// hoist declares the functions, structs, types and modules of a block before it runs,
+// so that function bodies may refer to declarations that follow them
+func (c *Checker) hoist(statements []ast.Statement) {
+	for _, stmt := range statements {
+		switch node := stmt.(type) {
+		case *ast.StructStatement:
+			info := &struct_info{
+				name:    node.Name.Value,
+				fields:  node.Fields,
+				methods: make(map[string]*function_info),
+				scope:   c.scope,
+			}
+			c.scope.define(node.Name.Value, &symbol{typ: &static_type{name: "struct", struct_info: info}})
+		case *ast.TypeStatement:
+			if node.Variants != nil {
+				c.sum_type(node)
+				continue
+			}
+			c.scope.define(node.Name.Value, &symbol{typ: any_type, alias: node.Type})
+		case *ast.ModuleStatement:
+			c.scope.define(node.Name.Value, &symbol{typ: module_type})
+		case *ast.ComponentStatement:
+			c.scope.define(node.Name.Value, &symbol{typ: function_type})
+		}
+	}
+
+	// Functions go second so methods can find their struct
+	for _, stmt := range statements {
+		node, ok := stmt.(*ast.FnStatement)
+		if !ok || node.Name == nil {
+			continue
+		}
+		info := &function_info{
+			name:        node.Name.Value,
+			parameters:  node.Parameters,
+			return_type: node.ReturnType,
+			scope:       c.scope,
+			generator:   node.Generator,
+		}
+		if node.Receiver != nil {
+			info.name = node.Receiver.Name + "." + node.Name.Value
+			for _, receiver := range c.receiver_structs(node) {
+				receiver.methods[node.Name.Value] = info
+			}
+			continue
+		}
+		c.scope.define(node.Name.Value, &symbol{typ: &static_type{name: "function", function: info}})
+	}
+}
+
+// sum_type declares a sum type and its variants, which are constructed like structs.
+// Variants without fields are values rather than constructors.
+func (c *Checker) sum_type(node *ast.TypeStatement) {
+	sum := &sum_info{name: node.Name.Value}
+	for _, variant := range node.Variants {
+		info := &struct_info{
+			name:    variant.Name.Value,
+			fields:  variant.Fields,
+			methods: make(map[string]*function_info),
+			scope:   c.scope,
+			sum:     sum,
+		}
+		sum.variants = append(sum.variants, info)
+
+		if variant.Fields == nil {
+			c.scope.define(variant.Name.Value, &symbol{typ: instance_of(info)})
+		} else {
+			c.scope.define(variant.Name.Value, &symbol{typ: &static_type{name: "struct", struct_info: info}})
+		}
+	}
+	c.scope.define(node.Name.Value, &symbol{typ: &static_type{name: "type", sum_info: sum}})
+}
+
+// receiver_structs returns the structs a method declaration attaches to, like method_receivers:
+// a struct, a variant of a sum type, or every variant of a sum type
+func (c *Checker) receiver_structs(node *ast.FnStatement) []*struct_info {
+	if node.Receiver == nil {
+		return nil
+	}
+	sym := c.scope.lookup(node.Receiver.Name)
+	switch {
+	case sym == nil:
+		return nil
+	case sym.typ.name == "struct", sym.typ.is_instance() && sym.typ.struct_info.sum != nil:
+		return []*struct_info{sym.typ.struct_info}
+	case sym.typ.name == "type" && sym.typ.sum_info != nil:
+		return sym.typ.sum_info.variants
+	}
+	return nil
+}
+
+// receiver_type returns the type of self in a method declaration
+func (c *Checker) receiver_type(node *ast.FnStatement) *static_type {
+	sym := c.scope.lookup(node.Receiver.Name)
+	if sym.typ.sum_info != nil {
+		return value_of(sym.typ.sum_info)
+	}
+	return instance_of(sym.typ.struct_info)
+}
+
+// block checks statements in the current scope
+func (c *Checker) block(statements []ast.Statement) {
+	c.hoist(statements)
+	for _, stmt := range statements {
+		c.statement(stmt)
+	}
+}
+
+// nested_block checks a block statement in its own scope
+func (c *Checker) nested_block(block *ast.BlockStatement) {
+	if block == nil {
+		return
+	}
+	c.with_scope(func() {
+		c.block(block.Statements)
+	})
+}
+
+// loop_body checks a loop body twice: first silently, so variables reassigned later
+// in the body are widened, then again reporting diagnostics
+func (c *Checker) loop_body(label *ast.Identifier, declare func(), block *ast.BlockStatement) {
+	name := ""
+	if label != nil {
+		name = label.Value
+	}
+	c.loops = append(c.loops, name)
+	defer func() { c.loops = c.loops[:len(c.loops)-1] }()
+
+	for _, rehearse := range []int{1, 0} {
+		c.quiet += rehearse
+		c.rehearsing += rehearse
+		c.with_scope(func() {
+			declare()
+			if block != nil {
+				c.block(block.Statements)
+			}
+		})
+		c.quiet -= rehearse
+		c.rehearsing -= rehearse
+	}
+}
+
+// loop_control checks that break and continue appear inside a matching loop
+func (c *Checker) loop_control(node ast.Node, keyword string, label *ast.Identifier) {
+	if label == nil {
+		if len(c.loops) == 0 {
+			c.report(node, "%s outside of a loop", keyword)
+		}
+		return
+	}
+	for _, name := range c.loops {
+		if name == label.Value {
+			return
+		}
+	}
+	c.report(label, "no enclosing loop labeled '%s'", label.Value)
+}
+
+func (c *Checker) statement(stmt ast.Statement) {
+	switch node := stmt.(type) {
+	case *ast.ExpressionStatement:
+		c.expression(node.Expression)
+	case *ast.VarStatement:
+		c.var_statement(node)
+	case *ast.FnStatement:
+		c.fn_statement(node)
+	case *ast.ReturnStatement:
+		c.return_statement(node)
+	case *ast.BlockStatement:
+		c.nested_block(node)
+	case *ast.IfStatement:
+		c.expression(node.Condition)
+		c.nested_block(node.ThenBlock)
+		for _, else_if := range node.ElseIfs {
+			c.expression(else_if.Condition)
+			c.nested_block(else_if.Block)
+		}
+		c.nested_block(node.ElseBlock)
+	case *ast.ForStatement:
+		c.for_statement(node)
+	case *ast.WhileStatement:
+		c.loop_body(node.Label, func() {
+			c.expression(node.Condition)
+		}, node.Body)
+	case *ast.BreakStatement:
+		c.loop_control(node, "break", node.Label)
+	case *ast.ContinueStatement:
+		c.loop_control(node, "continue", node.Label)
+	case *ast.TryStatement:
+		c.nested_block(node.Body)
+		if node.CatchBlock != nil {
+			c.with_scope(func() {
+				if node.ErrorName != nil {
+					c.scope.define(node.ErrorName.Value, &symbol{typ: error_type})
+				}
+				c.block(node.CatchBlock.Statements)
+			})
+		}
+		c.nested_block(node.EnsureBlock)
+	case *ast.ThrowStatement:
+		c.expression(node.Value)
+	case *ast.YieldStatement:
+		if node.Value != nil {
+			c.expression(node.Value)
+		}
+	case *ast.CaseStatement:
+		c.case_branches(node.Expression, node.Branches)
+	case *ast.CheckStatement:
+		c.later(c.scope, c.function, func() {
+			c.with_scope(func() {
+				c.block(node.Statements)
+				c.assertions(node.Assertions)
+			})
+		})
+	case *ast.StructStatement:
+		for _, field := range node.Fields {
+			c.check_annotation(field.Type, field.Name)
+		}
+	case *ast.TypeStatement:
+		c.check_annotation(node.Type, node.Name)
+		for _, variant := range node.Variants {
+			for _, field := range variant.Fields {
+				c.check_annotation(field.Type, field.Name)
+			}
+		}
+	case *ast.ModuleStatement:
+		c.nested_block(node.Body)
+	case *ast.UsingStatement:
+		c.using_statement(node)
+	}
+}
+
+// check_annotation reports annotation names that are not known types
+func (c *Checker) check_annotation(annotation *ast.TypeAnnotation, at ast.Node) {
+	for _, name := range unknown_types(annotation, c.scope) {
+		c.report(at, "unknown type '%s'", name)
+	}
+}
+
+func (c *Checker) var_statement(node *ast.VarStatement) {
+	value := c.expression(node.Value)
+
+	if node.Pattern != nil {
+		if node.Type != nil {
+			c.check_annotation(node.Type, node)
+			if declared := resolve_annotation(node.Type, c.scope); !assignable(declared, value) {
+				c.report(node.Value, "type error: variable '%s' expects %s, got %s", node.Pattern.String(), node.Type.String(), value)
+			}
+		}
+		c.declare_binding(node.Pattern, value, node.IsConstant)
+		return
+	}
+
+	// Multiple names destructure a multiple return value
+	if len(node.Names) != 1 {
+		value = any_type
+	}
+
+	declared := value
+	if node.Type != nil {
+		c.check_annotation(node.Type, node)
+		declared = resolve_annotation(node.Type, c.scope)
+		if len(node.Names) == 1 && !assignable(declared, value) {
+			c.report(node.Value, "type error: variable '%s' expects %s, got %s", node.Names[0].Value, node.Type.String(), value)
+		}
+	}
+
+	for _, name := range node.Names {
+		c.scope.define(name.Value, &symbol{typ: declared, annotation: node.Type, is_constant: node.IsConstant})
+	}
+}
+
+func (c *Checker) fn_statement(node *ast.FnStatement) {
+	var info *function_info
+	receivers := c.receiver_structs(node)
+	switch {
+	case node.Receiver != nil && receivers == nil:
+		if sym := c.scope.lookup(node.Receiver.Name); sym == nil {
+			c.report(node, "cannot declare method on %s: not defined", node.Receiver.Name)
+		} else if !sym.typ.is_any() {
+			c.report(node, "cannot declare method on %s: not a struct", node.Receiver.Name)
+		}
+		return
+	case receivers != nil:
+		info = receivers[0].methods[node.Name.Value]
+	default:
+		if sym := c.scope.lookup(node.Name.Value); sym != nil {
+			info = sym.typ.function
+		}
+	}
+	if info == nil {
+		return
+	}
+
+	for _, param := range node.Parameters {
+		c.check_annotation(param.Type, param.Name)
+	}
+	c.check_annotation(node.ReturnType, node)
+
+	c.function_body(info, node.Body, func() {
+		if receivers != nil {
+			c.scope.define("self", &symbol{typ: c.receiver_type(node)})
+		}
+	})
+
+	if node.WhereBlock != nil {
+		c.later(c.scope, info, func() {
+			c.with_scope(func() {
+				c.declare_parameters(info)
+				c.scope.define("result", &symbol{typ: info.result()})
+				for _, name := range []string{"arg0", "arg1", "arg2"} {
+					c.scope.define(name, &symbol{typ: any_type})
+				}
+				c.block(node.WhereBlock.Statements)
+				c.assertions(node.WhereBlock.Assertions)
+			})
+		})
+	}
+}
+
+// function_body schedules a function body to be checked with its parameters in scope
+func (c *Checker) function_body(info *function_info, body *ast.BlockStatement, declare func()) {
+	if body == nil {
+		return
+	}
+	c.later(c.scope, info, func() {
+		c.with_scope(func() {
+			declare()
+			c.declare_parameters(info)
+			c.block(body.Statements)
+		})
+	})
+}
+
+func (c *Checker) declare_parameters(info *function_info) {
+	for _, param := range info.parameters {
+		typ := resolve_annotation(param.Type, info.scope)
+
+		// Defaults are evaluated in the function, after the parameters before them
+		if param.Default != nil {
+			value := c.expression(param.Default)
+			if param.Type != nil && !assignable(typ, value) {
+				c.report(param.Default, "type error in %s: parameter '%s' expects %s, got %s",
+					describe_function(info), param.Name.Value, param.Type.String(), value)
+			}
+		}
+
+		if param.Rest {
+			c.scope.define(param.Name.Value, &symbol{typ: array_of(typ)})
+			continue
+		}
+		if param.Pattern != nil {
+			c.declare_binding(param.Pattern, typ, false)
+			continue
+		}
+		c.scope.define(param.Name.Value, &symbol{typ: typ, annotation: param.Type})
+	}
+}
+
+func (c *Checker) return_statement(node *ast.ReturnStatement) {
+	values := []*static_type{}
+	for _, value := range node.Values {
+		values = append(values, c.expression(value))
+	}
+
+	// Generators end with a bare return; what they produce is what they yield
+	if c.function != nil && c.function.generator {
+		if len(values) > 0 && values[0] != null_type {
+			c.report(node, "%s is a generator and cannot return a value", describe_function(c.function))
+		}
+		return
+	}
+
+	if c.function == nil || c.function.return_type == nil || len(values) != 1 {
+		return
+	}
+
+	// Returning an error propagates it instead of producing a value
+	if values[0].name == "error" {
+		return
+	}
+
+	expected := resolve_annotation(c.function.return_type, c.function.scope)
+	if !assignable(expected, values[0]) {
+		c.report(node.Values[0], "type error in %s: return value expects %s, got %s",
+			describe_function(c.function), c.function.return_type.String(), values[0])
+	}
+}
+
+func (c *Checker) for_statement(node *ast.ForStatement) {
+	iterable := c.expression(node.Iterable)
+
+	// Types of the loop variables follow eval_for_statement
+	variable, index := any_type, any_type
+	switch iterable.name {
+	case "array":
+		variable, index = iterable.element, number_type
+		if variable == nil {
+			variable = any_type
+		}
+	case "string":
+		variable, index = string_type, number_type
+	case "range":
+		variable, index = number_type, number_type
+	case "iterator", "set", "tuple":
+		index = number_type
+	}
+
+	c.loop_body(node.Label, func() {
+		if node.Pattern != nil {
+			c.declare_binding(node.Pattern, variable, false)
+		} else {
+			c.scope.define(node.Variable.Value, &symbol{typ: variable})
+		}
+		if node.Index != nil {
+			c.scope.define(node.Index.Value, &symbol{typ: index})
+		}
+	}, node.Body)
+}
+
+// case_branches checks the subject, patterns and results of a case statement or expression
+func (c *Checker) case_branches(subject ast.Expression, branches []*ast.CaseBranch) *static_type {
+	subject_type := c.expression(subject)
+
+	var result *static_type
+	for i, branch := range branches {
+		if branch == nil {
+			continue
+		}
+		if branch.Guard == nil && i < len(branches)-1 && c.covers_everything(branch.Pattern) {
+			c.warn(branch.Pattern, "pattern '%s' matches every value, so the branches after it never run", branch.Pattern.String())
+		}
+
+		// Names bound by the pattern are visible in the guard and the result
+		var branch_type *static_type
+		c.with_scope(func() {
+			c.pattern(branch.Pattern, subject_type)
+			if branch.Guard != nil {
+				c.expression(branch.Guard)
+			}
+			branch_type = c.expression(branch.Result)
+		})
+		if result == nil {
+			result = branch_type
+		} else if !same_type(result, branch_type) {
+			result = any_type
+		}
+	}
+	c.exhaustiveness(subject, subject_type, branches)
+
+	if result == nil {
+		return any_type
+	}
+	return result
+}
+
+// pattern declares the names a case pattern binds, given the type of the value it is matched against
+func (c *Checker) pattern(pattern ast.Expression, subject *static_type) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if !c.binds(pattern.Value) {
+			// Capitalized names are values or variants, so one that is not defined is a mistake
+			if pattern.Value != "_" {
+				c.identifier(pattern)
+			}
+			return
+		}
+		c.scope.define(pattern.Value, &symbol{typ: subject})
+
+	case *ast.TypePattern:
+		c.check_annotation(pattern.Type, pattern)
+		if pattern.Name.Value != "_" {
+			c.scope.define(pattern.Name.Value, &symbol{typ: resolve_annotation(pattern.Type, c.scope)})
+		}
+
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			c.pattern(alternative, subject)
+		}
+
+	case *ast.ArrayPattern:
+		element := any_type
+		if subject != nil && subject.name == "array" && subject.element != nil {
+			element = subject.element
+		}
+		for _, item := range pattern.Elements {
+			if rest, ok := item.(*ast.RestPattern); ok {
+				if rest.Name.Value != "_" {
+					c.scope.define(rest.Name.Value, &symbol{typ: array_of(element)})
+				}
+				continue
+			}
+			c.pattern(item, element)
+		}
+
+	case *ast.MapPattern:
+		for i, key := range pattern.Keys {
+			c.expression(key)
+			c.pattern(pattern.Values[i], any_type)
+		}
+
+	case *ast.StructPattern:
+		c.struct_pattern(pattern)
+
+	default:
+		c.expression(pattern)
+	}
+}
+
+// declare_binding declares the names a destructuring var, for or parameter binds, like destructure does
+func (c *Checker) declare_binding(pattern ast.Expression, value *static_type, is_constant bool) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			c.scope.define(pattern.Value, &symbol{typ: value, is_constant: is_constant})
+		}
+
+	case *ast.DefaultPattern:
+		// The part may be missing, so the name holds either the part or the default
+		fallback := c.expression(pattern.Default)
+		if value.is_any() || value.name == "null" {
+			value = fallback
+		} else if !same_type(value, fallback) {
+			value = any_type
+		}
+		c.declare_binding(pattern.Target, value, is_constant)
+
+	case *ast.ArrayPattern:
+		element := any_type
+		switch {
+		case value.name == "array" && value.element != nil:
+			element = value.element
+		case !value.is_any() && value.name != "array":
+			c.report(pattern, "cannot destructure %s as an array", value)
+		}
+		for _, item := range pattern.Elements {
+			if rest, ok := item.(*ast.RestPattern); ok {
+				if rest.Name.Value != "_" {
+					c.scope.define(rest.Name.Value, &symbol{typ: array_of(element), is_constant: is_constant})
+				}
+				continue
+			}
+			c.declare_binding(item, element, is_constant)
+		}
+
+	case *ast.MapPattern:
+		if !value.is_any() && value.name != "map" && !value.is_instance() {
+			c.report(pattern, "cannot destructure %s as a map", value)
+		}
+		for i, key := range pattern.Keys {
+			c.expression(key)
+			part := any_type
+			if literal, ok := key.(*ast.StringLiteral); ok && value.is_instance() {
+				// Struct fields have known types
+				field := value.struct_info.field(literal.Value)
+				if field == nil {
+					c.report(key, "unknown field '%s' for %s", literal.Value, value.struct_info.name)
+				} else {
+					part = resolve_annotation(field.Type, value.struct_info.scope)
+				}
+			}
+			c.declare_binding(pattern.Values[i], part, is_constant)
+		}
+	}
+}
+
+// struct_pattern checks the fields named by a struct pattern like match_struct_pattern does
+func (c *Checker) struct_pattern(pattern *ast.StructPattern) {
+	callee := c.identifier(pattern.Name)
+	if variant := c.unit_variant(pattern.Name.Value); variant != nil {
+		callee = &static_type{name: "struct", struct_info: variant}
+	}
+	if callee.is_any() {
+		for _, arg := range pattern.Arguments {
+			if named, ok := arg.(*ast.NamedArgument); ok {
+				arg = named.Value
+			}
+			c.pattern(arg, any_type)
+		}
+		return
+	}
+	if callee.name != "struct" || callee.struct_info == nil {
+		c.report(pattern, "'%s' in pattern is not a struct", pattern.Name.Value)
+		return
+	}
+
+	info := callee.struct_info
+	if len(pattern.Arguments) > len(info.fields) {
+		c.report(pattern, "too many fields in %s pattern: got %d, want %d", info.name, len(pattern.Arguments), len(info.fields))
+		return
+	}
+	for i, arg := range pattern.Arguments {
+		field := info.fields[i]
+		if named, ok := arg.(*ast.NamedArgument); ok {
+			if field = info.field(named.Name.Value); field == nil {
+				c.report(named.Name, "unknown field '%s' for %s", named.Name.Value, info.name)
+				continue
+			}
+			arg = named.Value
+		}
+		c.pattern(arg, resolve_annotation(field.Type, info.scope))
+	}
+}
+
+// exhaustiveness warns when a case over a closed set of values can fall through without a match
+func (c *Checker) exhaustiveness(subject ast.Expression, subject_type *static_type, branches []*ast.CaseBranch) {
+	cases := c.closed_set(subject_type, branches)
+	if cases == nil {
+		return
+	}
+
+	covered := make(map[string]bool)
+	for _, branch := range branches {
+		if branch == nil || branch.Guard != nil {
+			continue
+		}
+		if c.covers_everything(branch.Pattern) {
+			return
+		}
+		for _, name := range c.covered_cases(branch.Pattern) {
+			covered[name] = true
+		}
+	}
+
+	missing := []string{}
+	for _, name := range cases {
+		if !covered[name] {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 1 {
+		c.warn(subject, "case is not exhaustive: %s is not handled", missing[0])
+	} else if len(missing) > 1 {
+		c.warn(subject, "case is not exhaustive: %s are not handled", strings.Join(missing, ", "))
+	}
+}
+
+// closed_set lists the possible cases of a subject: true and false for booleans, or the variants of a sum type.
+// Subjects of unknown type are assumed to be of the sum type their patterns destructure.
+func (c *Checker) closed_set(subject_type *static_type, branches []*ast.CaseBranch) []string {
+	sum := subject_type.sum_info
+	switch {
+	case subject_type.name == "boolean":
+		return []string{"true", "false"}
+	case subject_type.is_any():
+		for _, branch := range branches {
+			if branch != nil && sum == nil {
+				sum = c.pattern_sum(branch.Pattern)
+			}
+		}
+	}
+	if sum == nil {
+		return nil
+	}
+
+	names := []string{}
+	for _, variant := range sum.variants {
+		names = append(names, variant.name)
+	}
+	return names
+}
+
+// pattern_sum returns the sum type whose variants a pattern names, if any
+func (c *Checker) pattern_sum(pattern ast.Expression) *sum_info {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if variant := c.unit_variant(pattern.Value); variant != nil {
+			return variant.sum
+		}
+	case *ast.StructPattern:
+		if sym := c.scope.lookup(pattern.Name.Value); sym != nil && sym.typ.struct_info != nil {
+			return sym.typ.struct_info.sum
+		}
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			if sum := c.pattern_sum(alternative); sum != nil {
+				return sum
+			}
+		}
+	}
+	return nil
+}
+
+// unit_variant returns the sum type variant without fields that name refers to, if any
+func (c *Checker) unit_variant(name string) *struct_info {
+	sym := c.scope.lookup(name)
+	if sym == nil || !sym.typ.is_instance() || sym.typ.struct_info.sum == nil {
+		return nil
+	}
+	return sym.typ.struct_info
+}
+
+// constant reports whether name refers to a constant, which a case pattern compares against
+func (c *Checker) constant(name string) bool {
+	sym := c.scope.lookup(name)
+	return sym != nil && sym.is_constant
+}
+
+// binds reports whether a bare name in a case pattern binds the value, the same way match_pattern decides:
+// _, capitalized names and constants do not
+func (c *Checker) binds(name string) bool {
+	return name != "_" && !unicode.IsUpper([]rune(name)[0]) && !c.constant(name)
+}
+
+// covers_everything reports whether a pattern matches any value
+func (c *Checker) covers_everything(pattern ast.Expression) bool {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		return pattern.Value == "_" || c.binds(pattern.Value)
+	case *ast.TypePattern:
+		return strings.EqualFold(pattern.Type.Name, "any")
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			if c.covers_everything(alternative) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// covered_cases lists the booleans and variants a pattern matches in full
+func (c *Checker) covered_cases(pattern ast.Expression) []string {
+	switch pattern := pattern.(type) {
+	case *ast.BooleanLiteral:
+		return []string{fmt.Sprint(pattern.Value)}
+	case *ast.Identifier:
+		if variant := c.unit_variant(pattern.Value); variant != nil {
+			return []string{variant.name}
+		}
+	case *ast.TypePattern:
+		switch typ := resolve_annotation(pattern.Type, c.scope); {
+		case typ == boolean_type:
+			return []string{"true", "false"}
+		case typ.sum_info != nil:
+			return c.closed_set(typ, nil)
+		case typ.is_instance():
+			return []string{typ.struct_info.name}
+		}
+	case *ast.StructPattern:
+		// Only patterns that accept any field values cover the whole variant
+		for _, arg := range pattern.Arguments {
+			if named, ok := arg.(*ast.NamedArgument); ok {
+				arg = named.Value
+			}
+			if !c.covers_everything(arg) {
+				return nil
+			}
+		}
+		return []string{pattern.Name.Value}
+	case *ast.AlternativePattern:
+		names := []string{}
+		for _, alternative := range pattern.Alternatives {
+			names = append(names, c.covered_cases(alternative)...)
+		}
+		return names
+	}
+	return nil
+}
+
+func (c *Checker) assertions(assertions []*ast.Assertion) {
+	for _, assertion := range assertions {
+		// The left side of raises is expected to fail
+		if assertion.Operator == "raises" {
+			c.quiet++
+			c.expression(assertion.Left)
+			c.quiet--
+		} else {
+			c.expression(assertion.Left)
+		}
+		if assertion.Right != nil {
+			c.expression(assertion.Right)
+		}
+	}
+}
+
+// using_statement declares the modules a using statement brings into scope
+func (c *Checker) using_statement(node *ast.UsingStatement) {
+	if node.Alias != nil {
+		c.scope.define(node.Alias.Value, &symbol{typ: module_type})
+		return
+	}
+
+	names, ok := c.module_names(node.Path.Value)
+	if !ok {
+		// Without knowing which modules are imported, unknown identifiers cannot be reported
+		c.lenient = true
+		return
+	}
+	for _, name := range names {
+		c.scope.define(name, &symbol{typ: module_type})
+	}
+}
+
+// module_names lists the modules declared by a local module file
+func (c *Checker) module_names(path string) ([]string, bool) {
+	// Standard library and third-party packages live outside the project
+	if strings.HasPrefix(path, "std/") || strings.Contains(path, "github.com/") ||
+		strings.Contains(path, "gitlab.com/") || strings.Contains(path, "bitbucket.org/") {
+		return nil, false
+	}
+
+	if !filepath.IsAbs(path) {
+		if !strings.HasPrefix(path, "./") && !strings.HasPrefix(path, "../") && !strings.HasSuffix(path, ".s") {
+			path = path + ".s"
+		}
+		path = filepath.Join(c.source_dir, path)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	p := parser.New(lexer.New(string(content)))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		return nil, false
+	}
+
+	names := []string{}
+	for _, stmt := range program.Statements {
+		if module, ok := stmt.(*ast.ModuleStatement); ok {
+			names = append(names, module.Name.Value)
+		}
+	}
+	return names, true
+}
+
+// describe_function names a function for diagnostics, matching runtime errors
+func describe_function(info *function_info) string {
+	if info.name == "" {
+		return "anonymous function"
+	}
+	return fmt.Sprintf("function '%s'", info.name)
+}
+
+// arity describes how many arguments a parameter list accepts, matching runtime errors
+func arity(params []*ast.Parameter) string {
+	required, optional := 0, 0
+	for _, param := range params {
+		switch {
+		case param.Rest:
+			return fmt.Sprintf("at least %d", required)
+		case param.Default != nil:
+			optional++
+		default:
+			required++
+		}
+	}
+	if optional > 0 {
+		return fmt.Sprintf("%d to %d", required, required+optional)
+	}
+	return fmt.Sprint(required)
+}
@@ -0,0 +1,209 @@
+package checker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vpaulo/seda/evaluator"
+	"github.com/vpaulo/seda/lexer"
+	"github.com/vpaulo/seda/object"
+	"github.com/vpaulo/seda/parser"
+)
+
+func checkInput(t *testing.T, input string) []Diagnostic {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if p.HasErrors() {
+		t.Fatalf("parse errors: %v", p.FormatErrors())
+	}
+	return Check(program, t.TempDir())
+}
+
+func TestCheckReportsErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`var x: number = "hello"`, `line 1:17: type error: variable 'x' expects number, got string`},
+		{"var total = 1\nvar label = total + \"items\"", "line 2:13: type mismatch: number + string"},
+		{`var s = -"text"`, `line 1:9: unknown operator: -string`},
+		{`var bits = ~"text"`, `line 1:12: unknown operator: ~string`},
+		{`println(missing)`, `line 1:9: identifier not found: missing`},
+		{"fn add(a, b) ::\n  return a + b\nend\nadd(1)", "line 4:1: wrong number of arguments for function 'add': got 1, want 2"},
+		{"fn greet(name: string) ::\n  return name\nend\ngreet(42)", "line 4:7: type error in function 'greet': parameter 'name' expects string, got number"},
+		{"fn answer(): string ::\n  return 42\nend", "line 2:10: type error in function 'answer': return value expects string, got number"},
+		{`var words = ["a", "b"].upper()`, `line 1:24: method 'upper' not found on Array`},
+		{`var n = (5).trim`, `line 1:13: method 'trim' not found on Number`},
+		{"var seen = {1, 2}\nseen.push(3)", "line 2:6: method 'push' not found on Set"},
+		{"var both = {1} | [2]", "line 1:12: type mismatch: set | Array[number]"},
+		{"var x: number = 1\nx = \"two\"", "line 2:5: type error: variable 'x' expects number, got string"},
+		{"const limit = 1\nlimit = 2", "line 2:1: cannot reassign constant 'limit'"},
+		{"type Name = string\nvar n: Name = 5", "line 2:15: type error: variable 'n' expects Name, got number"},
+		{"type Names = Array[string]\nvar n: Names = [1, 2]", "line 2:16: type error: variable 'n' expects Names, got Array[number]"},
+		{`var x: Missing = 1`, `line 1:1: unknown type 'Missing'`},
+		{"struct Point ::\n  x: number,\n  y: number\nend\nvar p = Point(x: 1)", "line 5:9: missing field 'y' for Point"},
+		{"struct Point ::\n  x: number,\n  y: number\nend\nvar p = Point(x: 1, y: \"2\")", "line 5:21: type error in Point: field 'y' expects number, got string"},
+		{"struct Point ::\n  x: number\nend\nvar p = Point(x: 1, z: 2)", "line 4:21: unknown field 'z' for Point"},
+		{"struct Point ::\n  x: number\nend\nvar p = Point(1)\np.move()", "line 5:3: method 'move' not found on Point"},
+		{"fn outer() ::\n  return inner_value\nend", "line 2:10: identifier not found: inner_value"},
+		{"for word in [\"a\", \"b\"] ::\n  println(word.sqrt())\nend", "line 2:16: method 'sqrt' not found on String"},
+		{`var greeting = "Hi #{nobody}"`, `line 1:16: identifier not found: nobody`},
+		{"for x in [1] ::\n  break missing\nend", "line 2:9: no enclosing loop labeled 'missing'"},
+		{"fn stop() ::\n  continue\nend", "line 2:3: continue outside of a loop"},
+		{"while ready ::\n  println(1)\nend", "line 1:7: identifier not found: ready"},
+		{"case [1] ::\n  [x] => println(x)\nend\nprintln(x)", "line 4:9: identifier not found: x"},
+		{"case 1 ::\n  n: Count => n\nend", "line 2:3: unknown type 'Count'"},
+		{"case \"a\" ::\n  s: string => s.sqrt()\nend", "line 2:18: method 'sqrt' not found on String"},
+		{"struct Point ::\n  x: number\nend\ncase Point(1) ::\n  Point(z: 0) => 0\nend", "line 5:9: unknown field 'z' for Point"},
+		{"var done = true\ncase done ::\n  true => println(1)\nend", "line 2:6: warning: case is not exhaustive: false is not handled"},
+		{"fn greet(name, greeting = \"Hi\") ::\n  return greeting + name\nend\ngreet(\"a\", nme: \"b\")", "line 4:12: unknown parameter 'nme' for function 'greet'"},
+		{"fn greet(name, greeting = \"Hi\") ::\n  return greeting + name\nend\ngreet(greeting: \"b\")", "line 4:1: missing argument for parameter 'name' of function 'greet'"},
+		{"fn total(...nums: number) ::\n  return nums.sum()\nend\ntotal(1, \"2\")", "line 4:10: type error in function 'total': parameter 'nums' expects number, got string"},
+		{"fn pad(text, width: number = \"wide\") ::\n  return text\nend", "line 1:30: type error in function 'pad': parameter 'width' expects number, got string"},
+		{"var [a, b] = \"ab\"", "line 1:5: cannot destructure string as an array"},
+		{"struct P ::\n  x: number\nend\nvar {x, z} = P(1)", "line 4:9: unknown field 'z' for P"},
+		{"var [first, ...rest] = [\"a\", \"b\"]\nvar n = first.sqrt()", "line 2:15: method 'sqrt' not found on String"},
+		{"const {host} = {\"host\": \"x\"}\nhost = \"y\"", "line 2:1: cannot reassign constant 'host'"},
+		{"var count = 1\ncount += \"x\"", "line 2:1: type mismatch: number + string"},
+		{"const limit = 1\nlimit *= 2", "line 2:1: cannot reassign constant 'limit'"},
+		{"const items = [1]\nitems += 2", "line 2:1: cannot modify immutable array"},
+		{"var label: string = \"a\"\nlabel -= \"b\"", "line 2:1: unknown operator: string - string"},
+		{"struct P ::\n  n: number\nend\nvar p = P(1)\np.n += \"s\"", "line 5:3: type mismatch: number + string"},
+		{"fn add(a, b) ::\n  return a + b\nend\nvar n = 1 |> add(2, 3)", "line 4:14: wrong number of arguments for function 'add': got 3, want 2"},
+		{"fn shout(text: string) ::\n  return text.upper()\nend\nvar s = 42 |> shout", "line 4:9: type error in function 'shout': parameter 'text' expects string, got number"},
+		{"var s = 1 |> missing", "line 1:14: identifier not found: missing"},
+		{"var f = (n: number) => n.upper()", "line 1:26: method 'upper' not found on Number"},
+		{"fn numbers() ::\n  yield 1\n  return 2\nend", "line 3:3: function 'numbers' is a generator and cannot return a value"},
+		{"fn numbers() ::\n  yield 1\nend\nvar n: number = numbers()", "line 4:17: type error: variable 'n' expects number, got iterator"},
+		{"fn numbers() ::\n  yield 1\nend\nnumbers().push(1)", "line 4:11: method 'push' not found on Iterator"},
+		{"type Shape = Circle(r: number) | Empty\nvar c = Circle(\"big\")", "line 2:16: type error in Circle: field 'r' expects number, got string"},
+		{"type Shape = Circle(r) | Empty\nvar s: Shape = 5", "line 2:16: type error: variable 's' expects Shape, got number"},
+		{"struct Money ::\n  cents: number\nend\nfn Money.__add(other) ::\n  return Money(self.cents + other.cents)\nend\nvar less = Money(1) < Money(2)", "line 7:12: unknown operator: Money < Money"},
+		{"type Shape = Circle(r) | Rect(w, h) | Empty\nfn f(s) ::\n  return case s ::\n    Circle(r) => r\n    Rect(0, h) => h\n  end\nend", "line 3:15: warning: case is not exhaustive: Rect, Empty are not handled"},
+		{"var Shape = 1\nfn Shape.area() ::\n  return 0\nend", "line 2:1: cannot declare method on Shape: not a struct"},
+		{"var limit = 1\nvar size = case 2 ::\n  limit => \"small\"\n  _ => \"big\"\nend", "line 3:3: warning: pattern 'limit' matches every value, so the branches after it never run"},
+		{"type Shape = Circle(r) | Empty\nfn f(s) ::\n  return case s ::\n    Circle(r) => r\n    Emtpy => 0\n    _ => 1\n  end\nend", "line 5:5: identifier not found: Emtpy"},
+	}
+
+	for _, tt := range tests {
+		diagnostics := checkInput(t, tt.input)
+		if len(diagnostics) != 1 {
+			t.Errorf("input %q: expected 1 diagnostic, got %d: %v", tt.input, len(diagnostics), diagnostics)
+			continue
+		}
+		if diagnostics[0].String() != tt.expected {
+			t.Errorf("input %q: expected %q, got %q", tt.input, tt.expected, diagnostics[0].String())
+		}
+	}
+}
+
+func TestCheckAcceptsValidPrograms(t *testing.T) {
+	tests := []string{
+		// Functions may refer to declarations that come later in the file
+		"fn first() ::\n  return second() + counter\nend\nfn second() ::\n  return 1\nend\nvar counter = 2",
+		// Untyped variables may change type
+		"var value = 1\nvalue = \"one\"\nvar label = value + \"!\"",
+		// Variables reassigned inside a loop are not assumed to keep their first type
+		"var last = nil\nfor item in [1, 2] ::\n  if last != nil ::\n    println(last + 1)\n  end\n  last = item\nend",
+		// Custom properties become methods of every value
+		"var text = \"hi\"\ntext.shout = fn(self) ::\n  return self.upper()\nend\nprintln(text.shout())",
+		"String.shout = fn(self) ::\n  return self.upper()\nend\nprintln(\"hi\".shout())",
+		// Struct methods bind self
+		"struct Point ::\n  x: number,\n  y: number\nend\nfn Point.sum() ::\n  return self.x + self.y\nend\nvar total: number = Point(1, 2).sum()",
+		// The left side of raises is expected to fail
+		"fn add(a: number, b: number) ::\n  return a + b\nend\ncheck \"bad input\" ::\n  add(\"1\", 2) raises \"type error\"\nend",
+		// Where blocks see result and the function parameters
+		"fn double(n) ::\n  return n * 2\nwhere ::\n  double(2) is 4\n  result isGreater n\nend",
+		// Case wildcards and built-in modules
+		"var size = case 3 ::\n  1 => \"one\"\n  _ => Math.sqrt(9)\nend",
+		// Returned errors skip the return type
+		"fn parse(text: string): number ::\n  return error(\"cannot parse \" + text)\nend",
+		"var names: Array[string] = \"a,b\".split(\",\")\nvar count: number = names.length",
+		// Labeled loops may be exited from nested loops
+		"outer: for a in [1] ::\n  var i = 0\n  while i < 3 ::\n    i = i + 1\n    continue outer\n  end\nend",
+		// Case patterns bind names for their guard and result
+		"struct Point ::\n  x: number,\n  y: number\nend\nvar d = case Point(1, 2) ::\n  Point(0, y) => y\n  Point(x: x) if x > 1 => x.abs()\n  [first, ...rest] => rest.length\n  {\"r\": r} | r: number => r\n  _ => 0\nend",
+		"var done = true\ncase done ::\n  true => println(1)\n  false if done => println(2)\n  other => println(3)\nend",
+		// Defaults, named arguments and rest parameters
+		"fn greet(name, greeting = \"Hi\", ...rest: string) ::\n  return greeting + name + rest.join(\"\")\nend\ngreet(\"a\")\ngreet(greeting: \"Yo\", name: \"b\")\ngreet(\"a\", \"b\", \"c\", \"d\")",
+		// Destructuring declares every name it binds
+		"var [a, b = 2, ...rest] = [1]\nvar {name, address: {city}} = {\"name\": \"n\", \"address\": {\"city\": \"c\"}}\nfor [k, v] in [[1, 2]] ::\n  println(k + v + a + b + rest.length())\nend\nfn dist([x, y], {z}) ::\n  return x + y + z\nend\nprintln(name + city)",
+		// Compound assignments keep the types they combine
+		"var total: number = 0\ntotal += 2\ntotal ^= 2\nvar names: Array[string] = []\nnames += \"a\"\nnames += [\"b\"]\nvar text = \"a\"\ntext += \"b\"\nvar m = {\"k\": 1}\nm[\"k\"] += 1",
+		// Bitwise operators work on numbers
+		"var mask: number = (1 << 4) | 3 & ~1\nmask ~= 2\nmask >>= 1",
+		// Sets combine with set operators and tuples iterate like arrays
+		"var common: set = {1, 2} & Set([2, 3]) | {4}\nvar pair: tuple = Tuple(1, 2)\nfor i, x in pair ::\n  println(i + x + common.length() + pair.sum())\nend",
+		// Nil-safe operators skip null receivers and ?? picks the fallback type
+		"var user = nil\nvar city: string = user?.address?.city ?? \"none\"\nvar f = nil\nf?.(1)\nvar first = user?[0]",
+		// Pipelines pass the left value as the first argument
+		"fn add(a: number, b: number): number ::\n  return a + b\nend\nvar total: number = 1 |> add(2) |> add(b: 3)",
+		// Arrow functions declare their parameters
+		"var pairs = [[1, 2]].map(([a, b]) => a + b)\nvar add = (a, b = 1) => a + b\nvar n = add(1) |> (x => x * 2)",
+		// Generators return iterators, which take the array methods
+		"fn numbers(limit: number): Iterator ::\n  var i = 0\n  while i < limit ::\n    yield i\n    i += 1\n  end\n  return\nend\nvar evens: Array = numbers(10).filter(n => n % 2 == 0).collect()\nfor i, n in numbers(3) ::\n  println(i.abs() + n)\nend\nvar it: Iterator = numbers(2)\nit.next()\nit.close()",
+		// Structs with an iter method take the array methods too
+		"struct Countdown ::\n  from: number\nend\nfn Countdown.iter() ::\n  yield self.from\nend\nvar total: number = Countdown(3).sum()",
+		// Sum type variants construct values of the sum type
+		"type Shape = Circle(r: number) | Rect(w, h) | Empty\nfn area(s: Shape): number ::\n  return case s ::\n    Circle(r) => r * r\n    Rect(w, h) if w > 0 => w * h\n    Rect(_, _) | Empty => 0\n  end\nend\nvar shape: Shape = Empty\nvar a = area(Circle(2)) + area(shape)",
+		// Operator methods such as __add make operators work on struct instances
+		"struct Money ::\n  cents: number\nend\nfn Money.__add(other): Money ::\n  return Money(self.cents + other.cents)\nend\nfn Money.__lt(other) ::\n  return self.cents < other.cents\nend\nvar total: Money = Money(1) + Money(2)\nvar more: boolean = total >= Money(1)",
+		// Maps inherit from their prototypes, whose methods super reaches
+		"var Animal = {}\nAnimal.speak = fn(self) ::\n  return \"...\"\nend\nvar Dog = Object.extend(Animal)\nDog.speak = fn(self) ::\n  return super.speak() + \"!\"\nend\nprintln(Object.extend(Dog, {\"name\": \"Rex\"}).speak())",
+		// Methods declared on a sum type belong to each of its variants
+		"type Shape = Circle(r: number) | Empty\nfn Shape.size(): number ::\n  return case self ::\n    Circle(r) => r\n    Empty => 0\n  end\nend\nvar n: number = Circle(2).size() + Empty.size()",
+		// Constants in case patterns are compared, not bound
+		"const LIMIT = 1\nvar size = case 2 ::\n  LIMIT => \"at limit\"\n  n => \"other\"\nend",
+		// Catch binds the error inside its block
+		"try ::\n  var n = 1 / 0\ncatch err ::\n  println(err.message)\n  throw err\nensure ::\n  println(\"done\")\nend",
+	}
+
+	for _, input := range tests {
+		diagnostics := checkInput(t, input)
+		if len(diagnostics) != 0 {
+			t.Errorf("input %q: expected no diagnostics, got %v", input, diagnostics)
+		}
+	}
+}
+
+func TestCheckUnresolvedModulesDisableIdentifierChecks(t *testing.T) {
+	input := "using \"std/collections\"\nprintln(Stack.new())"
+
+	diagnostics := checkInput(t, input)
+	if len(diagnostics) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diagnostics)
+	}
+}
+
+// TestBuiltinMethodsExist keeps builtin_methods in sync with the evaluator
+func TestBuiltinMethodsExist(t *testing.T) {
+	receivers := map[string]string{
+		"string":   `"text"`,
+		"array":    `[1, 2]`,
+		"number":   `5`,
+		"boolean":  `true`,
+		"error":    `error("failed")`,
+		"time":     `Time.now()`,
+		"iterator": "(fn() ::\n  yield 1\nend)()",
+		"map":      `{"a": 1}`,
+		"set":      `{1, 2}`,
+		"tuple":    `Tuple(1, 2)`,
+	}
+
+	for type_name, methods := range builtin_methods {
+		receiver, ok := receivers[type_name]
+		if !ok {
+			t.Fatalf("no sample receiver for %s", type_name)
+		}
+		for method := range methods {
+			input := "var receiver = " + receiver + "\nreceiver." + method + "()"
+			program := parser.New(lexer.New(input)).ParseProgram()
+			result := evaluator.Eval(program, object.NewEnvironment())
+
+			if err, ok := result.(*object.Error); ok && strings.Contains(err.Message, "not found on") {
+				t.Errorf("%s.%s is listed but the evaluator does not implement it: %s", type_name, method, err.Message)
+			}
+		}
+	}
+}
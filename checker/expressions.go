@@ -0,0 +1,654 @@
+package checker
+
+import (
+	"github.com/vpaulo/seda/ast"
+)
+
+// expression checks an expression and returns its inferred type
+func (c *Checker) expression(exp ast.Expression) *static_type {
+	switch node := exp.(type) {
+	case nil:
+		return any_type
+	case *ast.NumberLiteral:
+		return number_type
+	case *ast.StringLiteral:
+		return string_type
+	case *ast.InterpolatedString:
+		// Interpolated expressions are parsed separately, so report them at the string itself
+		if c.position == nil {
+			c.position = &node.Token
+			defer func() { c.position = nil }()
+		}
+		for _, part := range node.Parts {
+			c.expression(part)
+		}
+		return string_type
+	case *ast.BooleanLiteral:
+		return boolean_type
+	case *ast.NilLiteral:
+		return null_type
+	case *ast.Identifier:
+		return c.identifier(node)
+	case *ast.ArrayLiteral:
+		var element *static_type
+		for i, e := range node.Elements {
+			element_type := c.expression(e)
+			if i == 0 {
+				element = element_type
+			} else if !same_type(element, element_type) {
+				element = any_type
+			}
+		}
+		return array_of(element)
+	case *ast.MapLiteral:
+		for _, pair := range node.Pairs {
+			c.expression(pair.Key)
+			c.expression(pair.Value)
+		}
+		return map_type
+	case *ast.SetLiteral:
+		for _, e := range node.Elements {
+			c.expression(e)
+		}
+		return set_type
+	case *ast.FunctionLiteral:
+		for _, param := range node.Parameters {
+			c.check_annotation(param.Type, param.Name)
+		}
+		info := &function_info{parameters: node.Parameters, return_type: node.ReturnType, scope: c.scope, generator: node.Generator}
+		c.function_body(info, node.Body, func() {})
+		return &static_type{name: "function", function: info}
+	case *ast.PrefixExpression:
+		return c.prefix_expression(node)
+	case *ast.InfixExpression:
+		return c.infix_expression(node)
+	case *ast.CallExpression:
+		return c.call_expression(node)
+	case *ast.NamedArgument:
+		c.expression(node.Value)
+		return any_type
+	case *ast.IndexExpression:
+		left := c.expression(node.Left)
+		c.expression(node.Index)
+		if left.is_instance() {
+			if method, ok := left.struct_info.methods["__index"]; ok {
+				return method.result()
+			}
+		}
+		switch left.name {
+		case "array":
+			if left.element != nil {
+				return left.element
+			}
+		case "string":
+			return string_type
+		}
+		return any_type
+	case *ast.DotExpression:
+		return c.dot_expression(node)
+	case *ast.AssignmentExpression:
+		return c.assignment(node)
+	case *ast.RangeExpression:
+		c.expression(node.Start)
+		c.expression(node.End)
+		return range_type
+	case *ast.PipelineExpression:
+		return c.pipeline(node)
+	case *ast.CaseExpression:
+		return c.case_branches(node.Expression, node.Branches)
+	}
+	return any_type
+}
+
+// identifier resolves a name the same way eval_identifier does
+func (c *Checker) identifier(node *ast.Identifier) *static_type {
+	if sym := c.scope.lookup(node.Value); sym != nil {
+		return sym.typ
+	}
+	if typ, ok := global_functions[node.Value]; ok {
+		return typ
+	}
+	if typ, ok := global_objects[node.Value]; ok {
+		return typ
+	}
+
+	// self and super are bound by the caller of custom methods and _ is the case wildcard
+	if node.Value != "self" && node.Value != "super" && node.Value != "_" && !c.lenient {
+		c.report(node, "identifier not found: %s", node.Value)
+	}
+	return any_type
+}
+
+func (c *Checker) prefix_expression(node *ast.PrefixExpression) *static_type {
+	right := c.expression(node.Right)
+
+	switch node.Operator {
+	case "-", "~":
+		if !right.is_any() && right.name != "number" {
+			c.report(node, "unknown operator: %s%s", node.Operator, right)
+			return any_type
+		}
+		return number_type
+	case "!", "not":
+		return boolean_type
+	}
+	return any_type
+}
+
+// infix_expression mirrors the operand rules of eval_infix_expression
+func (c *Checker) infix_expression(node *ast.InfixExpression) *static_type {
+	left := c.expression(node.Left)
+	right := c.expression(node.Right)
+	if node.Operator == "??" {
+		return coalesced(left, right)
+	}
+	return c.binary(node, node.Operator, left, right)
+}
+
+// binary returns the type of applying an infix operator, reporting operands it does not support
+func (c *Checker) binary(node ast.Node, operator string, left, right *static_type) *static_type {
+	switch operator {
+	case "&&", "and", "||", "or", "==", "!=":
+		return boolean_type
+	}
+
+	comparison := false
+	switch operator {
+	case "<", ">", "<=", ">=":
+		comparison = true
+	}
+
+	if left.is_any() || right.is_any() {
+		if comparison {
+			return boolean_type
+		}
+		return any_type
+	}
+
+	if result, ok := c.overloaded(operator, left); ok {
+		if comparison {
+			return boolean_type
+		}
+		return result
+	}
+
+	switch {
+	case left.name == "number" && right.name == "number":
+		if comparison {
+			return boolean_type
+		}
+		return number_type
+	case left.name == "string" && right.name == "string" && (comparison || operator == "+"):
+		if comparison {
+			return boolean_type
+		}
+		return string_type
+	case left.name == "set" && right.name == "set" && (operator == "|" || operator == "&" || operator == "-" || operator == "~"):
+		return set_type
+	case left.name != right.name:
+		c.report(node, "type mismatch: %s %s %s", left, operator, right)
+	default:
+		c.report(node, "unknown operator: %s %s %s", left, operator, right)
+	}
+	return any_type
+}
+
+// overloaded returns the type of an operator a struct instance defines with a method such as __add.
+// Maps and arrays may get those methods as properties, so any property of the name will do.
+// Comparisons without their own method are worked out from __lt.
+func (c *Checker) overloaded(operator string, left *static_type) (*static_type, bool) {
+	name, ok := operator_methods[operator]
+	if !ok {
+		return nil, false
+	}
+	names := []string{name}
+	switch operator {
+	case "<=", ">", ">=":
+		names = append(names, "__lt")
+	}
+
+	for _, name := range names {
+		switch {
+		case left.is_instance():
+			if method, ok := left.struct_info.methods[name]; ok {
+				return method.result(), true
+			}
+		case left.name == "map" || left.name == "array":
+			if c.properties[name] {
+				return any_type, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// coalesced returns the type of a ?? b, which is a unless a is null
+func coalesced(left, right *static_type) *static_type {
+	switch {
+	case left.name == "null":
+		return right
+	case left.is_any():
+		return any_type
+	}
+	return left
+}
+
+// call_arguments checks call arguments, separating positional and named ones
+func (c *Checker) call_arguments(arguments []ast.Expression) ([]*static_type, map[string]*static_type) {
+	positional := []*static_type{}
+	named := make(map[string]*static_type)
+	for _, arg := range arguments {
+		if named_arg, ok := arg.(*ast.NamedArgument); ok {
+			named[named_arg.Name.Value] = c.expression(named_arg.Value)
+			continue
+		}
+		positional = append(positional, c.expression(arg))
+	}
+	return positional, named
+}
+
+func (c *Checker) call_expression(node *ast.CallExpression) *static_type {
+	if dot, ok := node.Function.(*ast.DotExpression); ok {
+		return c.method_call(dot, node.Arguments)
+	}
+
+	callee := c.expression(node.Function)
+	args, named := c.call_arguments(node.Arguments)
+	if node.Optional && callee.name == "null" {
+		return null_type
+	}
+	return c.apply(callee, node, node.Arguments, args, named)
+}
+
+// pipeline checks value |> f(args) as the call f(value, args)
+func (c *Checker) pipeline(node *ast.PipelineExpression) *static_type {
+	input := c.expression(node.Left)
+
+	call, ok := node.Right.(*ast.CallExpression)
+	if !ok {
+		callee := c.expression(node.Right)
+		return c.apply(callee, node, []ast.Expression{node.Left}, []*static_type{input}, nil)
+	}
+
+	callee := c.expression(call.Function)
+	args, named := c.call_arguments(call.Arguments)
+	arguments := append([]ast.Expression{node.Left}, call.Arguments...)
+	return c.apply(callee, call, arguments, append([]*static_type{input}, args...), named)
+}
+
+// apply checks calling callee with the given arguments and returns the result type
+func (c *Checker) apply(callee *static_type, node ast.Node, arguments []ast.Expression, args []*static_type, named map[string]*static_type) *static_type {
+	switch callee.name {
+	case "function":
+		if callee.function == nil {
+			return any_type
+		}
+		return c.check_call(callee.function, node, arguments, args, named)
+	case "struct":
+		c.check_construction(callee.struct_info, node, arguments, args, named)
+		return instance_of(callee.struct_info)
+	case "any":
+		return any_type
+	}
+
+	c.report(node, "not a function: %s", callee)
+	return any_type
+}
+
+// check_call validates the arguments of a call to a user function like extend_function_env does,
+// and returns its result type
+func (c *Checker) check_call(fn *function_info, node ast.Node, arguments []ast.Expression, args []*static_type, named map[string]*static_type) *static_type {
+	params := fn.parameters
+	var rest *ast.Parameter
+	if len(params) > 0 && params[len(params)-1].Rest {
+		rest = params[len(params)-1]
+		params = params[:len(params)-1]
+	}
+
+	if rest == nil && len(args) > len(params) {
+		c.report(node, "wrong number of arguments for %s: got %d, want %s", describe_function(fn), len(args), arity(fn.parameters))
+		return fn.result()
+	}
+
+	positional := 0
+	for _, arg := range arguments {
+		named_arg, ok := arg.(*ast.NamedArgument)
+		if !ok {
+			param := rest
+			if positional < len(params) {
+				param = params[positional]
+			}
+			c.check_argument(fn, param, args[positional], arg)
+			positional++
+			continue
+		}
+
+		param := find_parameter(params, named_arg.Name.Value)
+		switch {
+		case param == nil:
+			c.report(named_arg, "unknown parameter '%s' for %s", named_arg.Name.Value, describe_function(fn))
+		case index_of_parameter(params, param) < len(args):
+			c.report(named_arg, "parameter '%s' of %s given more than once", param.Name.Value, describe_function(fn))
+		default:
+			c.check_argument(fn, param, named[param.Name.Value], named_arg)
+		}
+	}
+
+	for i, param := range params {
+		if _, given := named[param.Name.Value]; given || i < len(args) || param.Default != nil {
+			continue
+		}
+		if len(named) == 0 {
+			c.report(node, "wrong number of arguments for %s: got %d, want %s", describe_function(fn), len(args), arity(fn.parameters))
+			break
+		}
+		c.report(node, "missing argument for parameter '%s' of %s", param.Name.Value, describe_function(fn))
+	}
+
+	return fn.result()
+}
+
+// check_argument validates a value passed to an annotated parameter
+func (c *Checker) check_argument(fn *function_info, param *ast.Parameter, value *static_type, at ast.Node) {
+	if param == nil || param.Type == nil {
+		return
+	}
+	expected := resolve_annotation(param.Type, fn.scope)
+	if !assignable(expected, value) {
+		c.report(at, "type error in %s: parameter '%s' expects %s, got %s",
+			describe_function(fn), param.Name.Value, param.Type.String(), value)
+	}
+}
+
+// find_parameter returns the parameter called name, if any
+func find_parameter(params []*ast.Parameter, name string) *ast.Parameter {
+	for _, param := range params {
+		if param.Name.Value == name {
+			return param
+		}
+	}
+	return nil
+}
+
+// index_of_parameter returns the position of param in params, or -1
+func index_of_parameter(params []*ast.Parameter, param *ast.Parameter) int {
+	for i, candidate := range params {
+		if candidate == param {
+			return i
+		}
+	}
+	return -1
+}
+
+// check_construction validates a struct constructor call like instantiate_struct does
+func (c *Checker) check_construction(info *struct_info, node ast.Node, arguments []ast.Expression, args []*static_type, named map[string]*static_type) {
+	if len(args) > len(info.fields) {
+		c.report(node, "too many arguments for %s: got %d, want %d", info.name, len(args), len(info.fields))
+		return
+	}
+
+	given := make(map[string]*static_type)
+	positions := make(map[string]ast.Node)
+	for i, field := range info.fields {
+		if i < len(args) {
+			given[field.Name.Value] = args[i]
+		}
+	}
+	positional := 0
+	for _, arg := range arguments {
+		if named_arg, ok := arg.(*ast.NamedArgument); ok {
+			positions[named_arg.Name.Value] = named_arg
+			if !info.has_field(named_arg.Name.Value) {
+				c.report(named_arg, "unknown field '%s' for %s", named_arg.Name.Value, info.name)
+			} else if _, ok := given[named_arg.Name.Value]; ok {
+				c.report(named_arg, "field '%s' of %s given more than once", named_arg.Name.Value, info.name)
+			}
+			continue
+		}
+		if positional < len(info.fields) {
+			positions[info.fields[positional].Name.Value] = arg
+		}
+		positional++
+	}
+	for name, typ := range named {
+		if _, ok := given[name]; !ok {
+			given[name] = typ
+		}
+	}
+
+	for _, field := range info.fields {
+		value, ok := given[field.Name.Value]
+		if !ok {
+			c.report(node, "missing field '%s' for %s", field.Name.Value, info.name)
+			continue
+		}
+		c.check_field(info, field, value, positions[field.Name.Value])
+	}
+}
+
+// check_field validates a value stored in a typed struct field
+func (c *Checker) check_field(info *struct_info, field *ast.StructField, value *static_type, at ast.Node) {
+	if field.Type == nil {
+		return
+	}
+	expected := resolve_annotation(field.Type, info.scope)
+	if !assignable(expected, value) {
+		c.report(at, "type error in %s: field '%s' expects %s, got %s", info.name, field.Name.Value, field.Type.String(), value)
+	}
+}
+
+func (info *struct_info) has_field(name string) bool {
+	return info.field(name) != nil
+}
+
+// collected_method returns the result of an array method called on an instance with a next or iter method,
+// which runs it over the values the instance produces
+func (info *struct_info) collected_method(name string) (*static_type, bool) {
+	_, has_next := info.methods["next"]
+	_, has_iter := info.methods["iter"]
+	if !has_next && !has_iter || name == "next" || name == "close" || name == "to_array" {
+		return nil, false
+	}
+	result, ok := builtin_methods["iterator"][name]
+	return result, ok
+}
+
+func (info *struct_info) field(name string) *ast.StructField {
+	for _, field := range info.fields {
+		if field.Name.Value == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// method_call checks receiver.method(args)
+func (c *Checker) method_call(dot *ast.DotExpression, arguments []ast.Expression) *static_type {
+	receiver := c.expression(dot.Left)
+	args, named := c.call_arguments(arguments)
+	name := dot.Property.Value
+
+	if receiver.is_instance() {
+		if method, ok := receiver.struct_info.methods[name]; ok {
+			return c.check_call(method, dot, arguments, args, named)
+		}
+		if receiver.struct_info.has_field(name) || c.properties[name] {
+			return any_type
+		}
+		if result, ok := receiver.struct_info.collected_method(name); ok {
+			return result
+		}
+		c.report(dot, "method '%s' not found on %s", name, receiver.struct_info.name)
+		return any_type
+	}
+
+	return c.builtin_method(dot, receiver, name)
+}
+
+// dot_expression checks property access, which reads struct fields or calls methods without arguments
+func (c *Checker) dot_expression(node *ast.DotExpression) *static_type {
+	receiver := c.expression(node.Left)
+	name := node.Property.Value
+
+	if receiver.is_instance() {
+		if field := receiver.struct_info.field(name); field != nil {
+			return resolve_annotation(field.Type, receiver.struct_info.scope)
+		}
+		if method, ok := receiver.struct_info.methods[name]; ok {
+			return method.result()
+		}
+		if !c.properties[name] {
+			c.report(node, "method '%s' not found on %s", name, receiver.struct_info.name)
+		}
+		return any_type
+	}
+
+	// Without parentheses a name on a map reads its key of that name first
+	if receiver.name == "map" {
+		return any_type
+	}
+
+	return c.builtin_method(node, receiver, name)
+}
+
+// builtin_method checks a method of a built-in type and returns its result type
+func (c *Checker) builtin_method(node *ast.DotExpression, receiver *static_type, name string) *static_type {
+	methods, ok := builtin_methods[receiver.name]
+	if !ok {
+		return any_type
+	}
+
+	result, ok := methods[name]
+	if !ok {
+		// Maps also hold functions under their keys, so other names are not reported
+		if !c.properties[name] && receiver.name != "map" {
+			c.report(node, "method '%s' not found on %s", name, type_display_names[receiver.name])
+		}
+		return any_type
+	}
+
+	// Methods that pick or reorder elements keep the element type of the array
+	if receiver.name == "array" && receiver.element != nil {
+		switch name {
+		case "first", "last", "pop", "find", "min", "max":
+			return receiver.element
+		case "rest", "filter", "sort", "sort_by", "reverse", "unique", "slice", "take", "drop":
+			return receiver
+		}
+	}
+	return result
+}
+
+// assignment checks the target and value of an assignment
+func (c *Checker) assignment(node *ast.AssignmentExpression) *static_type {
+	if node.IsCompound() {
+		return c.compound_assignment(node)
+	}
+	value := c.expression(node.Value)
+
+	switch left := node.Left.(type) {
+	case *ast.Identifier:
+		sym := c.scope.lookup(left.Value)
+		if sym == nil {
+			// Assigning an unknown name creates the variable
+			c.scope.define(left.Value, &symbol{typ: value})
+			return value
+		}
+		if sym.is_constant {
+			c.report(left, "cannot reassign constant '%s'", left.Value)
+			return value
+		}
+		if sym.annotation != nil {
+			expected := resolve_annotation(sym.annotation, c.scope)
+			if !assignable(expected, value) {
+				c.report(node.Value, "type error: variable '%s' expects %s, got %s", left.Value, sym.annotation.String(), value)
+			}
+			return value
+		}
+		// Untyped variables may change type; stop assuming anything about them
+		if !same_type(sym.typ, value) {
+			sym.typ = any_type
+		}
+	case *ast.DotExpression:
+		c.properties[left.Property.Value] = true
+		receiver := c.expression(left.Left)
+		if receiver.is_instance() {
+			field := receiver.struct_info.field(left.Property.Value)
+			if field == nil {
+				c.report(left, "unknown field '%s' on %s", left.Property.Value, receiver.struct_info.name)
+			} else {
+				c.check_field(receiver.struct_info, field, value, node.Value)
+			}
+		}
+	default:
+		c.expression(node.Left)
+	}
+
+	return value
+}
+
+// compound_assignment checks assignments like += that combine the target's current value with the new one
+func (c *Checker) compound_assignment(node *ast.AssignmentExpression) *static_type {
+	target := c.expression(node.Left)
+	value := c.expression(node.Value)
+
+	operator := node.BinaryOperator()
+	var combined *static_type
+	if target.name == "array" && operator == "+" {
+		combined = appended(target, value)
+	} else {
+		combined = c.binary(node, operator, target, value)
+	}
+
+	switch left := node.Left.(type) {
+	case *ast.Identifier:
+		sym := c.scope.lookup(left.Value)
+		if sym == nil {
+			return combined
+		}
+		if sym.is_constant {
+			// Constant arrays are immutable, so += fails while appending
+			if target.name == "array" && operator == "+" {
+				c.report(left, "cannot modify immutable array")
+			} else {
+				c.report(left, "cannot reassign constant '%s'", left.Value)
+			}
+			return combined
+		}
+		if sym.annotation != nil {
+			expected := resolve_annotation(sym.annotation, c.scope)
+			if !assignable(expected, combined) {
+				c.report(node.Value, "type error: variable '%s' expects %s, got %s", left.Value, sym.annotation.String(), combined)
+			}
+			return combined
+		}
+		if !same_type(sym.typ, combined) {
+			sym.typ = any_type
+		}
+	case *ast.DotExpression:
+		c.properties[left.Property.Value] = true
+		// The receiver was already checked as part of the target
+		c.quiet++
+		receiver := c.expression(left.Left)
+		c.quiet--
+		if receiver.is_instance() {
+			if field := receiver.struct_info.field(left.Property.Value); field != nil {
+				c.check_field(receiver.struct_info, field, combined, node.Value)
+			}
+		}
+	}
+
+	return combined
+}
+
+// appended returns the type of an array after += adds value, either an array of elements or a single element
+func appended(array, value *static_type) *static_type {
+	element := value
+	if value.name == "array" {
+		element = value.element
+	}
+	if array.element == nil || element == nil || !same_type(array.element, element) {
+		return array_type
+	}
+	return array
+}
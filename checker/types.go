@@ -0,0 +1,229 @@
+package checker
+
+import (
+	"strings"
+
+	"github.com/vpaulo/seda/ast"
+)
+
+// max_type_alias_depth guards against type aliases that refer to each other
+const max_type_alias_depth = 64
+
+// static_type is what the checker knows about a value without running the program
+type static_type struct {
+	name        string       // any, number, string, boolean, null, function, struct, module, array, map, ... or a struct name
+	element     *static_type // element type of arrays, when known
+	function    *function_info
+	struct_info *struct_info // struct type values and struct instances
+	sum_info    *sum_info    // sum type declarations and values of a sum type
+}
+
+// function_info describes a user function signature
+type function_info struct {
+	name        string
+	parameters  []*ast.Parameter
+	return_type *ast.TypeAnnotation
+	scope       *scope // scope the function was declared in, used to resolve its annotations
+	generator   bool   // calls return an iterator instead of running the body
+}
+
+// result returns the type a call to the function produces
+func (f *function_info) result() *static_type {
+	if f.generator {
+		return iterator_type
+	}
+	return resolve_annotation(f.return_type, f.scope)
+}
+
+// struct_info describes a struct declaration
+type struct_info struct {
+	name    string
+	fields  []*ast.StructField
+	methods map[string]*function_info
+	scope   *scope
+	sum     *sum_info // set for the variants of a sum type
+}
+
+// sum_info describes a sum type and its closed set of variants
+type sum_info struct {
+	name     string
+	variants []*struct_info
+}
+
+var (
+	any_type      = &static_type{name: "any"}
+	number_type   = &static_type{name: "number"}
+	string_type   = &static_type{name: "string"}
+	boolean_type  = &static_type{name: "boolean"}
+	null_type     = &static_type{name: "null"}
+	range_type    = &static_type{name: "range"}
+	time_type     = &static_type{name: "time"}
+	error_type    = &static_type{name: "error"}
+	map_type      = &static_type{name: "map"}
+	module_type   = &static_type{name: "module"}
+	function_type = &static_type{name: "function"}
+	array_type    = &static_type{name: "array"}
+	iterator_type = &static_type{name: "iterator"}
+	set_type      = &static_type{name: "set"}
+	tuple_type    = &static_type{name: "tuple"}
+)
+
+// array_of returns the type of an array whose elements are all of the given type
+func array_of(element *static_type) *static_type {
+	if element == nil || element.name == "any" {
+		return array_type
+	}
+	return &static_type{name: "array", element: element}
+}
+
+// instance_of returns the type of an instance of a struct
+func instance_of(info *struct_info) *static_type {
+	return &static_type{name: info.name, struct_info: info}
+}
+
+// value_of returns the type of a value of a sum type, which may be any of its variants
+func value_of(info *sum_info) *static_type {
+	return &static_type{name: info.name, sum_info: info}
+}
+
+func (t *static_type) is_any() bool {
+	return t == nil || t.name == "any"
+}
+
+// is_instance reports whether t describes an instance of a user struct
+func (t *static_type) is_instance() bool {
+	return t != nil && t.struct_info != nil && t.name != "struct"
+}
+
+func (t *static_type) String() string {
+	if t == nil {
+		return "any"
+	}
+	if t.name == "array" && t.element != nil {
+		return "Array[" + t.element.String() + "]"
+	}
+	return t.name
+}
+
+// same_type reports whether two types are interchangeable for inference purposes
+func same_type(a, b *static_type) bool {
+	if a.is_any() || b.is_any() {
+		return a.is_any() && b.is_any()
+	}
+	if a.name != b.name {
+		return false
+	}
+	if a.element != nil || b.element != nil {
+		return a.element != nil && b.element != nil && same_type(a.element, b.element)
+	}
+	return true
+}
+
+// assignable reports whether a value of type actual may be stored where expected is declared.
+// Unknown types on either side are always accepted so the checker never rejects valid programs.
+func assignable(expected, actual *static_type) bool {
+	if expected.is_any() || actual.is_any() {
+		return true
+	}
+	if expected.name == "function" {
+		return actual.name == "function" || actual.name == "struct"
+	}
+	if expected.sum_info != nil && actual.is_instance() && actual.struct_info.sum == expected.sum_info {
+		return true
+	}
+	if expected.name != actual.name {
+		return false
+	}
+	if expected.element != nil && actual.element != nil {
+		return assignable(expected.element, actual.element)
+	}
+	return true
+}
+
+// builtin_type maps the built-in annotation names to their types
+func builtin_type(name string) (*static_type, bool) {
+	switch strings.ToLower(name) {
+	case "any":
+		return any_type, true
+	case "number":
+		return number_type, true
+	case "string":
+		return string_type, true
+	case "boolean", "bool":
+		return boolean_type, true
+	case "null", "nil":
+		return null_type, true
+	case "function", "fn":
+		return function_type, true
+	case "range":
+		return range_type, true
+	case "iterator":
+		return iterator_type, true
+	case "set":
+		return set_type, true
+	case "tuple":
+		return tuple_type, true
+	case "time":
+		return time_type, true
+	case "error":
+		return error_type, true
+	case "array":
+		return array_type, true
+	case "map":
+		return map_type, true
+	}
+	return nil, false
+}
+
+// resolve_annotation turns a type annotation into a static type using the aliases and structs in s.
+// Types that cannot be resolved become any; unknown_types reports them separately.
+func resolve_annotation(annotation *ast.TypeAnnotation, s *scope) *static_type {
+	return resolve_annotation_depth(annotation, s, 0)
+}
+
+func resolve_annotation_depth(annotation *ast.TypeAnnotation, s *scope, depth int) *static_type {
+	if annotation == nil || depth > max_type_alias_depth {
+		return any_type
+	}
+
+	if builtin, ok := builtin_type(annotation.Name); ok {
+		// Array[T] keeps its element type, Map parameters are not tracked
+		if builtin.name == "array" && len(annotation.Parameters) > 0 {
+			return array_of(resolve_annotation_depth(annotation.Parameters[0], s, depth))
+		}
+		return builtin
+	}
+
+	if sym := s.lookup(annotation.Name); sym != nil {
+		if sym.alias != nil {
+			return resolve_annotation_depth(sym.alias, s, depth+1)
+		}
+		if sym.typ.name == "struct" && sym.typ.struct_info != nil {
+			return instance_of(sym.typ.struct_info)
+		}
+		if sym.typ.name == "type" && sym.typ.sum_info != nil {
+			return value_of(sym.typ.sum_info)
+		}
+	}
+
+	return any_type
+}
+
+// unknown_types lists the names in an annotation that are neither built-in nor declared in s
+func unknown_types(annotation *ast.TypeAnnotation, s *scope) []string {
+	if annotation == nil {
+		return nil
+	}
+
+	unknown := []string{}
+	if _, ok := builtin_type(annotation.Name); !ok {
+		sym := s.lookup(annotation.Name)
+		if sym == nil || (sym.alias == nil && sym.typ.name != "struct" && sym.typ.name != "type") {
+			unknown = append(unknown, annotation.Name)
+		}
+	}
+	for _, parameter := range annotation.Parameters {
+		unknown = append(unknown, unknown_types(parameter, s)...)
+	}
+	return unknown
+}
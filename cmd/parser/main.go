@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/vpaulo/seda/checker"
 	"github.com/vpaulo/seda/evaluator"
 	"github.com/vpaulo/seda/lexer"
 	"github.com/vpaulo/seda/object"
@@ -40,9 +41,12 @@ func main() {
 		return
 	}
 
-	// Check if first argument is a package management command
+	// Check if first argument is a package management or check command
 	command := flag.Arg(0)
 	switch command {
+	case "check":
+		handle_check()
+		return
 	case "install":
 		handle_install()
 		return
@@ -115,6 +119,7 @@ func main() {
 		// Set the source directory for module resolution
 		abs_path, _ := filepath.Abs(filename)
 		env.SourceDir = filepath.Dir(abs_path)
+		env.SourceFile = filename
 		test_result := evaluator.RunTests(program, env)
 		fmt.Println(test_result.String())
 
@@ -134,12 +139,17 @@ func main() {
 	// Set the source directory for module resolution
 	abs_path, _ := filepath.Abs(filename)
 	env.SourceDir = filepath.Dir(abs_path)
+	env.SourceFile = filename
 	result := evaluator.Eval(program, env)
 
 	if result != nil {
 		switch result := result.(type) {
 		case *object.Error:
+			fmt.Fprint(os.Stderr, result.Traceback())
 			fmt.Fprintf(os.Stderr, "Runtime error: %s\n", result.Message)
+			for cause := result.Cause; cause != nil; cause = cause.Cause {
+				fmt.Fprintf(os.Stderr, "Caused by: %s\n", cause.Message)
+			}
 			os.Exit(1)
 		default:
 			if *verbose_mode {
@@ -162,6 +172,9 @@ func usage() {
 	fmt.Println("OPTIONS:")
 	flag.PrintDefaults()
 	fmt.Println()
+	fmt.Println("STATIC CHECK COMMAND:")
+	fmt.Println("  seda check <source-file>     # Report type errors without running the file")
+	fmt.Println()
 	fmt.Println("PACKAGE MANAGEMENT COMMANDS:")
 	fmt.Println("  seda install <package-url>   # Install a package from git repository")
 	fmt.Println("  seda update <package-name>   # Update an installed package")
@@ -174,6 +187,7 @@ func usage() {
 	fmt.Println("  seda -test program.s                      # Run tests in program.s")
 	fmt.Println("  seda -ast program.s                       # Show AST of program.s")
 	fmt.Println("  seda -verbose program.s                   # Execute with detailed output")
+	fmt.Println("  seda check program.s                      # Type check program.s")
 	fmt.Println("  seda -help                                # Show this help message")
 	fmt.Println("  seda install github.com/user/awesome-lib  # Install a package")
 	fmt.Println("  seda list                                 # List installed packages")
@@ -260,6 +274,59 @@ func clearEnvironment(env *object.Environment) {
 	*env = *object.NewEnvironment()
 }
 
+// Static check command
+
+func handle_check() {
+	if flag.NArg() < 2 {
+		fmt.Println("Error: source file required")
+		fmt.Println("Usage: seda check <source-file>")
+		os.Exit(1)
+	}
+	filename := flag.Arg(1)
+
+	input, err := os.ReadFile(filename)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+
+	p := parser.New(lexer.New(string(input)))
+	program := p.ParseProgram()
+
+	if p.HasErrors() {
+		fmt.Fprintf(os.Stderr, "Parse errors in %s:\n", filename)
+		for _, err := range p.FormatErrors() {
+			fmt.Fprintf(os.Stderr, "  %s\n", err)
+		}
+		os.Exit(1)
+	}
+
+	// Local modules are resolved relative to the checked file
+	abs_path, _ := filepath.Abs(filename)
+	diagnostics := checker.Check(program, filepath.Dir(abs_path))
+	if len(diagnostics) == 0 {
+		fmt.Printf("No problems found in %s\n", filename)
+		return
+	}
+
+	// Warnings are printed but only errors fail the check
+	failed := false
+	for _, diagnostic := range diagnostics {
+		failed = failed || !diagnostic.Warning
+	}
+	if failed {
+		fmt.Fprintf(os.Stderr, "Type errors in %s:\n", filename)
+	} else {
+		fmt.Fprintf(os.Stderr, "Warnings in %s:\n", filename)
+	}
+	for _, diagnostic := range diagnostics {
+		fmt.Fprintf(os.Stderr, "  %s\n", diagnostic)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
 // Package management commands
 
 func handle_install() {
@@ -110,3 +110,31 @@ print(x)`
 		t.Errorf("Expected file extension warning, got %q", outputStr)
 	}
 }
+
+func TestE2ECheckCommand(t *testing.T) {
+	// Create a file with a type error
+	testFile := "test_check.s"
+	content := `var count: number = "three"
+print(count)`
+
+	err := os.WriteFile(testFile, []byte(content), 0644)
+	if err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+	defer os.Remove(testFile)
+
+	// The checker reports the error without running the file
+	cmd := exec.Command("go", "run", "cmd/parser/main.go", "check", testFile)
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("Expected check to fail, got output %q", output)
+	}
+
+	outputStr := string(output)
+	if !strings.Contains(outputStr, "line 1:21: type error: variable 'count' expects number, got string") {
+		t.Errorf("Expected type error with position, got %q", outputStr)
+	}
+	if strings.Contains(outputStr, "three") {
+		t.Errorf("Expected the file not to run, got %q", outputStr)
+	}
+}
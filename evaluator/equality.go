@@ -0,0 +1,111 @@
+package evaluator
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// equality_methods are the methods a value can decide its own equality with, in the order they are tried
+var equality_methods = []string{"__eq", "equals"}
+
+// call_equals_hook lets a value decide its own equality with an __eq or equals method, found like
+// the methods of other operators. ==, !=, contains and every other comparison go through it.
+// found is false when left has neither method.
+func call_equals_hook(left, right object.Object) (object.Object, bool) {
+	for _, name := range equality_methods {
+		if result, found := call_operator_method(left, name, right); found {
+			return result, true
+		}
+	}
+	return nil, false
+}
+
+// properties_equal compares the custom properties attached to two maps or arrays. Both need
+// the same property names; data properties must be equal, while function properties only
+// need to exist on both, since each object usually gets its own copy of a method.
+func properties_equal(left, right map[string]object.Object) bool {
+	if len(left) != len(right) {
+		return false
+	}
+	for name, left_value := range left {
+		right_value, ok := right[name]
+		if !ok {
+			return false
+		}
+		_, left_fn := left_value.(*object.Function)
+		_, right_fn := right_value.(*object.Function)
+		if left_fn || right_fn {
+			if left_fn != right_fn {
+				return false
+			}
+			continue
+		}
+		if !is_equal(left_value, right_value) {
+			return false
+		}
+	}
+	return true
+}
+
+// map_key returns the string a value is stored under in a map. Strings are stored as they are,
+// so m["name"] and m.name find the same pair; every other key uses its canonical form, so
+// equal composite values such as [1, 2] or {"a": 1} land on the same pair. Those forms start
+// with a NUL byte and the type of the key, so 1, true or [1, 2] never meet "1", "true" or "[1, 2]".
+func map_key(key object.Object) string {
+	if str, ok := key.(*object.String); ok {
+		return str.Value
+	}
+	return "\x00" + string(key.Type()) + ":" + canonical_key(key)
+}
+
+// canonical_key returns a string that is the same for values is_equal considers equal:
+// maps and sets are written with sorted entries, and structs with a hash method use it.
+func canonical_key(obj object.Object) string {
+	switch obj := obj.(type) {
+	case *object.Array:
+		return "[" + canonical_elements(obj.Elements) + "]"
+	case *object.Tuple:
+		return "(" + canonical_elements(obj.Elements) + ")"
+	case *object.Set:
+		parts := make([]string, 0, obj.Len())
+		for _, element := range obj.Values() {
+			parts = append(parts, canonical_key(element))
+		}
+		sort.Strings(parts)
+		return "Set{" + strings.Join(parts, ", ") + "}"
+	case *object.Map:
+		parts := make([]string, 0, obj.Len())
+		for _, pair := range obj.Entries() {
+			parts = append(parts, canonical_key(pair.Key)+": "+canonical_key(pair.Value))
+		}
+		sort.Strings(parts)
+		return "{" + strings.Join(parts, ", ") + "}"
+	case *object.StructInstance:
+		if method, ok := obj.Struct.Methods["hash"].(*object.Function); ok {
+			return obj.Struct.Name + "#" + canonical_key(call_function(bind_self(method, obj), nil, nil))
+		}
+		parts := make([]string, 0, len(obj.Struct.Fields))
+		for _, field := range obj.Struct.Fields {
+			name := field.Name.Value
+			parts = append(parts, name+": "+canonical_key(obj.Fields[name]))
+		}
+		return obj.Struct.Name + "{" + strings.Join(parts, ", ") + "}"
+	case *object.Number:
+		return obj.Key()
+	case *object.String:
+		return strconv.Quote(obj.Value)
+	default:
+		return obj.Inspect()
+	}
+}
+
+func canonical_elements(elements []object.Object) string {
+	parts := make([]string, len(elements))
+	for i, element := range elements {
+		parts[i] = canonical_key(element)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,129 @@
+package evaluator
+
+import (
+	"testing"
+)
+
+// Equality Tests
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+		{`{"a": [1, {"c": 2}]} == {"a": [1, {"c": 2}]}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		{`{"a": 1} != {"a": 1}`, false},
+		{`{"a": 1} == nil`, false},
+		{"[1, [2, 3]] == [1, [2, 3]]", true},
+		{"[1, 2] == [2, 1]", false},
+		{"struct P ::\n  x: number\nend\nP(1) == P(1)", true},
+		{"struct P ::\n  x: number\nend\nP(1) != P(2)", true},
+		{"var a = [1]\na.tag = \"x\"\na == [1]", false},
+		{"var a = [1]\na.tag = \"x\"\nvar b = [1]\nb.tag = \"x\"\na == b", true},
+		{"const r = case {\"a\": 1} ::\n  {\"a\": 1} => true\n  _ => false\nend\nr", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEqualsHook(t *testing.T) {
+	money := moneyStruct +
+		"fn Money.equals(other) ::\n  return other.cents == self.cents\nend\n" +
+		"fn Money.hash() ::\n  return self.cents\nend\n"
+	vector := "fn vec(x) ::\n  const v = {\"x\": x}\n" +
+		"  v.equals = fn(self, other) :: return self[\"x\"] == other[\"x\"] end\n" +
+		"  return v\nend\n"
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{money + `Money(100, "EUR") == Money(100, "USD")`, true},
+		{money + `Money(100, "EUR") == Money(5, "EUR")`, false},
+		{money + `[Money(1, "EUR")] == [Money(1, "USD")]`, true},
+		{money + `{Money(5, "EUR"): "five"}[Money(5, "USD")] == "five"`, true},
+		{vector + "vec(1) == vec(1)", true},
+		{vector + "vec(1) != vec(2)", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestEqualityHooksAgree(t *testing.T) {
+	// ==, contains and map lookup must give the same answer whichever hook a type defines
+	eq := moneyStruct +
+		"fn Money.__eq(other) ::\n  return other.cents == self.cents\nend\n" +
+		"fn Money.hash() ::\n  return self.cents\nend\n"
+	equals := moneyStruct +
+		"fn Money.equals(other) ::\n  return other.cents == self.cents\nend\n" +
+		"fn Money.hash() ::\n  return self.cents\nend\n"
+	vector := "fn vec(x, y) ::\n  const v = {\"x\": x, \"y\": y}\n" +
+		"  v.__eq = fn(self, other) :: return self.x == other.x end\n" +
+		"  return v\nend\n"
+	checks := []struct {
+		input    string
+		expected bool
+	}{
+		{`a == b`, true},
+		{`a != b`, false},
+		{`[a].contains(b)`, true},
+		{`{a: "found"}.get(b, "missing") == "found"`, true},
+		{`a == c`, false},
+		{`[a].contains(c)`, false},
+		{`{a: "found"}.get(c, "missing") == "found"`, false},
+	}
+
+	for _, prelude := range []string{
+		eq + "var a = Money(1, \"EUR\")\nvar b = Money(1, \"USD\")\nvar c = Money(2, \"EUR\")\n",
+		equals + "var a = Money(1, \"EUR\")\nvar b = Money(1, \"USD\")\nvar c = Money(2, \"EUR\")\n",
+	} {
+		for _, tt := range checks {
+			testBooleanObject(t, testEval(prelude+tt.input), tt.expected)
+		}
+	}
+
+	// Maps are keyed by their contents, so only == and contains consult the hook
+	for _, tt := range checks[:2] {
+		testBooleanObject(t, testEval(vector+"var a = vec(1, 2)\nvar b = vec(1, 5)\n"+tt.input), tt.expected)
+	}
+	testBooleanObject(t, testEval(vector+"[vec(1, 2)].contains(vec(1, 5))"), true)
+}
+
+func TestCompositeMapKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{[1, 2]: "pair"}[[1, 2]]`, `"pair"`},
+		{`{{"a": 1, "b": 2}: "m"}[{"b": 2, "a": 1}]`, `"m"`},
+		{`{{1, 2}: "s"}[{2, 1}]`, `"s"`},
+		{"struct P ::\n  x: number\nend\n{P(1): \"p\"}[P(1)]", `"p"`},
+		{"var m = {}\nm[[1, 2]] = 3\nm[[1, 2]] = 4\nm.length()", "1"},
+		{`{[1, 2]: 3}.has([1, 2])`, "true"},
+		{`{[1, 2]: 3}.get([2, 1], 0)`, "0"},
+		{`{"a": 1}["a"]`, "1"},
+		{`{1: "one"}[1]`, `"one"`},
+		// Keys of different types stay apart even when they print alike
+		{"var m = {}\nm[1] = \"number\"\nm[\"1\"] = \"string\"\nm[1]", `"number"`},
+		{`{true: 1, "true": 2}.length()`, "2"},
+		{`{[1, 2]: 1, "[1, 2]": 2}["[1, 2]"]`, "2"},
+		{`{Set(): 1, {}: 2}[{}]`, "2"},
+		// Numbers that compare equal find the same pair
+		{`{0.3: "x"}[0.1 + 0.2]`, `"x"`},
+		{`{2: "two"}[4 / 2.0]`, `"two"`},
+		// Loops and JSON see the keys as they were given
+		{"var total = 0\nfor k in {1: \"a\", 2: \"b\"} ::\n  total += k\nend\ntotal", "3"},
+		{`JSON.stringify({1: "a", "b": 2})`, `"{"1":"a","b":2}"`},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
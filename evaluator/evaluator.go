@@ -1,7 +1,9 @@
 package evaluator
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"math/rand"
@@ -41,13 +43,15 @@ var global_json_module *object.Map
 var global_os_module *object.Map
 var global_time_module *object.Map
 var global_ui_module *object.Map
+var global_seq_module *object.Map
+var global_object_module *object.Map
 
 func init() {
 	// Initialize the global type objects
-	global_array_object = &object.Map{Pairs: make(map[string]object.MapPair)}
-	global_string_object = &object.Map{Pairs: make(map[string]object.MapPair)}
-	global_number_object = &object.Map{Pairs: make(map[string]object.MapPair)}
-	global_map_object = &object.Map{Pairs: make(map[string]object.MapPair)}
+	global_array_object = object.NewMap()
+	global_string_object = object.NewMap()
+	global_number_object = object.NewMap()
+	global_map_object = object.NewMap()
 
 	// Initialize global modules
 	global_math_module = init_math_module()
@@ -56,6 +60,15 @@ func init() {
 	global_os_module = init_os_module()
 	global_time_module = init_time_module()
 	global_ui_module = init_ui_module()
+	global_seq_module = init_seq_module()
+	global_object_module = init_object_module()
+
+	// Set builds from any iterable and print calls to_string methods, which both need the evaluator,
+	// so they join the global functions here
+	global_functions["Set"] = &object.Builtin{Fn: set_builtin}
+	global_functions["Tuple"] = &object.Builtin{Fn: tuple_builtin}
+	global_functions["print"] = &object.Builtin{Fn: print_builtin}
+	global_functions["println"] = &object.Builtin{Fn: println_builtin}
 
 	// Set up the evaluator reference for object_methods
 	SetEvaluator(func(node interface{}, env *object.Environment) object.Object {
@@ -94,6 +107,17 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return object.NewError("parse error: invalid syntax")
 	}
 
+	result := eval_node(node, env)
+
+	// The innermost node that produces a runtime error marks where it originated
+	if err, ok := result.(*object.Error); ok {
+		locate_error(err, node, env)
+	}
+	return result
+}
+
+// eval_node dispatches on the node type
+func eval_node(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// Program
 	case *ast.Program:
@@ -110,8 +134,33 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return val
 		}
 
+		// Patterns destructure arrays and maps into several variables
+		if node.Pattern != nil {
+			// Printing the pattern is only worth it when there is a type to report against
+			if node.Type != nil {
+				if err := check_variable_type(node.Type, node.Pattern.String(), val, env); err != nil {
+					return err
+				}
+			}
+			err := destructure(node.Pattern, val, env, func(name string, value object.Object) {
+				if node.IsConstant {
+					mark_immutable(value)
+					env.SetConstant(name, value)
+				} else {
+					env.Set(name, value)
+				}
+			})
+			if err != nil {
+				return err
+			}
+			return val
+		}
+
 		// Single assignment (backward compatible)
 		if len(node.Names) == 1 {
+			if err := check_variable_type(node.Type, node.Names[0].Value, val, env); err != nil {
+				return err
+			}
 			if node.IsConstant {
 				// Mark the value as immutable (deep immutability)
 				mark_immutable(val)
@@ -119,6 +168,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			} else {
 				env.Set(node.Names[0].Value, val)
 			}
+			env.SetType(node.Names[0].Value, node.Type)
 			return val
 		}
 
@@ -139,6 +189,13 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 				len(values), len(node.Names))
 		}
 
+		// The type annotation applies to every variable
+		for i, name := range node.Names {
+			if err := check_variable_type(node.Type, name.Value, values[i], env); err != nil {
+				return err
+			}
+		}
+
 		// Assign each value to corresponding variable
 		for i, name := range node.Names {
 			if node.IsConstant {
@@ -148,6 +205,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			} else {
 				env.Set(name.Value, values[i])
 			}
+			env.SetType(name.Value, node.Type)
 		}
 
 		return val
@@ -170,11 +228,29 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.ComponentStatement:
 		return eval_component_statement(node, env)
 
+	case *ast.StructStatement:
+		return eval_struct_statement(node, env)
+
 	case *ast.ReturnStatement:
 		return eval_return_statement(node, env)
 
+	case *ast.WhileStatement:
+		return eval_while_statement(node, env)
+
 	case *ast.BreakStatement:
-		return &object.Break{}
+		return &object.Break{Label: label_name(node.Label)}
+
+	case *ast.ContinueStatement:
+		return &object.Continue{Label: label_name(node.Label)}
+
+	case *ast.TryStatement:
+		return eval_try_statement(node, env)
+
+	case *ast.ThrowStatement:
+		return eval_throw_statement(node, env)
+
+	case *ast.YieldStatement:
+		return eval_yield_statement(node, env)
 
 	case *ast.CheckStatement:
 		return eval_check_statement(node, env)
@@ -215,6 +291,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return eval_prefix_expression(node.Operator, right)
 
 	case *ast.InfixExpression:
+		// a ?? b only evaluates b when a is null
+		if node.Operator == "??" {
+			left := Eval(node.Left, env)
+			if is_error(left) || left != object.NULL {
+				return left
+			}
+			return Eval(node.Right, env)
+		}
+
 		// Short-circuit evaluation for logical operators
 		if node.Operator == "&&" || node.Operator == "and" {
 			left := Eval(node.Left, env)
@@ -271,183 +356,278 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.MapLiteral:
 		return eval_map_literal(node, env)
 
+	case *ast.SetLiteral:
+		return eval_set_literal(node, env)
+
 	case *ast.UIElement:
 		return eval_ui_element(node, env)
 
 	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if is_error(left) {
-			return left
-		}
-		index := Eval(node.Index, env)
-		if is_error(index) {
-			return index
-		}
-		return eval_index_expression(left, index)
+		return end_chain(eval_index_link(node, env))
 
 	case *ast.AssignmentExpression:
-		val := Eval(node.Value, env)
-		if is_error(val) {
-			return val
-		}
+		return eval_assignment_expression(node, env)
 
-		// Handle identifier assignment
-		if ident, ok := node.Left.(*ast.Identifier); ok {
-			result := env.Update(ident.Value, val)
-			if is_error(result) {
-				return result
-			}
-			return val
-		}
+	case *ast.FunctionLiteral:
+		return eval_function_literal(node, env)
 
-		// Handle index assignment (e.g., map["key"] = value, array[0] = value)
-		if index_expr, ok := node.Left.(*ast.IndexExpression); ok {
-			// Evaluate the left side to get the collection
-			collection := Eval(index_expr.Left, env)
-			if is_error(collection) {
-				return collection
-			}
+	case *ast.CallExpression:
+		return end_chain(eval_call_expression(node, env))
 
-			// Evaluate the index
-			index := Eval(index_expr.Index, env)
-			if is_error(index) {
-				return index
-			}
+	case *ast.DotExpression:
+		return end_chain(eval_dot_expression(node, env))
 
-			// Handle map index assignment
-			if map_obj, ok := collection.(*object.Map); ok {
-				// Check if map is immutable
-				if map_obj.IsImmutable {
-					return object.NewError("cannot modify immutable map")
-				}
+	case *ast.CaseExpression:
+		return eval_case_expression(node, env)
 
-				// Convert index to string key
-				var key string
-				switch idx := index.(type) {
-				case *object.String:
-					key = idx.Value
-				case *object.Number:
-					key = idx.Inspect()
-				default:
-					key = index.Inspect()
-				}
+	case *ast.RangeExpression:
+		return eval_range_expression(node, env)
 
-				map_obj.Pairs[key] = object.MapPair{
-					Key:   index,
-					Value: val,
-				}
-				return val
-			}
+	case *ast.PipelineExpression:
+		return eval_pipeline_expression(node, env)
 
-			// Handle array index assignment
-			if array_obj, ok := collection.(*object.Array); ok {
-				// Check if array is immutable
-				if array_obj.IsImmutable {
-					return object.NewError("cannot modify immutable array")
-				}
+	case *ast.NamedArgument:
+		return object.NewError("named argument '%s' is only allowed in a call", node.Name.Value)
 
-				if num_idx, ok := index.(*object.Number); ok {
-					idx := int(num_idx.Value)
-					if idx < 0 || idx >= len(array_obj.Elements) {
-						return object.NewError("index out of bounds: %d", idx)
-					}
-					array_obj.Elements[idx] = val
-					return val
-				}
-				return object.NewError("array index must be a number, got %s", index.Type())
-			}
+	default:
+		return object.NewError("unknown node type: %T", node)
+	}
+}
 
-			return object.NewError("index assignment not supported for %s", collection.Type())
+// eval_assignment_expression assigns to a variable, an index or a property.
+// Compound assignments like += read the target once and combine its value with the new one.
+func eval_assignment_expression(node *ast.AssignmentExpression, env *object.Environment) object.Object {
+	switch left := node.Left.(type) {
+	// Handle identifier assignment
+	case *ast.Identifier:
+		val := eval_assigned_value(node, env, func() object.Object {
+			return eval_identifier(left, env)
+		})
+		if is_error(val) {
+			return val
 		}
 
-		// Handle property assignment (e.g., Array.map = fn(...) :: ... end)
-		if dot_expr, ok := node.Left.(*ast.DotExpression); ok {
-			// Evaluate the left side to get the object
-			obj := Eval(dot_expr.Left, env)
-			if is_error(obj) {
-				return obj
-			}
-
-			property_name := dot_expr.Property.Value
+		// Typed variables keep their declared type
+		if err := check_variable_type(env.TypeOf(left.Value), left.Value, val, env); err != nil {
+			return err
+		}
+		result := env.Update(left.Value, val)
+		if is_error(result) {
+			return result
+		}
+		return val
 
-			// Check if it's a Map object
-			if map_obj, ok := obj.(*object.Map); ok {
-				// If the key already exists in Pairs, update it there (data update)
-				// OR if the value is not a function, treat it as data
-				if _, exists := map_obj.Pairs[property_name]; exists || val.Type() != object.FUNCTION_OBJ {
-					map_obj.Pairs[property_name] = object.MapPair{
-						Key:   &object.String{Value: property_name},
-						Value: val,
-					}
-				} else {
-					// It's a new function being added - treat as a custom method
-					if map_obj.Properties == nil {
-						map_obj.Properties = make(map[string]object.Object)
-					}
-					map_obj.Properties[property_name] = val
-				}
+	// Handle index assignment (e.g., map["key"] = value, array[0] = value)
+	case *ast.IndexExpression:
+		var val object.Object
+		if !node.IsCompound() {
+			val = Eval(node.Value, env)
+			if is_error(val) {
 				return val
 			}
+		}
 
-			// Check if it's an Array object
-			if array_obj, ok := obj.(*object.Array); ok {
-				if array_obj.Properties == nil {
-					array_obj.Properties = make(map[string]object.Object)
-				}
-				array_obj.Properties[property_name] = val
-				return val
-			}
+		// Evaluate the left side to get the collection
+		collection := Eval(left.Left, env)
+		if is_error(collection) {
+			return collection
+		}
 
-			// Check if it's a String object
-			if str_obj, ok := obj.(*object.String); ok {
-				if str_obj.Properties == nil {
-					str_obj.Properties = make(map[string]object.Object)
-				}
-				str_obj.Properties[property_name] = val
+		// Evaluate the index
+		index := Eval(left.Index, env)
+		if is_error(index) {
+			return index
+		}
+
+		if node.IsCompound() {
+			val = eval_assigned_value(node, env, func() object.Object {
+				return eval_index_expression(collection, index)
+			})
+			if is_error(val) {
 				return val
 			}
+		}
+		return assign_index(collection, index, val)
 
-			// Check if it's a Number object
-			if num_obj, ok := obj.(*object.Number); ok {
-				if num_obj.Properties == nil {
-					num_obj.Properties = make(map[string]object.Object)
-				}
-				num_obj.Properties[property_name] = val
+	// Handle property assignment (e.g., Array.map = fn(...) :: ... end)
+	case *ast.DotExpression:
+		var val object.Object
+		if !node.IsCompound() {
+			val = Eval(node.Value, env)
+			if is_error(val) {
 				return val
 			}
+		}
 
-			// Check if it's a Boolean object
-			if bool_obj, ok := obj.(*object.Boolean); ok {
-				if bool_obj.Properties == nil {
-					bool_obj.Properties = make(map[string]object.Object)
-				}
-				bool_obj.Properties[property_name] = val
+		// Evaluate the left side to get the object
+		obj := Eval(left.Left, env)
+		if is_error(obj) {
+			return obj
+		}
+
+		if node.IsCompound() {
+			val = eval_assigned_value(node, env, func() object.Object {
+				return get_property(obj, left.Property.Value)
+			})
+			if is_error(val) {
 				return val
 			}
+		}
+		return assign_property(obj, left.Property.Value, val)
+	}
+
+	val := Eval(node.Value, env)
+	if is_error(val) {
+		return val
+	}
+	return object.NewError("invalid assignment target: %T", node.Left)
+}
+
+// eval_assigned_value evaluates the value of an assignment.
+// For compound assignments it is combined with the target's current value, read by current.
+func eval_assigned_value(node *ast.AssignmentExpression, env *object.Environment, current func() object.Object) object.Object {
+	if !node.IsCompound() {
+		return Eval(node.Value, env)
+	}
+
+	target := current()
+	if is_error(target) {
+		return target
+	}
+	val := Eval(node.Value, env)
+	if is_error(val) {
+		return val
+	}
 
-			return object.NewError("cannot assign property to %s", obj.Type())
+	// += appends to arrays in place, so aliases see the new elements like they do with push
+	if array_obj, ok := target.(*object.Array); ok && node.BinaryOperator() == "+" {
+		if array_obj.IsImmutable {
+			return object.NewError("cannot modify immutable array")
+		}
+		if added, ok := val.(*object.Array); ok {
+			array_obj.Elements = append(array_obj.Elements, added.Elements...)
+		} else {
+			array_obj.Elements = append(array_obj.Elements, val)
 		}
+		return array_obj
+	}
 
-		return object.NewError("invalid assignment target: %T", node.Left)
+	return eval_infix_expression(node.BinaryOperator(), target, val)
+}
 
-	case *ast.FunctionLiteral:
-		return eval_function_literal(node, env)
+// assign_index stores val at index of a map or array
+func assign_index(collection, index, val object.Object) object.Object {
+	// Handle map index assignment
+	if map_obj, ok := collection.(*object.Map); ok {
+		// Check if map is immutable
+		if map_obj.IsImmutable {
+			return object.NewError("cannot modify immutable map")
+		}
 
-	case *ast.CallExpression:
-		return eval_call_expression(node, env)
+		map_obj.Set(map_key(index), object.MapPair{
+			Key:   index,
+			Value: val,
+		})
+		return val
+	}
 
-	case *ast.DotExpression:
-		return eval_dot_expression(node, env)
+	// Handle array index assignment
+	if array_obj, ok := collection.(*object.Array); ok {
+		// Check if array is immutable
+		if array_obj.IsImmutable {
+			return object.NewError("cannot modify immutable array")
+		}
 
-	case *ast.CaseExpression:
-		return eval_case_expression(node, env)
+		if num_idx, ok := index.(*object.Number); ok {
+			idx := int(num_idx.Value)
+			if idx < 0 || idx >= len(array_obj.Elements) {
+				return object.NewError("index out of bounds: %d", idx)
+			}
+			array_obj.Elements[idx] = val
+			return val
+		}
+		return object.NewError("array index must be a number, got %s", index.Type())
+	}
 
-	case *ast.RangeExpression:
-		return eval_range_expression(node, env)
+	return object.NewError("index assignment not supported for %s", collection.Type())
+}
 
-	default:
-		return object.NewError("unknown node type: %T", node)
+// assign_property sets a property of a map, struct instance or built-in value
+func assign_property(obj object.Object, property_name string, val object.Object) object.Object {
+	// Check if it's a Map object
+	if map_obj, ok := obj.(*object.Map); ok {
+		// If the key already exists in Pairs, update it there (data update)
+		// OR if the value is not a function, treat it as data
+		if _, exists := map_obj.Get(property_name); exists || val.Type() != object.FUNCTION_OBJ {
+			if map_obj.IsImmutable {
+				return object.NewError("cannot modify immutable map")
+			}
+			map_obj.Set(property_name, object.MapPair{
+				Key:   &object.String{Value: property_name},
+				Value: val,
+			})
+		} else {
+			// It's a new function being added - treat as a custom method
+			if map_obj.Properties == nil {
+				map_obj.Properties = make(map[string]object.Object)
+			}
+			map_obj.Properties[property_name] = val
+		}
+		return val
+	}
+
+	// Check if it's a struct instance - only declared fields can be assigned
+	if instance, ok := obj.(*object.StructInstance); ok {
+		if instance.IsImmutable {
+			return object.NewError("cannot modify immutable %s", instance.Struct.Name)
+		}
+		if !instance.Struct.HasField(property_name) {
+			return object.NewError("unknown field '%s' on %s", property_name, instance.Struct.Name)
+		}
+		if err := check_field_type(instance.Struct, property_name, val); err != nil {
+			return err
+		}
+		instance.Fields[property_name] = val
+		return val
+	}
+
+	// Check if it's an Array object
+	if array_obj, ok := obj.(*object.Array); ok {
+		if array_obj.Properties == nil {
+			array_obj.Properties = make(map[string]object.Object)
+		}
+		array_obj.Properties[property_name] = val
+		return val
+	}
+
+	// Check if it's a String object
+	if str_obj, ok := obj.(*object.String); ok {
+		if str_obj.Properties == nil {
+			str_obj.Properties = make(map[string]object.Object)
+		}
+		str_obj.Properties[property_name] = val
+		return val
+	}
+
+	// Check if it's a Number object
+	if num_obj, ok := obj.(*object.Number); ok {
+		if num_obj.Properties == nil {
+			num_obj.Properties = make(map[string]object.Object)
+		}
+		num_obj.Properties[property_name] = val
+		return val
+	}
+
+	// Check if it's a Boolean object
+	if bool_obj, ok := obj.(*object.Boolean); ok {
+		if bool_obj.Properties == nil {
+			bool_obj.Properties = make(map[string]object.Object)
+		}
+		bool_obj.Properties[property_name] = val
+		return val
 	}
+
+	return object.NewError("cannot assign property to %s", obj.Type())
 }
 
 // eval_program evaluates a program (list of statements)
@@ -455,11 +635,14 @@ func eval_program(stmts []ast.Statement, env *object.Environment) object.Object
 	var result object.Object
 
 	for _, statement := range stmts {
+		track_statement(statement, env)
 		result = Eval(statement, env)
 
 		switch result := result.(type) {
 		case *object.ReturnValue:
 			return result.Value
+		case *object.Break, *object.Continue:
+			return stray_loop_exit(result)
 		case *object.Error:
 			// Only propagate runtime errors immediately
 			// User-created errors (via error() builtin) are treated as regular values
@@ -477,11 +660,12 @@ func eval_block_statement(block *ast.BlockStatement, env *object.Environment) ob
 	var result object.Object
 
 	for _, statement := range block.Statements {
+		track_statement(statement, env)
 		result = Eval(statement, env)
 
 		if result != nil {
 			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.BREAK_OBJ {
+			if rt == object.RETURN_VALUE_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
 				return result
 			}
 			// Propagate runtime errors immediately, but not user-created errors
@@ -498,13 +682,11 @@ func eval_block_statement(block *ast.BlockStatement, env *object.Environment) ob
 
 // Literal evaluation functions
 func eval_number_literal(node *ast.NumberLiteral) object.Object {
-	// Convert string to float64
-	var value float64
-	_, err := fmt.Sscanf(node.Value, "%f", &value)
-	if err != nil {
+	value, ok := parse_number(node.Value)
+	if !ok {
 		return object.NewError("invalid number: %s", node.Value)
 	}
-	return &object.Number{Value: value}
+	return value
 }
 
 func eval_string_literal(node *ast.StringLiteral) object.Object {
@@ -524,6 +706,14 @@ func eval_interpolated_string(node *ast.InterpolatedString, env *object.Environm
 			return evaluated
 		}
 
+		// Types with a to_string method show themselves
+		if str, found := custom_string(evaluated); found {
+			if is_error(str) {
+				return str
+			}
+			evaluated = str
+		}
+
 		// Convert to string and append
 		// Use Value directly for strings to avoid quoted output
 		switch val := evaluated.(type) {
@@ -585,28 +775,40 @@ func eval_identifier(node *ast.Identifier, env *object.Environment) object.Objec
 			}
 			return global_ui_module
 		}
+		if node.Value == "Seq" {
+			if global_seq_module == nil {
+				global_seq_module = init_seq_module()
+			}
+			return global_seq_module
+		}
+		if node.Value == "Object" {
+			if global_object_module == nil {
+				global_object_module = init_object_module()
+			}
+			return global_object_module
+		}
 		// Check for global type objects
 		if node.Value == "Array" {
 			if global_array_object == nil {
-				global_array_object = &object.Map{Pairs: make(map[string]object.MapPair)}
+				global_array_object = object.NewMap()
 			}
 			return global_array_object
 		}
 		if node.Value == "String" {
 			if global_string_object == nil {
-				global_string_object = &object.Map{Pairs: make(map[string]object.MapPair)}
+				global_string_object = object.NewMap()
 			}
 			return global_string_object
 		}
 		if node.Value == "Number" {
 			if global_number_object == nil {
-				global_number_object = &object.Map{Pairs: make(map[string]object.MapPair)}
+				global_number_object = object.NewMap()
 			}
 			return global_number_object
 		}
 		if node.Value == "Map" {
 			if global_map_object == nil {
-				global_map_object = &object.Map{Pairs: make(map[string]object.MapPair)}
+				global_map_object = object.NewMap()
 			}
 			return global_map_object
 		}
@@ -632,7 +834,7 @@ func eval_expressions(exps []ast.Expression, env *object.Environment) []object.O
 }
 
 func eval_map_literal(node *ast.MapLiteral, env *object.Environment) object.Object {
-	pairs := make(map[string]object.MapPair)
+	map_obj := object.NewMap()
 
 	for _, pair := range node.Pairs {
 		key := Eval(pair.Key, env)
@@ -645,12 +847,10 @@ func eval_map_literal(node *ast.MapLiteral, env *object.Environment) object.Obje
 			return value
 		}
 
-		// Use string representation of key
-		keyStr := key.String()
-		pairs[keyStr] = object.MapPair{Key: key, Value: value}
+		map_obj.Set(map_key(key), object.MapPair{Key: key, Value: value})
 	}
 
-	return &object.Map{Pairs: pairs}
+	return map_obj
 }
 
 func eval_ui_element(node *ast.UIElement, env *object.Environment) object.Object {
@@ -695,7 +895,12 @@ func eval_index_expression(left, index object.Object) object.Object {
 		return eval_string_index_expression(left, index)
 	case left.Type() == object.MAP_OBJ:
 		return eval_map_index_expression(left, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.NUMBER_OBJ:
+		return eval_tuple_index_expression(left, index)
 	default:
+		if result, found := call_operator_method(left, "__index", index); found {
+			return result
+		}
 		return object.NewError("index operator not supported: %s", left.Type())
 	}
 }
@@ -724,12 +929,27 @@ func eval_array_index_expression(array, index object.Object) object.Object {
 	return array_object.Elements[idx]
 }
 
+func eval_tuple_index_expression(tuple, index object.Object) object.Object {
+	tuple_object := tuple.(*object.Tuple)
+	idx := int(index.(*object.Number).Value)
+	max := len(tuple_object.Elements) - 1
+
+	if idx < 0 || idx > max {
+		return object.NULL
+	}
+
+	return tuple_object.Elements[idx]
+}
+
 func eval_map_index_expression(map_obj, index object.Object) object.Object {
 	map_object := map_obj.(*object.Map)
-	key := index.String()
 
-	pair, ok := map_object.Pairs[key]
+	pair, ok := lookup_pair(map_object, map_key(index))
 	if !ok {
+		// An __index method supplies the keys the map does not hold
+		if result, found := call_operator_method(map_object, "__index", index); found {
+			return result
+		}
 		return object.NULL
 	}
 
@@ -743,6 +963,11 @@ func eval_prefix_expression(operator string, right object.Object) object.Object
 		return eval_bang_operator_expression(right)
 	case "-":
 		return eval_minus_prefix_operator_expression(right)
+	case "~":
+		if number, ok := right.(*object.Number); ok {
+			return complement_number(number)
+		}
+		return object.NewError("unknown operator: ~%s", right.Type())
 	default:
 		return object.NewError("unknown operator: %s%s", operator, right.Type())
 	}
@@ -766,8 +991,7 @@ func eval_minus_prefix_operator_expression(right object.Object) object.Object {
 		return object.NewError("unknown operator: -%s", right.Type())
 	}
 
-	value := right.(*object.Number).Value
-	return &object.Number{Value: -value}
+	return negate_number(right.(*object.Number))
 }
 
 // Infix expression evaluation
@@ -777,6 +1001,11 @@ func eval_infix_expression(operator string, left, right object.Object) object.Ob
 		return eval_logical_infix_expression(operator, left, right)
 	}
 
+	// Maps, arrays and struct instances can define methods such as __add and __eq for operators
+	if result, found := eval_overloaded_operator(operator, left, right); found {
+		return result
+	}
+
 	switch {
 	case left.Type() == object.NUMBER_OBJ && right.Type() == object.NUMBER_OBJ:
 		return eval_number_infix_expression(operator, left, right)
@@ -784,10 +1013,15 @@ func eval_infix_expression(operator string, left, right object.Object) object.Ob
 		return eval_string_infix_expression(operator, left, right)
 	case left.Type() == object.BOOLEAN_OBJ && right.Type() == object.BOOLEAN_OBJ:
 		return eval_boolean_infix_expression(operator, left, right)
+	case left.Type() == object.SET_OBJ && right.Type() == object.SET_OBJ:
+		return eval_set_infix_expression(operator, left.(*object.Set), right.(*object.Set))
+	case left.Type() == object.TUPLE_OBJ && right.Type() == object.TUPLE_OBJ && (operator == "==" || operator == "!="):
+		// Tuples are values, so they compare by their elements
+		return native_bool(is_equal(left, right) == (operator == "=="))
 	case operator == "==":
-		return native_bool(left == right)
+		return native_bool(is_equal(left, right))
 	case operator == "!=":
-		return native_bool(left != right)
+		return native_bool(!is_equal(left, right))
 	case left.Type() != right.Type():
 		return object.NewError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -795,46 +1029,6 @@ func eval_infix_expression(operator string, left, right object.Object) object.Ob
 	}
 }
 
-func eval_number_infix_expression(operator string, left, right object.Object) object.Object {
-	left_val := left.(*object.Number).Value
-	right_val := right.(*object.Number).Value
-
-	switch operator {
-	case "+":
-		return &object.Number{Value: left_val + right_val}
-	case "-":
-		return &object.Number{Value: left_val - right_val}
-	case "*":
-		return &object.Number{Value: left_val * right_val}
-	case "/":
-		if right_val == 0 {
-			return object.NewError("division by zero")
-		}
-		return &object.Number{Value: left_val / right_val}
-	case "%":
-		if right_val == 0 {
-			return object.NewError("division by zero")
-		}
-		return &object.Number{Value: float64(int(left_val) % int(right_val))}
-	case "^":
-		return &object.Number{Value: math.Pow(left_val, right_val)}
-	case "<":
-		return native_bool(left_val < right_val)
-	case ">":
-		return native_bool(left_val > right_val)
-	case "<=":
-		return native_bool(left_val <= right_val)
-	case ">=":
-		return native_bool(left_val >= right_val)
-	case "==":
-		return native_bool(left_val == right_val)
-	case "!=":
-		return native_bool(left_val != right_val)
-	default:
-		return object.NewError("unknown operator: %s", operator)
-	}
-}
-
 func eval_string_infix_expression(operator string, left, right object.Object) object.Object {
 	left_val := left.(*object.String).Value
 	right_val := right.(*object.String).Value
@@ -885,15 +1079,22 @@ func mark_immutable(obj object.Object) {
 	case *object.Map:
 		v.IsImmutable = true
 		// Recursively mark all nested values as immutable
-		for _, pair := range v.Pairs {
+		for _, pair := range v.Entries() {
 			mark_immutable(pair.Value)
 		}
+	case *object.Set:
+		v.IsImmutable = true
 	case *object.Number:
 		v.IsImmutable = true
 	case *object.String:
 		v.IsImmutable = true
 	case *object.Boolean:
 		v.IsImmutable = true
+	case *object.StructInstance:
+		v.IsImmutable = true
+		for _, field := range v.Fields {
+			mark_immutable(field)
+		}
 	// Other types (NULL, functions, etc.) don't need immutability tracking
 	}
 }
@@ -989,21 +1190,14 @@ func eval_for_statement(node *ast.ForStatement, env *object.Environment) object.
 			}
 
 			// Set value variable
-			loop_env.Set(node.Variable.Value, element)
-
-			// Execute loop body
-			result = Eval(node.Body, loop_env)
-			if is_error(result) {
-				return result
-			}
-
-			// Handle break
-			if result.Type() == object.BREAK_OBJ {
-				return object.NULL
+			if err := bind_loop_variable(node, loop_env, element); err != nil {
+				return err
 			}
 
-			// Handle return values
-			if result.Type() == object.RETURN_VALUE_OBJ {
+			// Execute loop body
+			var done bool
+			result, done = loop_exit(Eval(node.Body, loop_env), node.Label)
+			if done {
 				return result
 			}
 		}
@@ -1017,134 +1211,172 @@ func eval_for_statement(node *ast.ForStatement, env *object.Environment) object.
 			}
 
 			// Set value variable (character as string)
-			loop_env.Set(node.Variable.Value, &object.String{Value: string(char)})
-
-			// Execute loop body
-			result = Eval(node.Body, loop_env)
-			if is_error(result) {
-				return result
+			if err := bind_loop_variable(node, loop_env, &object.String{Value: string(char)}); err != nil {
+				return err
 			}
 
-			// Handle break
-			if result.Type() == object.BREAK_OBJ {
-				return object.NULL
-			}
-
-			// Handle return values
-			if result.Type() == object.RETURN_VALUE_OBJ {
+			// Execute loop body
+			var done bool
+			result, done = loop_exit(Eval(node.Body, loop_env), node.Label)
+			if done {
 				return result
 			}
 		}
 
 	case *object.Range:
-		// Iterate over range
-		end := iter.End
-		if iter.Inclusive {
-			end = iter.End + 1
-		}
-		for i := iter.Start; i < end; i++ {
+		// Iterate over range; more is checked before i moves on, so a range ending at the
+		// largest integer does not overflow
+		first, last, more := iter.Bounds()
+		for i := first; more; i++ {
+			more = i < last
 			// Set index variable if present (for ranges, this is the iteration count)
 			if node.Index != nil {
-				loop_env.Set(node.Index.Value, &object.Number{Value: float64(i - iter.Start)})
+				loop_env.Set(node.Index.Value, object.NewInteger(i-first))
 			}
 
 			// Set value variable (the current number in the range)
-			loop_env.Set(node.Variable.Value, &object.Number{Value: float64(i)})
-
-			// Execute loop body
-			result = Eval(node.Body, loop_env)
-			if is_error(result) {
-				return result
+			if err := bind_loop_variable(node, loop_env, object.NewInteger(i)); err != nil {
+				return err
 			}
 
-			// Handle break
-			if result.Type() == object.BREAK_OBJ {
-				return object.NULL
-			}
-
-			// Handle return values
-			if result.Type() == object.RETURN_VALUE_OBJ {
+			// Execute loop body
+			var done bool
+			result, done = loop_exit(Eval(node.Body, loop_env), node.Label)
+			if done {
 				return result
 			}
 		}
 
 	case *object.Map:
-		// Iterate over map key-value pairs
-		for key, pair := range iter.Pairs {
+		// Maps with a next or iter method iterate over what those produce
+		if is_protocol_iterable(iter) {
+			return eval_for_iterator(node, iter, loop_env)
+		}
+
+		// Iterate over map key-value pairs in the order they were added
+		for _, key := range iter.Keys() {
+			// Keys the loop body deleted are skipped
+			pair, ok := iter.Get(key)
+			if !ok {
+				continue
+			}
 			// Set index variable if present (for maps, this is the value)
 			if node.Index != nil {
 				loop_env.Set(node.Index.Value, pair.Value)
 			}
 
-			// Set value variable (the key)
-			loop_env.Set(node.Variable.Value, &object.String{Value: key})
-
-			// Execute loop body
-			result = Eval(node.Body, loop_env)
-			if is_error(result) {
-				return result
-			}
-
-			// Handle break
-			if result.Type() == object.BREAK_OBJ {
-				return object.NULL
+			// Set value variable (the key, as it was given)
+			if err := bind_loop_variable(node, loop_env, pair.Key); err != nil {
+				return err
 			}
 
-			// Handle return values
-			if result.Type() == object.RETURN_VALUE_OBJ {
+			// Execute loop body
+			var done bool
+			result, done = loop_exit(Eval(node.Body, loop_env), node.Label)
+			if done {
 				return result
 			}
 		}
 
 	default:
-		return object.NewError("object is not iterable: %T", iterable)
+		// Generators, other iterators and struct instances with a next or iter method
+		return eval_for_iterator(node, iterable, loop_env)
 	}
 
 	return result
 }
 
-func eval_case_statement(node *ast.CaseStatement, env *object.Environment) object.Object {
-	if node == nil {
-		return object.NewError("case statement is nil")
+// bind_loop_variable sets the loop variable, or destructures the element when the loop has a pattern
+func bind_loop_variable(node *ast.ForStatement, loop_env *object.Environment, element object.Object) *object.Error {
+	if node.Pattern == nil {
+		loop_env.Set(node.Variable.Value, element)
+		return nil
 	}
+	return destructure(node.Pattern, element, loop_env, func(name string, value object.Object) {
+		loop_env.Set(name, value)
+	})
+}
 
-	// Evaluate the expression to match against
-	expr := Eval(node.Expression, env)
-	if is_error(expr) {
-		return expr
-	}
+func eval_while_statement(node *ast.WhileStatement, env *object.Environment) object.Object {
+	// Create a new environment for the loop scope
+	loop_env := object.NewEnclosedEnvironment(env)
 
-	// Try each case branch
-	for _, branch := range node.Branches {
-		if branch == nil {
-			continue
-		}
+	var result object.Object = object.NULL
 
-		// Check for wildcard pattern (underscore) before evaluation
-		if ident, ok := branch.Pattern.(*ast.Identifier); ok && ident.Value == "_" {
-			// Wildcard matches everything
-			return Eval(branch.Result, env)
+	for {
+		condition := Eval(node.Condition, loop_env)
+		if is_error(condition) {
+			return condition
+		}
+		if !is_truthy(condition) {
+			break
 		}
 
-		// Evaluate the pattern
-		pattern := Eval(branch.Pattern, env)
-		if is_error(pattern) {
-			return pattern
+		// Execute loop body
+		var done bool
+		result, done = loop_exit(Eval(node.Body, loop_env), node.Label)
+		if done {
+			return result
 		}
+	}
+
+	return result
+}
 
-		// Check if pattern matches expression
-		if is_equal(expr, pattern) {
-			return Eval(branch.Result, env)
+// loop_exit interprets the result of a loop body. It returns the loop's value so far and
+// whether the loop stops; breaks and continues aimed at an outer loop stop this loop and propagate.
+func loop_exit(result object.Object, label *ast.Identifier) (object.Object, bool) {
+	if is_error(result) {
+		return result, true
+	}
+
+	switch control := result.(type) {
+	case *object.Break:
+		if control.Label == "" || control.Label == label_name(label) {
+			return object.NULL, true
+		}
+		return control, true
+	case *object.Continue:
+		if control.Label == "" || control.Label == label_name(label) {
+			return object.NULL, false
 		}
+		return control, true
+	case *object.ReturnValue:
+		return result, true
 	}
+	return result, false
+}
 
-	// No match found
-	return object.NULL
+// stray_loop_exit returns result, or an error when it is a break or continue that left every
+// loop, which happens when it names a label no enclosing loop of its function has
+func stray_loop_exit(result object.Object) object.Object {
+	switch control := result.(type) {
+	case *object.Break:
+		return loop_exit_error("break", control.Label)
+	case *object.Continue:
+		return loop_exit_error("continue", control.Label)
+	}
+	return result
 }
 
-func eval_case_expression(node *ast.CaseExpression, env *object.Environment) object.Object {
+func loop_exit_error(keyword, label string) *object.Error {
+	if label == "" {
+		return object.NewError("%s outside of a loop", keyword)
+	}
+	return object.NewError("no enclosing loop labeled '%s'", label)
+}
+
+// label_name returns the name of an optional loop label
+func label_name(label *ast.Identifier) string {
+	if label == nil {
+		return ""
+	}
+	return label.Value
+}
+
+func eval_case_statement(node *ast.CaseStatement, env *object.Environment) object.Object {
 	if node == nil {
-		return object.NewError("case expression is nil")
+		return object.NewError("case statement is nil")
 	}
 
 	// Evaluate the expression to match against
@@ -1153,32 +1385,21 @@ func eval_case_expression(node *ast.CaseExpression, env *object.Environment) obj
 		return expr
 	}
 
-	// Try each case branch
-	for _, branch := range node.Branches {
-		if branch == nil {
-			continue
-		}
-
-		// Check for wildcard pattern (underscore) before evaluation
-		if ident, ok := branch.Pattern.(*ast.Identifier); ok && ident.Value == "_" {
-			// Wildcard matches everything
-			return Eval(branch.Result, env)
-		}
+	return eval_case_branches(expr, node.Branches, env)
+}
 
-		// Evaluate the pattern
-		pattern := Eval(branch.Pattern, env)
-		if is_error(pattern) {
-			return pattern
-		}
+func eval_case_expression(node *ast.CaseExpression, env *object.Environment) object.Object {
+	if node == nil {
+		return object.NewError("case expression is nil")
+	}
 
-		// Check if pattern matches expression
-		if is_equal(expr, pattern) {
-			return Eval(branch.Result, env)
-		}
+	// Evaluate the expression to match against
+	expr := Eval(node.Expression, env)
+	if is_error(expr) {
+		return expr
 	}
 
-	// No match found - return NULL for expressions
-	return object.NULL
+	return eval_case_branches(expr, node.Branches, env)
 }
 
 func eval_range_expression(node *ast.RangeExpression, env *object.Environment) object.Object {
@@ -1202,11 +1423,37 @@ func eval_range_expression(node *ast.RangeExpression, env *object.Environment) o
 		return object.NewError("range end must be a number, got %s", end.Type())
 	}
 
+	// A range holds the whole numbers between its bounds, so fractional bounds are rounded
+	// inwards instead of truncated: 0..2.5 holds 0, 1 and 2, like 0...2
+	start_value, _, err := range_bound("start", start_num, math.Ceil)
+	if err != nil {
+		return err
+	}
+	end_value, end_exact, err := range_bound("end", end_num, math.Floor)
+	if err != nil {
+		return err
+	}
+
 	return &object.Range{
-		Start:     int(start_num.Value),
-		End:       int(end_num.Value),
-		Inclusive: node.Inclusive,
+		Start:     start_value,
+		End:       end_value,
+		Inclusive: node.Inclusive || !end_exact,
+	}
+}
+
+// range_bound converts a range bound to a 64-bit integer, rounding a fractional one with round.
+// exact is false when the bound had a fractional part.
+func range_bound(name string, bound *object.Number, round func(float64) float64) (value int64, exact bool, err *object.Error) {
+	if value, ok := bound.Int64(); ok {
+		return value, true, nil
 	}
+	if bound.Kind == object.FLOAT || bound.Kind == object.DECIMAL {
+		rounded := round(bound.Value)
+		if rounded >= math.MinInt64 && rounded < math.MaxInt64 {
+			return int64(rounded), rounded == bound.Value, nil
+		}
+	}
+	return 0, false, object.NewError("range %s %s does not fit in 64 bits", name, bound.Inspect())
 }
 
 // Helper functions for control flow
@@ -1225,6 +1472,11 @@ func is_truthy(obj object.Object) bool {
 }
 
 func is_equal(left, right object.Object) bool {
+	// A value with an equals hook decides for itself; an error counts as not equal
+	if result, found := call_equals_hook(left, right); found {
+		return !is_error(result) && is_truthy(result)
+	}
+
 	// Handle different types
 	if left.Type() != right.Type() {
 		return false
@@ -1232,11 +1484,7 @@ func is_equal(left, right object.Object) bool {
 
 	switch left.Type() {
 	case object.NUMBER_OBJ:
-		// Use epsilon-based comparison for floating-point numbers
-		left_val := left.(*object.Number).Value
-		right_val := right.(*object.Number).Value
-		epsilon := 1e-8 // Tolerance for floating-point comparison (10 nanounits)
-		return math.Abs(left_val-right_val) < epsilon
+		return numbers_equal(left.(*object.Number), right.(*object.Number))
 	case object.STRING_OBJ:
 		return left.(*object.String).Value == right.(*object.String).Value
 	case object.BOOLEAN_OBJ:
@@ -1258,6 +1506,64 @@ func is_equal(left, right object.Object) bool {
 				return false
 			}
 		}
+		return properties_equal(left_arr.Properties, right_arr.Properties)
+	case object.MAP_OBJ:
+		// Maps are equal when they share a prototype and hold the same keys with equal values, in any order
+		left_map := left.(*object.Map)
+		right_map := right.(*object.Map)
+		if left_map.Len() != right_map.Len() {
+			return false
+		}
+		if left_map.Proto != right_map.Proto {
+			return false
+		}
+		for _, key := range left_map.Keys() {
+			left_pair, _ := left_map.Get(key)
+			right_pair, ok := right_map.Get(key)
+			if !ok || !is_equal(left_pair.Value, right_pair.Value) {
+				return false
+			}
+		}
+		return properties_equal(left_map.Properties, right_map.Properties)
+	case object.TUPLE_OBJ:
+		left_tuple := left.(*object.Tuple)
+		right_tuple := right.(*object.Tuple)
+		if len(left_tuple.Elements) != len(right_tuple.Elements) {
+			return false
+		}
+		for i := range left_tuple.Elements {
+			if !is_equal(left_tuple.Elements[i], right_tuple.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case object.SET_OBJ:
+		// Sets are equal when they hold the same elements, in any order
+		left_set := left.(*object.Set)
+		right_set := right.(*object.Set)
+		if left_set.Len() != right_set.Len() {
+			return false
+		}
+		for _, element := range left_set.Values() {
+			if !right_set.Contains(element) {
+				return false
+			}
+		}
+		return true
+	case object.STRUCT_INSTANCE_OBJ:
+		left_inst := left.(*object.StructInstance)
+		right_inst := right.(*object.StructInstance)
+
+		// Instances must come from the same struct and have equal fields
+		if left_inst.Struct != right_inst.Struct {
+			return false
+		}
+		for _, field := range left_inst.Struct.Fields {
+			name := field.Name.Value
+			if !is_equal(left_inst.Fields[name], right_inst.Fields[name]) {
+				return false
+			}
+		}
 		return true
 	default:
 		return left == right
@@ -1268,11 +1574,31 @@ func is_equal(left, right object.Object) bool {
 
 func eval_fn_statement(node *ast.FnStatement, env *object.Environment) object.Object {
 	// Function definitions create a function object and bind it to the environment
+	name := node.Name.Value
+	if node.Receiver != nil {
+		name = node.Receiver.Name + "." + name
+	}
+
 	fn := &object.Function{
+		Name:       name,
 		Parameters: node.Parameters,
+		ReturnType: node.ReturnType,
 		Body:       node.Body,
 		Env:        env,
 		WhereBlock: node.WhereBlock,
+		Generator:  node.Generator,
+	}
+
+	// Methods declared as fn Struct.method() are attached to the struct
+	if node.Receiver != nil {
+		receivers, err := method_receivers(node.Receiver.Name, env)
+		if err != nil {
+			return err
+		}
+		for _, struct_type := range receivers {
+			struct_type.Methods[node.Name.Value] = fn
+		}
+		return fn
 	}
 
 	// Bind the function to the environment
@@ -1280,6 +1606,28 @@ func eval_fn_statement(node *ast.FnStatement, env *object.Environment) object.Ob
 	return fn
 }
 
+// method_receivers returns the structs a method declared on name attaches to: a struct, a variant
+// of a sum type, or every variant when name is the sum type itself
+func method_receivers(name string, env *object.Environment) ([]*object.Struct, *object.Error) {
+	value, ok := env.Get(name)
+	if !ok {
+		return nil, object.NewError("cannot declare method on %s: not defined", name)
+	}
+	switch value := value.(type) {
+	case *object.Struct:
+		return []*object.Struct{value}, nil
+	case *object.StructInstance:
+		if value.Struct.IsUnitVariant() {
+			return []*object.Struct{value.Struct}, nil
+		}
+	case *object.TypeAlias:
+		if value.IsSumType() {
+			return value.Variants, nil
+		}
+	}
+	return nil, object.NewError("cannot declare method on %s: not a struct", name)
+}
+
 func eval_component_statement(node *ast.ComponentStatement, env *object.Environment) object.Object {
 	// Component definitions create a UIComponent object and bind it to the environment
 	component := &object.UIComponent{
@@ -1327,75 +1675,349 @@ func eval_return_statement(node *ast.ReturnStatement, env *object.Environment) o
 	}
 }
 
+// eval_try_statement runs the body, hands a raised error to the catch block and always runs ensure
+func eval_try_statement(node *ast.TryStatement, env *object.Environment) object.Object {
+	result := Eval(node.Body, env)
+
+	if err, ok := result.(*object.Error); ok && !err.IsUserCreated && node.CatchBlock != nil {
+		catch_env := object.NewEnclosedEnvironment(env)
+		if node.ErrorName != nil {
+			// The caught error becomes a regular value so it can be inspected, stored or rethrown
+			caught := *err
+			caught.IsUserCreated = true
+			catch_env.Set(node.ErrorName.Value, &caught)
+		}
+		result = Eval(node.CatchBlock, catch_env)
+	}
+
+	if node.EnsureBlock != nil {
+		// Errors and control flow from ensure take over, otherwise the earlier outcome stands
+		ensured := Eval(node.EnsureBlock, env)
+		if is_runtime_error(ensured) {
+			return ensured
+		}
+		if ensured != nil {
+			if rt := ensured.Type(); rt == object.RETURN_VALUE_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
+				return ensured
+			}
+		}
+	}
+
+	if result == nil {
+		return object.NULL
+	}
+	return result
+}
+
+// eval_throw_statement raises an error value or message so it propagates until caught
+func eval_throw_statement(node *ast.ThrowStatement, env *object.Environment) object.Object {
+	val := Eval(node.Value, env)
+	if is_runtime_error(val) {
+		return val
+	}
+
+	switch val := val.(type) {
+	case *object.Error:
+		// Rethrown errors keep the position and traceback of where they first failed
+		thrown := *val
+		thrown.IsUserCreated = false
+		return &thrown
+	case *object.String:
+		return object.NewError("%s", val.Value)
+	}
+	return object.NewError("throw expects an error or string, got %s", val.Type())
+}
+
 func eval_function_literal(node *ast.FunctionLiteral, env *object.Environment) object.Object {
 	return &object.Function{
 		Parameters: node.Parameters,
+		ReturnType: node.ReturnType,
 		Body:       node.Body,
 		Env:        env,
+		Generator:  node.Generator,
 	}
 }
 
 func eval_call_expression(node *ast.CallExpression, env *object.Environment) object.Object {
 	// Check if this is a method call (obj.method())
 	if dot_expr, ok := node.Function.(*ast.DotExpression); ok {
-		return eval_method_call(dot_expr, node.Arguments, env)
+		return eval_method_call(dot_expr, node.Arguments, env, node.Optional)
 	}
 
 	// Regular function call
-	function := Eval(node.Function, env)
-	if is_error(function) {
+	function := eval_chain_left(node.Function, env)
+	if is_error(function) || function == short_circuit {
 		return function
 	}
+	if node.Optional && function == object.NULL {
+		return short_circuit
+	}
 
-	args := eval_expressions(node.Arguments, env)
+	args, named := eval_call_arguments(node.Arguments, env)
 	// Propagate runtime errors immediately, but allow user-created errors as arguments
 	if len(args) == 1 && is_runtime_error(args[0]) {
 		return args[0]
 	}
 
-	return apply_function(function, args, env)
+	return apply_named_call(function, args, named, env)
 }
 
-func apply_function(fn object.Object, args []object.Object, callerEnv *object.Environment) object.Object {
-	switch function := fn.(type) {
-	case *object.Function:
-		extended_env := extend_function_env(function, args)
-		evaluated := Eval(function.Body, extended_env)
-		result := unwrap_return_value(evaluated)
+// eval_pipeline_expression calls the right side with the left value as its first argument
+func eval_pipeline_expression(node *ast.PipelineExpression, env *object.Environment) object.Object {
+	input := Eval(node.Left, env)
+	if is_runtime_error(input) {
+		return input
+	}
 
-		// Execute where block assertions if present (but not if we're already in a where block test)
-		if function.WhereBlock != nil && !in_where_block_test {
-			test_result := eval_where_block(function.WhereBlock, extended_env, result, args)
-			if test_result.Failed > 0 {
-				// Print test failures during normal execution
-				if !in_test_mode {
-					fmt.Printf("Function test failures:\n%s\n", test_result.String())
-				}
+	call, ok := node.Right.(*ast.CallExpression)
+	if !ok {
+		function := Eval(node.Right, env)
+		if is_error(function) {
+			return function
+		}
+		return apply_function(function, []object.Object{input}, env)
+	}
+
+	function := Eval(call.Function, env)
+	if is_error(function) {
+		return function
+	}
+	args, named := eval_call_arguments(call.Arguments, env)
+	if len(args) == 1 && is_runtime_error(args[0]) {
+		return args[0]
+	}
+	args = append([]object.Object{input}, args...)
+
+	return apply_named_call(function, args, named, env)
+}
+
+// eval_call_arguments evaluates call arguments, separating named arguments (name: value) from positional ones
+func eval_call_arguments(exps []ast.Expression, env *object.Environment) ([]object.Object, map[string]object.Object) {
+	var args []object.Object
+	var named map[string]object.Object
+
+	for _, e := range exps {
+		if named_arg, ok := e.(*ast.NamedArgument); ok {
+			evaluated := Eval(named_arg.Value, env)
+			if is_runtime_error(evaluated) {
+				return []object.Object{evaluated}, nil
 			}
-			// Collect where block results during test mode
-			if in_test_mode {
-				where_block_results = append(where_block_results, test_result)
+			if named == nil {
+				named = make(map[string]object.Object)
 			}
+			if _, exists := named[named_arg.Name.Value]; exists {
+				return []object.Object{object.NewError("duplicate named argument '%s'", named_arg.Name.Value)}, nil
+			}
+			named[named_arg.Name.Value] = evaluated
+			continue
 		}
 
-		return result
+		if named != nil {
+			return []object.Object{object.NewError("positional argument cannot follow named arguments")}, nil
+		}
+
+		evaluated := Eval(e, env)
+		// Propagate runtime errors immediately, but allow user-created errors as function arguments
+		if is_runtime_error(evaluated) {
+			return []object.Object{evaluated}, nil
+		}
+		args = append(args, evaluated)
+	}
+
+	return args, named
+}
+
+// apply_named_call applies a callable to positional and named arguments
+func apply_named_call(fn object.Object, args []object.Object, named map[string]object.Object, callerEnv *object.Environment) object.Object {
+	if len(named) == 0 {
+		return apply_function(fn, args, callerEnv)
+	}
+
+	switch function := fn.(type) {
+	case *object.Function:
+		return call_function(function, args, named)
+	case *object.Struct:
+		return instantiate_struct(function, args, named)
+	default:
+		return object.NewError("named arguments are not supported for %s", fn.Type())
+	}
+}
+
+// is_callable reports whether apply_function can call value
+func is_callable(value object.Object) bool {
+	switch value.(type) {
+	case *object.Function, *object.Builtin, *object.Struct:
+		return true
+	}
+	return false
+}
+
+func apply_function(fn object.Object, args []object.Object, callerEnv *object.Environment) object.Object {
+	switch function := fn.(type) {
+	case *object.Function:
+		return call_function(function, args, nil)
 	case *object.Builtin:
 		return function.Fn(args...)
+	case *object.Struct:
+		return instantiate_struct(function, args, nil)
 	default:
 		return object.NewError("not a function: %T", fn)
 	}
 }
 
-func extend_function_env(fn *object.Function, args []object.Object) *object.Environment {
+// call_function runs a user function with positional and named arguments
+func call_function(function *object.Function, args []object.Object, named map[string]object.Object) object.Object {
+	extended_env, err := extend_function_env(function, args, named, false)
+	if err != nil {
+		return err
+	}
+	if function.Generator {
+		return new_generator(function, extended_env)
+	}
+	frame := push_call(function)
+	evaluated := Eval(function.Body, extended_env)
+	pop_call(frame)
+	// Loops outside the function cannot be reached by its break and continue statements
+	result := stray_loop_exit(unwrap_return_value(evaluated))
+	if err := check_return_type(function, result); err != nil {
+		return err
+	}
+
+	// Execute where block assertions if present (but not if we're already in a where block test)
+	if function.WhereBlock != nil && !in_where_block_test {
+		test_result := eval_where_block(function.WhereBlock, extended_env, result, args)
+		if test_result.Failed > 0 {
+			// Print test failures during normal execution
+			if !in_test_mode {
+				fmt.Printf("Function test failures:\n%s\n", test_result.String())
+			}
+		}
+		// Collect where block results during test mode
+		if in_test_mode {
+			where_block_results = append(where_block_results, test_result)
+		}
+	}
+
+	return result
+}
+
+// extend_function_env binds arguments to parameters: positional arguments in order, then named
+// arguments, then defaults for whatever is left. A rest parameter collects the extra positional arguments.
+// Lenient binding, used for callbacks the interpreter calls itself, drops extra arguments and sets
+// missing ones to null instead of failing.
+func extend_function_env(fn *object.Function, args []object.Object, named map[string]object.Object, lenient bool) (*object.Environment, *object.Error) {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
-	for paramIdx, param := range fn.Parameters {
-		if paramIdx < len(args) {
-			env.Set(param.Name.Value, args[paramIdx])
+	params := fn.Parameters
+	var rest *ast.Parameter
+	if len(params) > 0 && params[len(params)-1].Rest {
+		rest = params[len(params)-1]
+		params = params[:len(params)-1]
+	}
+
+	// Callbacks the interpreter calls itself drop the arguments they do not declare
+	if lenient && rest == nil && len(args) > len(params) {
+		args = args[:len(params)]
+	}
+
+	if rest == nil && len(args) > len(params) {
+		return nil, object.NewError("wrong number of arguments for %s: got %d, want %s",
+			function_description(fn), len(args), arity(fn.Parameters))
+	}
+
+	for name := range named {
+		if !has_parameter(params, name) {
+			return nil, object.NewError("unknown parameter '%s' for %s", name, function_description(fn))
+		}
+	}
+
+	for i, param := range params {
+		value, given := named[param.Name.Value]
+		if i < len(args) {
+			if given {
+				return nil, object.NewError("parameter '%s' of %s given more than once", param.Name.Value, function_description(fn))
+			}
+			value, given = args[i], true
+		}
+
+		if !given {
+			if param.Default == nil && lenient {
+				// Lenient calls leave the parameters they were not given null
+				env.Set(param.Name.Value, object.NULL)
+				continue
+			}
+			if param.Default == nil {
+				if len(named) == 0 {
+					return nil, object.NewError("wrong number of arguments for %s: got %d, want %s",
+						function_description(fn), len(args), arity(fn.Parameters))
+				}
+				return nil, object.NewError("missing argument for parameter '%s' of %s", param.Name.Value, function_description(fn))
+			}
+
+			// Defaults are evaluated on every call and may refer to earlier parameters
+			value = Eval(param.Default, env)
+			if is_runtime_error(value) {
+				return nil, value.(*object.Error)
+			}
+		}
+
+		// Annotated parameters are checked against the argument
+		if err := check_parameter_type(fn, param, value); err != nil {
+			return nil, err
+		}
+		if param.Pattern != nil {
+			if err := destructure(param.Pattern, value, env, func(name string, value object.Object) { env.Set(name, value) }); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		env.Set(param.Name.Value, value)
+		env.SetType(param.Name.Value, param.Type)
+	}
+
+	if rest != nil {
+		collected := []object.Object{}
+		if len(args) > len(params) {
+			collected = append(collected, args[len(params):]...)
+		}
+		for _, value := range collected {
+			if err := check_parameter_type(fn, rest, value); err != nil {
+				return nil, err
+			}
 		}
+		env.Set(rest.Name.Value, &object.Array{Elements: collected})
 	}
 
-	return env
+	return env, nil
+}
+
+// has_parameter reports whether params declares a parameter called name
+func has_parameter(params []*ast.Parameter, name string) bool {
+	for _, param := range params {
+		if param.Name.Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// arity describes how many arguments a parameter list accepts, for error messages
+func arity(params []*ast.Parameter) string {
+	required, optional := 0, 0
+	for _, param := range params {
+		switch {
+		case param.Rest:
+			return fmt.Sprintf("at least %d", required)
+		case param.Default != nil:
+			optional++
+		default:
+			required++
+		}
+	}
+	if optional > 0 {
+		return fmt.Sprintf("%d to %d", required, required+optional)
+	}
+	return fmt.Sprint(required)
 }
 
 func unwrap_return_value(obj object.Object) object.Object {
@@ -1406,25 +2028,106 @@ func unwrap_return_value(obj object.Object) object.Object {
 }
 
 func eval_dot_expression(node *ast.DotExpression, env *object.Environment) object.Object {
-	left := Eval(node.Left, env)
+	left := eval_chain_left(node.Left, env)
 	// Only propagate runtime errors, allow user-created errors to have methods called on them
-	if is_runtime_error(left) {
+	if is_runtime_error(left) || left == short_circuit {
+		return left
+	}
+
+	if node.Optional {
+		if left == object.NULL {
+			return short_circuit
+		}
+		// Missing map keys read as null, so optional fields can be chained
+		if map_obj, ok := left.(*object.Map); ok {
+			if _, exists := lookup_pair(map_obj, node.Property.Value); !exists && !has_map_method(map_obj, node.Property.Value) {
+				return short_circuit
+			}
+		}
+	}
+
+	return get_property(left, node.Property.Value)
+}
+
+// short_circuit is what a nil-safe link hands back up its chain of member accesses, indexes and
+// calls when it meets null, so the links after it are skipped: nothing?.a.b is null, not an error.
+// end_chain turns it into null once the whole chain has been evaluated.
+var short_circuit object.Object = chain_end{}
+
+// chain_end is the type of short_circuit. It has a type of its own because pointers to empty
+// structs like object.Null may all be equal.
+type chain_end struct{}
+
+func (chain_end) Type() object.ObjectType { return object.NULL_OBJ }
+func (chain_end) Inspect() string         { return "null" }
+func (chain_end) String() string          { return "null" }
+
+// end_chain returns the value of a whole chain, null if a nil-safe link cut it short
+func end_chain(result object.Object) object.Object {
+	if result == short_circuit {
+		return object.NULL
+	}
+	return result
+}
+
+// eval_chain_left evaluates what a member access, index or call applies to. When that is an
+// earlier link of the same chain, a short_circuit from it is kept for the caller to pass on.
+func eval_chain_left(node ast.Expression, env *object.Environment) object.Object {
+	var result object.Object
+	switch node := node.(type) {
+	case *ast.DotExpression:
+		result = eval_dot_expression(node, env)
+	case *ast.IndexExpression:
+		result = eval_index_link(node, env)
+	case *ast.CallExpression:
+		result = eval_call_expression(node, env)
+	default:
+		return Eval(node, env)
+	}
+	// Links are evaluated here rather than through Eval, so errors are located the same way
+	if err, ok := result.(*object.Error); ok {
+		locate_error(err, node, env)
+	}
+	return result
+}
+
+// eval_index_link evaluates a[i] or the nil-safe a?[i] as a link of a chain
+func eval_index_link(node *ast.IndexExpression, env *object.Environment) object.Object {
+	left := eval_chain_left(node.Left, env)
+	if is_error(left) || left == short_circuit {
 		return left
 	}
+	if node.Optional && left == object.NULL {
+		return short_circuit
+	}
+	index := Eval(node.Index, env)
+	if is_error(index) {
+		return index
+	}
+	return eval_index_expression(left, index)
+}
 
+// get_property reads a module member, struct field, map key or zero-argument method of left
+func get_property(left object.Object, property_name string) object.Object {
 	// Handle module access
 	if module, ok := left.(*object.Module); ok {
-		if value, exists := module.Environment.Get(node.Property.Value); exists {
+		if value, exists := module.Environment.Get(property_name); exists {
 			return value
 		}
-		return object.NewError("undefined property '%s' in module '%s'", node.Property.Value, module.Name)
+		return object.NewError("undefined property '%s' in module '%s'", property_name, module.Name)
 	}
 	
+	// Handle struct field access - declared fields first, then methods
+	if instance, ok := left.(*object.StructInstance); ok {
+		if value, exists := instance.Fields[property_name]; exists {
+			return value
+		}
+	}
+
 	// Handle map property access - check data keys first, then custom methods
 	if map_obj, ok := left.(*object.Map); ok {
-		property_name := node.Property.Value
-		// First check if it's a data key in Pairs
-		if pair, exists := map_obj.Pairs[property_name]; exists {
+		// First check if it's a data key in Pairs, its own or a prototype's
+		if pair, exists := lookup_pair(map_obj, property_name); exists {
 			return pair.Value
 		}
 		// If not a data key, fall through to method call below
@@ -1432,21 +2135,25 @@ func eval_dot_expression(node *ast.DotExpression, env *object.Environment) objec
 
 	// Handle property-style method access (zero-argument methods without parentheses)
 	// Try to call the method with no arguments
-	method_name := node.Property.Value
-	result := call_object_method(left, method_name, []object.Object{})
+	result := call_object_method(left, property_name, []object.Object{})
 
 	// If it's an error saying the method doesn't exist, return that error
 	// Otherwise return the result (which could be a value or an error)
 	return result
 }
 
-func eval_method_call(dot_expr *ast.DotExpression, arguments []ast.Expression, env *object.Environment) object.Object {
+// eval_method_call calls a method of the receiver; with optional set, as in m.f?.(), a map key
+// that is missing or null evaluates to null instead of being called
+func eval_method_call(dot_expr *ast.DotExpression, arguments []ast.Expression, env *object.Environment, optional bool) object.Object {
 	// Evaluate the object (receiver)
-	receiver := Eval(dot_expr.Left, env)
+	receiver := eval_chain_left(dot_expr.Left, env)
 	// Only propagate runtime errors, allow user-created errors to have methods called on them
-	if is_runtime_error(receiver) {
+	if is_runtime_error(receiver) || receiver == short_circuit {
 		return receiver
 	}
+	if dot_expr.Optional && receiver == object.NULL {
+		return short_circuit
+	}
 
 	// Handle module method calls
 	if module, ok := receiver.(*object.Module); ok {
@@ -1454,42 +2161,58 @@ func eval_method_call(dot_expr *ast.DotExpression, arguments []ast.Expression, e
 		function_name := dot_expr.Property.Value
 		if function, exists := module.Environment.Get(function_name); exists {
 			// Evaluate arguments
-			args := eval_expressions(arguments, env)
+			args, named := eval_call_arguments(arguments, env)
 			if len(args) == 1 && is_error(args[0]) {
 				return args[0]
 			}
 
 			// Call the function
-			return apply_function(function, args, env)
+			return apply_named_call(function, args, named, env)
 		}
 		return object.NewError("undefined function '%s' in module '%s'", function_name, module.Name)
 	}
 
-	// Handle Map function calls - check Pairs for functions (like Math module functions)
+	// Handle Map function calls - check Pairs for functions (like Math module functions).
+	// A key holding data does not hide a method of the same name, so {"length": 5}.length() works.
 	if map_obj, ok := receiver.(*object.Map); ok {
 		method_name := dot_expr.Property.Value
-		if pair, exists := map_obj.Pairs[method_name]; exists {
+		if pair, exists := lookup_pair(map_obj, method_name); exists && (is_callable(pair.Value) || !has_map_method(map_obj, method_name)) {
+			if optional && pair.Value == object.NULL {
+				return short_circuit
+			}
 			// Evaluate arguments
-			args := eval_expressions(arguments, env)
+			args, named := eval_call_arguments(arguments, env)
 			if len(args) == 1 && is_error(args[0]) {
 				return args[0]
 			}
 
 			// Call the function
-			return apply_function(pair.Value, args, env)
+			return apply_named_call(pair.Value, args, named, env)
+		}
+		if optional && !has_map_method(map_obj, method_name) {
+			return short_circuit
 		}
 		// If not found in Pairs, fall through to call_object_method for custom methods
 	}
 
 	// Evaluate arguments
-	args := eval_expressions(arguments, env)
+	args, named := eval_call_arguments(arguments, env)
 	if len(args) == 1 && is_error(args[0]) {
 		return args[0]
 	}
-
 	// Get method name
 	method_name := dot_expr.Property.Value
 
+	if len(named) > 0 {
+		// Struct methods take named arguments like any user function
+		if instance, ok := receiver.(*object.StructInstance); ok {
+			if method, ok := instance.Struct.Methods[method_name].(*object.Function); ok {
+				return call_function(bind_self(method, instance), args, named)
+			}
+		}
+		return object.NewError("named arguments are not supported for method '%s'", method_name)
+	}
+
 	// Dispatch method based on receiver type
 	return call_object_method(receiver, method_name, args)
 }
@@ -1545,25 +2268,82 @@ func eval_module_statement(node *ast.ModuleStatement, env *object.Environment) o
 	// Create a new environment for the module
 	module_env := object.NewEnclosedEnvironment(env)
 
-	// Evaluate the module body in the module environment
-	result := eval_block_statement(node.Body, module_env)
-	if is_error(result) {
-		return result
+	// Evaluate the module body in the module environment
+	result := eval_block_statement(node.Body, module_env)
+	if is_error(result) {
+		return result
+	}
+
+	// Create the module object
+	module := &object.Module{
+		Name:        node.Name.Value,
+		Environment: module_env,
+	}
+
+	// Register the module in the current environment
+	env.Set(node.Name.Value, module)
+
+	return module
+}
+
+// eval_struct_statement handles struct declarations
+func eval_struct_statement(node *ast.StructStatement, env *object.Environment) object.Object {
+	struct_type := &object.Struct{
+		Name:    node.Name.Value,
+		Fields:  node.Fields,
+		Methods: make(map[string]object.Object),
+		Env:     env,
+	}
+
+	// Store the struct in the environment so it can be called as a constructor
+	env.Set(node.Name.Value, struct_type)
+
+	return struct_type
+}
+
+// instantiate_struct builds a struct instance from positional and named arguments
+func instantiate_struct(struct_type *object.Struct, args []object.Object, named map[string]object.Object) object.Object {
+	if len(args) > len(struct_type.Fields) {
+		return object.NewError("too many arguments for %s: got %d, want %d",
+			struct_type.Name, len(args), len(struct_type.Fields))
+	}
+
+	instance := &object.StructInstance{
+		Struct: struct_type,
+		Fields: make(map[string]object.Object),
 	}
 
-	// Create the module object
-	module := &object.Module{
-		Name:        node.Name.Value,
-		Environment: module_env,
+	// Positional arguments fill fields in declaration order
+	for i, arg := range args {
+		instance.Fields[struct_type.Fields[i].Name.Value] = arg
 	}
 
-	// Register the module in the current environment
-	env.Set(node.Name.Value, module)
+	// Named arguments fill the remaining fields by name
+	for name, value := range named {
+		if !struct_type.HasField(name) {
+			return object.NewError("unknown field '%s' for %s", name, struct_type.Name)
+		}
+		if _, exists := instance.Fields[name]; exists {
+			return object.NewError("field '%s' of %s given more than once", name, struct_type.Name)
+		}
+		instance.Fields[name] = value
+	}
 
-	return module
+	// Every declared field must be provided with a value of its declared type
+	for _, field := range struct_type.Fields {
+		value, ok := instance.Fields[field.Name.Value]
+		if !ok {
+			return object.NewError("missing field '%s' for %s", field.Name.Value, struct_type.Name)
+		}
+		if err := check_field_type(struct_type, field.Name.Value, value); err != nil {
+			return err
+		}
+	}
+
+	return instance
 }
 
-// eval_type_statement handles type alias declarations
+// eval_type_statement handles type alias and sum type declarations
 func eval_type_statement(node *ast.TypeStatement, env *object.Environment) object.Object {
 	// Create a type alias object
 	type_alias := &object.TypeAlias{
@@ -1571,6 +2351,27 @@ func eval_type_statement(node *ast.TypeStatement, env *object.Environment) objec
 		TypeAnnotation: node.Type,
 	}
 
+	// Each variant of a sum type is a struct; variants without fields are single shared values
+	if node.Variants != nil {
+		type_alias.Variants = []*object.Struct{}
+		for _, variant := range node.Variants {
+			variant_type := &object.Struct{
+				Name:    variant.Name.Value,
+				Fields:  variant.Fields,
+				Methods: make(map[string]object.Object),
+				Env:     env,
+				SumType: type_alias,
+			}
+			type_alias.Variants = append(type_alias.Variants, variant_type)
+
+			if variant_type.IsUnitVariant() {
+				env.Set(variant.Name.Value, &object.StructInstance{Struct: variant_type, Fields: make(map[string]object.Object)})
+			} else {
+				env.Set(variant.Name.Value, variant_type)
+			}
+		}
+	}
+
 	// Store the type alias in the environment
 	env.Set(node.Name.Value, type_alias)
 
@@ -1605,6 +2406,7 @@ func eval_using_statement(node *ast.UsingStatement, env *object.Environment) obj
 
 	// Create a new environment for the loaded module
 	module_env := object.NewEnclosedEnvironment(env)
+	module_env.SourceFile = resolved_path
 
 	// Evaluate the module file in its own environment
 	result := Eval(program, module_env)
@@ -1766,8 +2568,29 @@ func eval_isA_assertion(left, right object.Object) (bool, string) {
 	case *object.String:
 		expectedType = strings.ToLower(r.Value)
 	case *object.TypeAlias:
+		// Sum types accept any of their variants
+		if r.IsSumType() {
+			if instance, ok := left.(*object.StructInstance); ok && instance.Struct.SumType == r {
+				return true, ""
+			}
+			return false, fmt.Sprintf("Expected type %s, got %s", r.Name, describe_type(left))
+		}
 		// For type aliases, check the underlying type
 		expectedType = strings.ToLower(r.TypeAnnotation.Name)
+	case *object.Struct:
+		expectedType = strings.ToLower(r.Name)
+	case *object.Map:
+		// A map is a prototype: values are of its kind when they are it or inherit from it
+		if m, ok := left.(*object.Map); ok && m.Inherits(r) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("Expected a value inheriting from the given map, got %s", describe_type(left))
+	case *object.StructInstance:
+		// Variants without fields are values, so they name their own type
+		if !r.Struct.IsUnitVariant() {
+			return false, "isA operator requires a string type name or type alias"
+		}
+		expectedType = strings.ToLower(r.Struct.Name)
 	default:
 		return false, "isA operator requires a string type name or type alias"
 	}
@@ -1777,6 +2600,11 @@ func eval_isA_assertion(left, right object.Object) (bool, string) {
 	// Convert our internal type names to user-friendly names
 	userFriendlyType := get_user_friendly_type_name(actualType)
 
+	// Struct instances are named after their struct
+	if instance, ok := left.(*object.StructInstance); ok {
+		userFriendlyType = strings.ToLower(instance.Struct.Name)
+	}
+
 	if userFriendlyType != expectedType {
 		return false, fmt.Sprintf("Expected type %s, got %s", expectedType, userFriendlyType)
 	}
@@ -1804,6 +2632,12 @@ func eval_contains_assertion(left, right object.Object) (bool, string) {
 		}
 		return false, fmt.Sprintf("String %s does not contain %s", left.Inspect(), right.Inspect())
 
+	case *object.Set:
+		if container.Contains(right) {
+			return true, ""
+		}
+		return false, fmt.Sprintf("Set %s does not contain %s", left.Inspect(), right.Inspect())
+
 	default:
 		return false, fmt.Sprintf("Cannot use contains on type %s", left.Type())
 	}
@@ -1825,6 +2659,16 @@ func get_user_friendly_type_name(internal_type string) string {
 		return "function"
 	case "NULL":
 		return "null"
+	case "STRUCT":
+		return "struct"
+	case "ITERATOR":
+		return "iterator"
+	case "SET":
+		return "set"
+	case "TUPLE":
+		return "tuple"
+	case "SUPER":
+		return "super"
 	default:
 		return internal_type
 	}
@@ -2201,10 +3045,10 @@ func download_git_module(path, target_path string) (string, error) {
 
 // init_math_module initializes the Math module with all math functions and constants
 func init_math_module() *object.Map {
-	math_module := &object.Map{Pairs: make(map[string]object.MapPair)}
+	math_module := object.NewMap()
 
 	// Math.pow(base, exponent) - power function
-	math_module.Pairs["pow"] = object.MapPair{
+	math_module.Set("pow", object.MapPair{
 		Key: &object.String{Value: "pow"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2222,10 +3066,10 @@ func init_math_module() *object.Map {
 				return &object.Number{Value: math.Pow(base.Value, exp.Value)}
 			},
 		},
-	}
+	})
 
 	// Math.max(...values) - variadic maximum
-	math_module.Pairs["max"] = object.MapPair{
+	math_module.Set("max", object.MapPair{
 		Key: &object.String{Value: "max"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2245,10 +3089,10 @@ func init_math_module() *object.Map {
 				return &object.Number{Value: max_val}
 			},
 		},
-	}
+	})
 
 	// Math.min(...values) - variadic minimum
-	math_module.Pairs["min"] = object.MapPair{
+	math_module.Set("min", object.MapPair{
 		Key: &object.String{Value: "min"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2268,40 +3112,40 @@ func init_math_module() *object.Map {
 				return &object.Number{Value: min_val}
 			},
 		},
-	}
+	})
 
 	// Trigonometry functions
-	math_module.Pairs["sin"] = object.MapPair{
+	math_module.Set("sin", object.MapPair{
 		Key:   &object.String{Value: "sin"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Sin, "Math.sin")},
-	}
+	})
 
-	math_module.Pairs["cos"] = object.MapPair{
+	math_module.Set("cos", object.MapPair{
 		Key:   &object.String{Value: "cos"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Cos, "Math.cos")},
-	}
+	})
 
-	math_module.Pairs["tan"] = object.MapPair{
+	math_module.Set("tan", object.MapPair{
 		Key:   &object.String{Value: "tan"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Tan, "Math.tan")},
-	}
+	})
 
-	math_module.Pairs["asin"] = object.MapPair{
+	math_module.Set("asin", object.MapPair{
 		Key:   &object.String{Value: "asin"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Asin, "Math.asin")},
-	}
+	})
 
-	math_module.Pairs["acos"] = object.MapPair{
+	math_module.Set("acos", object.MapPair{
 		Key:   &object.String{Value: "acos"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Acos, "Math.acos")},
-	}
+	})
 
-	math_module.Pairs["atan"] = object.MapPair{
+	math_module.Set("atan", object.MapPair{
 		Key:   &object.String{Value: "atan"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Atan, "Math.atan")},
-	}
+	})
 
-	math_module.Pairs["atan2"] = object.MapPair{
+	math_module.Set("atan2", object.MapPair{
 		Key: &object.String{Value: "atan2"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2319,31 +3163,31 @@ func init_math_module() *object.Map {
 				return &object.Number{Value: math.Atan2(y.Value, x.Value)}
 			},
 		},
-	}
+	})
 
 	// Logarithm functions
-	math_module.Pairs["log"] = object.MapPair{
+	math_module.Set("log", object.MapPair{
 		Key:   &object.String{Value: "log"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Log, "Math.log")},
-	}
+	})
 
-	math_module.Pairs["log10"] = object.MapPair{
+	math_module.Set("log10", object.MapPair{
 		Key:   &object.String{Value: "log10"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Log10, "Math.log10")},
-	}
+	})
 
-	math_module.Pairs["log2"] = object.MapPair{
+	math_module.Set("log2", object.MapPair{
 		Key:   &object.String{Value: "log2"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Log2, "Math.log2")},
-	}
+	})
 
-	math_module.Pairs["exp"] = object.MapPair{
+	math_module.Set("exp", object.MapPair{
 		Key:   &object.String{Value: "exp"},
 		Value: &object.Builtin{Fn: math_unary_builtin(math.Exp, "Math.exp")},
-	}
+	})
 
 	// Random functions
-	math_module.Pairs["random"] = object.MapPair{
+	math_module.Set("random", object.MapPair{
 		Key: &object.String{Value: "random"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2353,9 +3197,9 @@ func init_math_module() *object.Map {
 				return &object.Number{Value: rand.Float64()}
 			},
 		},
-	}
+	})
 
-	math_module.Pairs["random_int"] = object.MapPair{
+	math_module.Set("random_int", object.MapPair{
 		Key: &object.String{Value: "random_int"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2380,23 +3224,23 @@ func init_math_module() *object.Map {
 				return &object.Number{Value: float64(random_val)}
 			},
 		},
-	}
+	})
 
 	// Math constants
-	math_module.Pairs["PI"] = object.MapPair{
+	math_module.Set("PI", object.MapPair{
 		Key:   &object.String{Value: "PI"},
 		Value: &object.Number{Value: math.Pi},
-	}
+	})
 
-	math_module.Pairs["E"] = object.MapPair{
+	math_module.Set("E", object.MapPair{
 		Key:   &object.String{Value: "E"},
 		Value: &object.Number{Value: math.E},
-	}
+	})
 
-	math_module.Pairs["TAU"] = object.MapPair{
+	math_module.Set("TAU", object.MapPair{
 		Key:   &object.String{Value: "TAU"},
 		Value: &object.Number{Value: 2 * math.Pi},
-	}
+	})
 
 	return math_module
 }
@@ -2415,12 +3259,19 @@ func math_unary_builtin(fn func(float64) float64, name string) func(...object.Ob
 	}
 }
 
+// io_error reports a failed file system operation on path
+func io_error(err error, path string) *object.Error {
+	return new_user_error("io", err.Error(), map[string]object.Object{
+		"path": &object.String{Value: path},
+	})
+}
+
 // init_file_module initializes the File module with all file and directory operations
 func init_file_module() *object.Map {
-	file_module := &object.Map{Pairs: make(map[string]object.MapPair)}
+	file_module := object.NewMap()
 
 	// File.read(path) - read file contents, returns (content, error)
-	file_module.Pairs["read"] = object.MapPair{
+	file_module.Set("read", object.MapPair{
 		Key: &object.String{Value: "read"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2435,7 +3286,7 @@ func init_file_module() *object.Map {
 				content, err := os.ReadFile(path.Value)
 				if err != nil {
 					// Return (nil, error)
-					user_error := &object.Error{Message: err.Error(), IsUserCreated: true}
+					user_error := io_error(err, path.Value)
 					return &object.MultiValue{Values: []object.Object{object.NULL, user_error}}
 				}
 
@@ -2446,10 +3297,10 @@ func init_file_module() *object.Map {
 				}}
 			},
 		},
-	}
+	})
 
 	// File.read_lines(path) - read file as array of lines, returns (lines, error)
-	file_module.Pairs["read_lines"] = object.MapPair{
+	file_module.Set("read_lines", object.MapPair{
 		Key: &object.String{Value: "read_lines"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2464,7 +3315,7 @@ func init_file_module() *object.Map {
 				content, err := os.ReadFile(path.Value)
 				if err != nil {
 					// Return (nil, error)
-					user_error := &object.Error{Message: err.Error(), IsUserCreated: true}
+					user_error := io_error(err, path.Value)
 					return &object.MultiValue{Values: []object.Object{object.NULL, user_error}}
 				}
 
@@ -2482,10 +3333,39 @@ func init_file_module() *object.Map {
 				}}
 			},
 		},
-	}
+	})
+
+	// File.lines(path) - iterate over the lines of a file without loading it all, returns (lines, error)
+	file_module.Set("lines", object.MapPair{
+		Key: &object.String{Value: "lines"},
+		Value: &object.Builtin{
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return object.NewError("wrong number of arguments for File.lines. got=%d, want=1", len(args))
+				}
+				path, ok := args[0].(*object.String)
+				if !ok {
+					return object.NewError("argument to File.lines must be STRING, got %s", args[0].Type())
+				}
+
+				file, err := os.Open(path.Value)
+				if err != nil {
+					// Return (nil, error)
+					user_error := io_error(err, path.Value)
+					return &object.MultiValue{Values: []object.Object{object.NULL, user_error}}
+				}
+
+				// Return (lines_iterator, nil)
+				return &object.MultiValue{Values: []object.Object{
+					line_iterator(file, path.Value),
+					object.NULL,
+				}}
+			},
+		},
+	})
 
 	// File.write(path, content) - write content to file, returns error or nil
-	file_module.Pairs["write"] = object.MapPair{
+	file_module.Set("write", object.MapPair{
 		Key: &object.String{Value: "write"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2503,16 +3383,16 @@ func init_file_module() *object.Map {
 
 				err := os.WriteFile(path.Value, []byte(content.Value), 0644)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// File.append(path, content) - append content to file, returns error or nil
-	file_module.Pairs["append"] = object.MapPair{
+	file_module.Set("append", object.MapPair{
 		Key: &object.String{Value: "append"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2530,21 +3410,21 @@ func init_file_module() *object.Map {
 
 				f, err := os.OpenFile(path.Value, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 				defer f.Close()
 
 				if _, err := f.WriteString(content.Value); err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// File.delete(path) - delete file, returns error or nil
-	file_module.Pairs["delete"] = object.MapPair{
+	file_module.Set("delete", object.MapPair{
 		Key: &object.String{Value: "delete"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2558,16 +3438,16 @@ func init_file_module() *object.Map {
 
 				err := os.Remove(path.Value)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// File.exists(path) - check if file or directory exists
-	file_module.Pairs["exists"] = object.MapPair{
+	file_module.Set("exists", object.MapPair{
 		Key: &object.String{Value: "exists"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2590,10 +3470,10 @@ func init_file_module() *object.Map {
 				return object.FALSE
 			},
 		},
-	}
+	})
 
 	// File.size(path) - get file size in bytes, returns (size, error)
-	file_module.Pairs["size"] = object.MapPair{
+	file_module.Set("size", object.MapPair{
 		Key: &object.String{Value: "size"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2607,7 +3487,7 @@ func init_file_module() *object.Map {
 
 				info, err := os.Stat(path.Value)
 				if err != nil {
-					user_error := &object.Error{Message: err.Error(), IsUserCreated: true}
+					user_error := io_error(err, path.Value)
 					return &object.MultiValue{Values: []object.Object{object.NULL, user_error}}
 				}
 
@@ -2617,10 +3497,10 @@ func init_file_module() *object.Map {
 				}}
 			},
 		},
-	}
+	})
 
 	// File.is_file(path) - check if path is a file
-	file_module.Pairs["is_file"] = object.MapPair{
+	file_module.Set("is_file", object.MapPair{
 		Key: &object.String{Value: "is_file"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2643,10 +3523,10 @@ func init_file_module() *object.Map {
 				return object.TRUE
 			},
 		},
-	}
+	})
 
 	// File.is_dir(path) - check if path is a directory
-	file_module.Pairs["is_dir"] = object.MapPair{
+	file_module.Set("is_dir", object.MapPair{
 		Key: &object.String{Value: "is_dir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2669,10 +3549,10 @@ func init_file_module() *object.Map {
 				return object.FALSE
 			},
 		},
-	}
+	})
 
 	// File.list_dir(path) - list directory contents, returns (files_array, error)
-	file_module.Pairs["list_dir"] = object.MapPair{
+	file_module.Set("list_dir", object.MapPair{
 		Key: &object.String{Value: "list_dir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2686,7 +3566,7 @@ func init_file_module() *object.Map {
 
 				entries, err := os.ReadDir(path.Value)
 				if err != nil {
-					user_error := &object.Error{Message: err.Error(), IsUserCreated: true}
+					user_error := io_error(err, path.Value)
 					return &object.MultiValue{Values: []object.Object{object.NULL, user_error}}
 				}
 
@@ -2701,10 +3581,10 @@ func init_file_module() *object.Map {
 				}}
 			},
 		},
-	}
+	})
 
 	// File.mkdir(path) - create directory, returns error or nil
-	file_module.Pairs["mkdir"] = object.MapPair{
+	file_module.Set("mkdir", object.MapPair{
 		Key: &object.String{Value: "mkdir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2718,16 +3598,16 @@ func init_file_module() *object.Map {
 
 				err := os.Mkdir(path.Value, 0755)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// File.mkdir_all(path) - create directory and all parent directories, returns error or nil
-	file_module.Pairs["mkdir_all"] = object.MapPair{
+	file_module.Set("mkdir_all", object.MapPair{
 		Key: &object.String{Value: "mkdir_all"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2741,16 +3621,16 @@ func init_file_module() *object.Map {
 
 				err := os.MkdirAll(path.Value, 0755)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// File.remove_dir(path) - remove directory, returns error or nil
-	file_module.Pairs["remove_dir"] = object.MapPair{
+	file_module.Set("remove_dir", object.MapPair{
 		Key: &object.String{Value: "remove_dir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2764,16 +3644,16 @@ func init_file_module() *object.Map {
 
 				err := os.RemoveAll(path.Value)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// File.join(...paths) - join path segments
-	file_module.Pairs["join"] = object.MapPair{
+	file_module.Set("join", object.MapPair{
 		Key: &object.String{Value: "join"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2794,10 +3674,10 @@ func init_file_module() *object.Map {
 				return &object.String{Value: result}
 			},
 		},
-	}
+	})
 
 	// File.basename(path) - get base name of path
-	file_module.Pairs["basename"] = object.MapPair{
+	file_module.Set("basename", object.MapPair{
 		Key: &object.String{Value: "basename"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2813,10 +3693,10 @@ func init_file_module() *object.Map {
 				return &object.String{Value: result}
 			},
 		},
-	}
+	})
 
 	// File.dirname(path) - get directory name of path
-	file_module.Pairs["dirname"] = object.MapPair{
+	file_module.Set("dirname", object.MapPair{
 		Key: &object.String{Value: "dirname"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2832,10 +3712,10 @@ func init_file_module() *object.Map {
 				return &object.String{Value: result}
 			},
 		},
-	}
+	})
 
 	// File.extname(path) - get file extension
-	file_module.Pairs["extname"] = object.MapPair{
+	file_module.Set("extname", object.MapPair{
 		Key: &object.String{Value: "extname"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2851,10 +3731,10 @@ func init_file_module() *object.Map {
 				return &object.String{Value: result}
 			},
 		},
-	}
+	})
 
 	// File.absolute_path(path) - get absolute path
-	file_module.Pairs["absolute_path"] = object.MapPair{
+	file_module.Set("absolute_path", object.MapPair{
 		Key: &object.String{Value: "absolute_path"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2874,10 +3754,10 @@ func init_file_module() *object.Map {
 				return &object.String{Value: result}
 			},
 		},
-	}
+	})
 
 	// File.cwd() - get current working directory
-	file_module.Pairs["cwd"] = object.MapPair{
+	file_module.Set("cwd", object.MapPair{
 		Key: &object.String{Value: "cwd"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2893,10 +3773,10 @@ func init_file_module() *object.Map {
 				return &object.String{Value: result}
 			},
 		},
-	}
+	})
 
 	// File.chdir(path) - change current working directory
-	file_module.Pairs["chdir"] = object.MapPair{
+	file_module.Set("chdir", object.MapPair{
 		Key: &object.String{Value: "chdir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2910,23 +3790,23 @@ func init_file_module() *object.Map {
 
 				err := os.Chdir(path.Value)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return io_error(err, path.Value)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	return file_module
 }
 
 // init_json_module creates and returns the JSON module with parse and stringify functions
 func init_json_module() *object.Map {
-	json_module := &object.Map{Pairs: make(map[string]object.MapPair)}
+	json_module := object.NewMap()
 
 	// JSON.parse(json_string) - parse JSON string to object
-	json_module.Pairs["parse"] = object.MapPair{
+	json_module.Set("parse", object.MapPair{
 		Key: &object.String{Value: "parse"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2939,10 +3819,9 @@ func init_json_module() *object.Map {
 				}
 
 				// Parse JSON into interface{}
-				var data interface{}
-				err := json.Unmarshal([]byte(json_str.Value), &data)
+				data, err := decode_json(json_str.Value)
 				if err != nil {
-					user_error := &object.Error{Message: fmt.Sprintf("invalid JSON: %s", err.Error()), IsUserCreated: true}
+					user_error := new_user_error("parse", fmt.Sprintf("invalid JSON: %s", err.Error()), nil)
 					return &object.MultiValue{Values: []object.Object{object.NULL, user_error}}
 				}
 
@@ -2951,10 +3830,10 @@ func init_json_module() *object.Map {
 				return &object.MultiValue{Values: []object.Object{result, object.NULL}}
 			},
 		},
-	}
+	})
 
 	// JSON.stringify(obj, indent?) - convert object to JSON string
-	json_module.Pairs["stringify"] = object.MapPair{
+	json_module.Set("stringify", object.MapPair{
 		Key: &object.String{Value: "stringify"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -2988,12 +3867,101 @@ func init_json_module() *object.Map {
 				return &object.String{Value: string(json_bytes)}
 			},
 		},
-	}
+	})
 
 	return json_module
 }
 
-// convert_json_to_object converts a Go interface{} (from json.Unmarshal) to a Seda object
+// json_object is a JSON object that keeps its keys in order, both as read by decode_json and as written by
+// JSON.stringify, so maps round-trip in the order they were built
+type json_object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (o *json_object) MarshalJSON() ([]byte, error) {
+	var out bytes.Buffer
+	out.WriteByte('{')
+	for i, key := range o.keys {
+		if i > 0 {
+			out.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(o.values[key])
+		if err != nil {
+			return nil, err
+		}
+		out.Write(name)
+		out.WriteByte(':')
+		out.Write(value)
+	}
+	out.WriteByte('}')
+	return out.Bytes(), nil
+}
+
+// decode_json parses JSON text, keeping numbers as written so whole numbers stay exact
+func decode_json(text string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	data, err := decode_json_value(decoder)
+	if err != nil || strings.TrimSpace(text[decoder.InputOffset():]) != "" {
+		// Unmarshal describes the problem the same way for truncated input and trailing data
+		var ignored interface{}
+		return nil, json.Unmarshal([]byte(text), &ignored)
+	}
+	return data, nil
+}
+
+// decode_json_value reads the next value from decoder, keeping the key order of objects
+func decode_json_value(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		obj := &json_object{values: make(map[string]interface{})}
+		for decoder.More() {
+			key_token, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			key, ok := key_token.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid object key %v", key_token)
+			}
+			value, err := decode_json_value(decoder)
+			if err != nil {
+				return nil, err
+			}
+			// A repeated key keeps its first place and its last value, like Unmarshal
+			if _, seen := obj.values[key]; !seen {
+				obj.keys = append(obj.keys, key)
+			}
+			obj.values[key] = value
+		}
+		_, err = decoder.Token()
+		return obj, err
+	case json.Delim('['):
+		array := []interface{}{}
+		for decoder.More() {
+			element, err := decode_json_value(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, element)
+		}
+		_, err = decoder.Token()
+		return array, err
+	}
+	return token, nil
+}
+
+// convert_json_to_object converts a Go interface{} (from decode_json) to a Seda object
 func convert_json_to_object(data interface{}) object.Object {
 	switch v := data.(type) {
 	case nil:
@@ -3003,6 +3971,11 @@ func convert_json_to_object(data interface{}) object.Object {
 			return object.TRUE
 		}
 		return object.FALSE
+	case json.Number:
+		if number, ok := parse_number(string(v)); ok {
+			return number
+		}
+		return object.NewError("invalid JSON number: %s", v)
 	case float64:
 		return &object.Number{Value: v}
 	case string:
@@ -3014,16 +3987,16 @@ func convert_json_to_object(data interface{}) object.Object {
 			elements[i] = convert_json_to_object(elem)
 		}
 		return &object.Array{Elements: elements}
-	case map[string]interface{}:
-		// Convert to Seda Map
-		pairs := make(map[string]object.MapPair)
-		for key, value := range v {
-			pairs[key] = object.MapPair{
+	case *json_object:
+		// Convert to Seda Map, in the order the keys were written
+		map_obj := object.NewMap()
+		for _, key := range v.keys {
+			map_obj.Set(key, object.MapPair{
 				Key:   &object.String{Value: key},
-				Value: convert_json_to_object(value),
-			}
+				Value: convert_json_to_object(v.values[key]),
+			})
 		}
-		return &object.Map{Pairs: pairs}
+		return map_obj
 	default:
 		return object.NewError("unsupported JSON type: %T", v)
 	}
@@ -3037,6 +4010,10 @@ func convert_object_to_json(obj object.Object) interface{} {
 	case *object.Boolean:
 		return v.Value
 	case *object.Number:
+		// Exact numbers are written digit for digit
+		if v.Kind != object.FLOAT {
+			return json.Number(v.Inspect())
+		}
 		return v.Value
 	case *object.String:
 		return v.Value
@@ -3047,11 +4024,35 @@ func convert_object_to_json(obj object.Object) interface{} {
 			result[i] = convert_object_to_json(elem)
 		}
 		return result
+	case *object.Set:
+		// Sets and tuples have no JSON form of their own and are written as arrays
+		return convert_object_to_json(&object.Array{Elements: v.Values()})
+	case *object.Tuple:
+		return convert_object_to_json(&object.Array{Elements: v.Elements})
 	case *object.Map:
-		// Convert Seda Map to Go map
-		result := make(map[string]interface{})
-		for key, pair := range v.Pairs {
-			result[key] = convert_object_to_json(pair.Value)
+		// Convert Seda Map to a JSON object with the keys in map order. Keys that are not
+		// strings are written as they print; when two print the same the later value wins.
+		result := &json_object{values: make(map[string]interface{})}
+		for _, pair := range v.Entries() {
+			name := pair.Key.Inspect()
+			if str, ok := pair.Key.(*object.String); ok {
+				name = str.Value
+			}
+			if _, seen := result.values[name]; !seen {
+				result.keys = append(result.keys, name)
+			}
+			result.values[name] = convert_object_to_json(pair.Value)
+		}
+		return result
+	case *object.StructInstance:
+		// Struct instances serialize as objects of their fields, in declaration order
+		result := &json_object{values: make(map[string]interface{})}
+		for _, field := range v.Struct.Fields {
+			name := field.Name.Value
+			if value, ok := v.Fields[name]; ok {
+				result.keys = append(result.keys, name)
+				result.values[name] = convert_object_to_json(value)
+			}
 		}
 		return result
 	default:
@@ -3068,12 +4069,21 @@ func SetCommandLineArgs(args []string) {
 	command_line_args = args
 }
 
+// exit_code returns the exit status of a failed command, or -1 if it never ran
+func exit_code(err error) int {
+	var exit_err *exec.ExitError
+	if errors.As(err, &exit_err) {
+		return exit_err.ExitCode()
+	}
+	return -1
+}
+
 // init_os_module creates and returns the OS module with environment, process, and system functions
 func init_os_module() *object.Map {
-	os_module := &object.Map{Pairs: make(map[string]object.MapPair)}
+	os_module := object.NewMap()
 
 	// OS.getenv(name) - get environment variable
-	os_module.Pairs["getenv"] = object.MapPair{
+	os_module.Set("getenv", object.MapPair{
 		Key: &object.String{Value: "getenv"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3089,10 +4099,10 @@ func init_os_module() *object.Map {
 				return &object.String{Value: value}
 			},
 		},
-	}
+	})
 
 	// OS.setenv(name, value) - set environment variable
-	os_module.Pairs["setenv"] = object.MapPair{
+	os_module.Set("setenv", object.MapPair{
 		Key: &object.String{Value: "setenv"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3110,16 +4120,16 @@ func init_os_module() *object.Map {
 
 				err := os.Setenv(name.Value, value.Value)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return new_user_error("os", err.Error(), nil)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// OS.env() - get all environment variables as map
-	os_module.Pairs["env"] = object.MapPair{
+	os_module.Set("env", object.MapPair{
 		Key: &object.String{Value: "env"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3127,26 +4137,26 @@ func init_os_module() *object.Map {
 					return object.NewError("wrong number of arguments for OS.env. got=%d, want=0", len(args))
 				}
 
-				env_map := &object.Map{Pairs: make(map[string]object.MapPair)}
+				env_map := object.NewMap()
 				for _, env_var := range os.Environ() {
 					parts := strings.SplitN(env_var, "=", 2)
 					if len(parts) == 2 {
 						key := parts[0]
 						value := parts[1]
-						env_map.Pairs[key] = object.MapPair{
+						env_map.Set(key, object.MapPair{
 							Key:   &object.String{Value: key},
 							Value: &object.String{Value: value},
-						}
+						})
 					}
 				}
 
 				return env_map
 			},
 		},
-	}
+	})
 
 	// OS.args() - get command line arguments
-	os_module.Pairs["args"] = object.MapPair{
+	os_module.Set("args", object.MapPair{
 		Key: &object.String{Value: "args"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3162,10 +4172,10 @@ func init_os_module() *object.Map {
 				return &object.Array{Elements: elements}
 			},
 		},
-	}
+	})
 
 	// OS.exit(code) - exit with status code
-	os_module.Pairs["exit"] = object.MapPair{
+	os_module.Set("exit", object.MapPair{
 		Key: &object.String{Value: "exit"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3181,10 +4191,10 @@ func init_os_module() *object.Map {
 				return object.NULL // Never reached
 			},
 		},
-	}
+	})
 
 	// OS.pid() - get process ID
-	os_module.Pairs["pid"] = object.MapPair{
+	os_module.Set("pid", object.MapPair{
 		Key: &object.String{Value: "pid"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3195,10 +4205,10 @@ func init_os_module() *object.Map {
 				return &object.Number{Value: float64(os.Getpid())}
 			},
 		},
-	}
+	})
 
 	// OS.platform() - get operating system
-	os_module.Pairs["platform"] = object.MapPair{
+	os_module.Set("platform", object.MapPair{
 		Key: &object.String{Value: "platform"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3209,10 +4219,10 @@ func init_os_module() *object.Map {
 				return &object.String{Value: runtime.GOOS}
 			},
 		},
-	}
+	})
 
 	// OS.arch() - get architecture
-	os_module.Pairs["arch"] = object.MapPair{
+	os_module.Set("arch", object.MapPair{
 		Key: &object.String{Value: "arch"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3223,10 +4233,10 @@ func init_os_module() *object.Map {
 				return &object.String{Value: runtime.GOARCH}
 			},
 		},
-	}
+	})
 
 	// OS.hostname() - get machine hostname
-	os_module.Pairs["hostname"] = object.MapPair{
+	os_module.Set("hostname", object.MapPair{
 		Key: &object.String{Value: "hostname"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3236,16 +4246,16 @@ func init_os_module() *object.Map {
 
 				hostname, err := os.Hostname()
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return new_user_error("os", err.Error(), nil)
 				}
 
 				return &object.String{Value: hostname}
 			},
 		},
-	}
+	})
 
 	// OS.home_dir() - get user home directory
-	os_module.Pairs["home_dir"] = object.MapPair{
+	os_module.Set("home_dir", object.MapPair{
 		Key: &object.String{Value: "home_dir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3255,16 +4265,16 @@ func init_os_module() *object.Map {
 
 				home, err := os.UserHomeDir()
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return new_user_error("os", err.Error(), nil)
 				}
 
 				return &object.String{Value: home}
 			},
 		},
-	}
+	})
 
 	// OS.temp_dir() - get temporary directory
-	os_module.Pairs["temp_dir"] = object.MapPair{
+	os_module.Set("temp_dir", object.MapPair{
 		Key: &object.String{Value: "temp_dir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3275,10 +4285,10 @@ func init_os_module() *object.Map {
 				return &object.String{Value: os.TempDir()}
 			},
 		},
-	}
+	})
 
 	// OS.cwd() - get current working directory
-	os_module.Pairs["cwd"] = object.MapPair{
+	os_module.Set("cwd", object.MapPair{
 		Key: &object.String{Value: "cwd"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3288,16 +4298,16 @@ func init_os_module() *object.Map {
 
 				cwd, err := os.Getwd()
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return new_user_error("os", err.Error(), nil)
 				}
 
 				return &object.String{Value: cwd}
 			},
 		},
-	}
+	})
 
 	// OS.chdir(path) - change current working directory
-	os_module.Pairs["chdir"] = object.MapPair{
+	os_module.Set("chdir", object.MapPair{
 		Key: &object.String{Value: "chdir"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3311,16 +4321,16 @@ func init_os_module() *object.Map {
 
 				err := os.Chdir(path.Value)
 				if err != nil {
-					return &object.Error{Message: err.Error(), IsUserCreated: true}
+					return new_user_error("os", err.Error(), nil)
 				}
 
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// OS.exec(command, ...args) - execute command and return output
-	os_module.Pairs["exec"] = object.MapPair{
+	os_module.Set("exec", object.MapPair{
 		Key: &object.String{Value: "exec"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3347,7 +4357,11 @@ func init_os_module() *object.Map {
 				output, err := cmd.CombinedOutput()
 
 				if err != nil {
-					user_error := &object.Error{Message: fmt.Sprintf("command failed: %s", err.Error()), IsUserCreated: true}
+					user_error := new_user_error("exec", fmt.Sprintf("command failed: %s", err.Error()), map[string]object.Object{
+						"command":   command,
+						"exit_code": &object.Number{Value: float64(exit_code(err))},
+						"output":    &object.String{Value: string(output)},
+					})
 					return &object.MultiValue{Values: []object.Object{object.NULL, user_error}}
 				}
 
@@ -3357,10 +4371,10 @@ func init_os_module() *object.Map {
 				}}
 			},
 		},
-	}
+	})
 
 	// OS.spawn(command, ...args) - spawn background process and return PID
-	os_module.Pairs["spawn"] = object.MapPair{
+	os_module.Set("spawn", object.MapPair{
 		Key: &object.String{Value: "spawn"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3387,25 +4401,25 @@ func init_os_module() *object.Map {
 				err := cmd.Start()
 
 				if err != nil {
-					return &object.Error{Message: fmt.Sprintf("failed to spawn command: %s", err.Error()), IsUserCreated: true}
+					return new_user_error("exec", fmt.Sprintf("failed to spawn command: %s", err.Error()), map[string]object.Object{
+						"command": command,
+					})
 				}
 
 				return &object.Number{Value: float64(cmd.Process.Pid)}
 			},
 		},
-	}
+	})
 
 	return os_module
 }
 
 // init_time_module creates and returns the Time module
 func init_time_module() *object.Map {
-	time_module := &object.Map{
-		Pairs: make(map[string]object.MapPair),
-	}
+	time_module := object.NewMap()
 
 	// Time.now() - returns current time
-	time_module.Pairs["now"] = object.MapPair{
+	time_module.Set("now", object.MapPair{
 		Key: &object.String{Value: "now"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3415,12 +4429,12 @@ func init_time_module() *object.Map {
 				return &object.Time{Value: time.Now()}
 			},
 		},
-	}
+	})
 
 	// Time.date(format, dateString) - parses a date string using the given format
 	// Format uses Seda patterns: YYYY, MM, DD, HH, mm, ss
 	// Example: Time.date("DD-MM-YYYY", "20-01-2000")
-	time_module.Pairs["date"] = object.MapPair{
+	time_module.Set("date", object.MapPair{
 		Key: &object.String{Value: "date"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3452,10 +4466,10 @@ func init_time_module() *object.Map {
 				return &object.Time{Value: parsedTime}
 			},
 		},
-	}
+	})
 
 	// Time.unix(seconds) - creates a Time from Unix timestamp (seconds since epoch)
-	time_module.Pairs["unix"] = object.MapPair{
+	time_module.Set("unix", object.MapPair{
 		Key: &object.String{Value: "unix"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3474,19 +4488,94 @@ func init_time_module() *object.Map {
 				return &object.Time{Value: unixTime}
 			},
 		},
-	}
+	})
 
 	return time_module
 }
 
+// init_seq_module creates the Seq module, which starts lazy sequences and offers their operators as functions
+func init_seq_module() *object.Map {
+	seq_module := object.NewMap()
+
+	// Seq.from(iterable) - a sequence over an array, string, range, iterator or value with a next or iter method
+	seq_module.Set("from", object.MapPair{
+		Key: &object.String{Value: "from"},
+		Value: &object.Builtin{
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return object.NewError("wrong number of arguments for Seq.from. got=%d, want=1", len(args))
+				}
+				it, err := iterate(args[0])
+				if err != nil {
+					return err
+				}
+				return it
+			},
+		},
+	})
+
+	// Seq.count_from(start, step = 1) - the endless sequence start, start + step, ...
+	seq_module.Set("count_from", object.MapPair{
+		Key: &object.String{Value: "count_from"},
+		Value: &object.Builtin{
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) < 1 || len(args) > 2 {
+					return object.NewError("wrong number of arguments for Seq.count_from. got=%d, want=1 or 2", len(args))
+				}
+				start, ok := args[0].(*object.Number)
+				if !ok {
+					return object.NewError("first argument to Seq.count_from must be NUMBER, got %s", args[0].Type())
+				}
+				step := &object.Number{Value: 1}
+				if len(args) == 2 {
+					if step, ok = args[1].(*object.Number); !ok {
+						return object.NewError("second argument to Seq.count_from must be NUMBER, got %s", args[1].Type())
+					}
+				}
+
+				count := 0.0
+				return &object.Iterator{
+					Name: "Seq.count_from",
+					Next: func() (object.Object, bool) {
+						count++
+						return &object.Number{Value: start.Value + step.Value*(count-1)}, true
+					},
+					Close: func() {},
+				}
+			},
+		},
+	})
+
+	// Seq.map(iterable, fn), Seq.take(iterable, n), ... - the sequence methods as functions, for pipelines
+	for name := range sequence_methods {
+		name := name
+		seq_module.Set(name, object.MapPair{
+			Key: &object.String{Value: name},
+			Value: &object.Builtin{
+				Fn: func(args ...object.Object) object.Object {
+					if len(args) == 0 {
+						return object.NewError("wrong number of arguments for Seq.%s. got=0, want at least 1", name)
+					}
+					it, err := iterate(args[0])
+					if err != nil {
+						return object.NewError("first argument to Seq.%s must be iterable, got %s", name, args[0].Type())
+					}
+					result, _ := call_sequence_method(it, name, args[1:])
+					return result
+				},
+			},
+		})
+	}
+
+	return seq_module
+}
+
 // UI Module - Declarative UI utilities
 func init_ui_module() *object.Map {
-	ui_module := &object.Map{
-		Pairs: make(map[string]object.MapPair),
-	}
+	ui_module := object.NewMap()
 
 	// UI.mount(component, ...args) - instantiates and renders a component
-	ui_module.Pairs["mount"] = object.MapPair{
+	ui_module.Set("mount", object.MapPair{
 		Key: &object.String{Value: "mount"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3514,7 +4603,7 @@ func init_ui_module() *object.Map {
 
 					// Invoke the function with its captured environment (closure)
 					// The function should have captured the component environment
-					result := apply_function(fn, []object.Object{}, fn.Env)
+					result := apply_function_from_method(fn, []object.Object{})
 					if is_error(result) {
 						return fmt.Errorf("error in onClick handler: %s", result.(*object.Error).Message)
 					}
@@ -3540,10 +4629,10 @@ func init_ui_module() *object.Map {
 				return object.NULL
 			},
 		},
-	}
+	})
 
 	// UI.inspect(ui_element) - inspects a UI element tree (debugging utility)
-	ui_module.Pairs["inspect"] = object.MapPair{
+	ui_module.Set("inspect", object.MapPair{
 		Key: &object.String{Value: "inspect"},
 		Value: &object.Builtin{
 			Fn: func(args ...object.Object) object.Object {
@@ -3561,7 +4650,7 @@ func init_ui_module() *object.Map {
 				return &object.String{Value: element.Inspect()}
 			},
 		},
-	}
+	})
 
 	return ui_module
 }
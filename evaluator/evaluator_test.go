@@ -346,6 +346,71 @@ func TestForStatements(t *testing.T) {
 	}
 }
 
+func TestWhileStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"var i = 0\nwhile i < 5 ::\n  i = i + 1\nend\ni", 5},
+		{"var i = 10\nwhile i < 5 ::\n  i = i + 1\nend\ni", 10},
+		{"var i = 0\nwhile true ::\n  i = i + 1\n  if i == 3 ::\n    break\n  end\nend\ni", 3},
+		{"fn first_over(limit) ::\n  var n = 1\n  while true ::\n    if n > limit :: return n end\n    n = n * 2\n  end\nend\nfirst_over(20)", 32},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestLoopControl(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		// continue skips the rest of the body
+		{"var sum = 0\nfor n in 1..6 ::\n  if n % 2 == 0 ::\n    continue\n  end\n  sum = sum + n\nend\nsum", 9},
+		{"var i = 0\nvar sum = 0\nwhile i < 5 ::\n  i = i + 1\n  if i == 2 :: continue end\n  sum = sum + i\nend\nsum", 13},
+		// labeled break leaves both loops
+		{"var count = 0\nouter: for a in 0..3 ::\n  for b in 0..3 ::\n    if b == 1 :: break outer end\n    count = count + 1\n  end\nend\ncount", 1},
+		// labeled continue moves to the next outer iteration
+		{"var count = 0\nouter: for a in 0..3 ::\n  for b in 0..3 ::\n    if b == 1 :: continue outer end\n    count = count + 1\n  end\nend\ncount", 3},
+		// unlabeled break only leaves the inner loop
+		{"var count = 0\nfor a in 0..3 ::\n  for b in 0..3 ::\n    if b == 1 :: break end\n    count = count + 1\n  end\nend\ncount", 3},
+		// labels work across loop kinds
+		{"var tries = 0\nretry: while tries < 10 ::\n  tries = tries + 1\n  for x in [1] ::\n    if tries < 4 :: continue retry end\n  end\n  break\nend\ntries", 4},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestLoopControlErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		// a label only reaches loops inside the same function
+		{"fn f() ::\n  break outer\nend\nouter: for a in 0..3 ::\n  f()\nend", "no enclosing loop labeled 'outer'"},
+		{"fn f() ::\n  for a in 0..3 ::\n    continue nope\n  end\nend\nf()", "no enclosing loop labeled 'nope'"},
+		{"fn f() ::\n  break\nend\nfor a in 0..3 ::\n  f()\nend", "break outside of a loop"},
+		{"[1, 2].map(fn(x) :: continue end)", "continue outside of a loop"},
+		{"break", "break outside of a loop"},
+		{"outer: for a in 0..3 ::\n  a\nend\ncontinue outer", "no enclosing loop labeled 'outer'"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
 func TestCaseExpressions(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -529,6 +594,167 @@ func TestCaseStatements(t *testing.T) {
 	}
 }
 
+func TestCasePatterns(t *testing.T) {
+	describe := `
+	struct Point ::
+		x: number,
+		y: number
+	end
+
+	fn describe(value) ::
+		return case value ::
+			0 | 1 => "tiny"
+			n: number if n < 0 => "negative #{n}"
+			2..10 => "small"
+			n: number => "number #{n}"
+			[] => "empty"
+			[first, ...rest] => "first #{first}, #{rest.length()} more"
+			{"type": "circle", "r": r} => "circle r=#{r}"
+			Point(0, y) => "on y axis at #{y}"
+			Point(x: px) if px > 5 => "far point"
+			Point(x, y) => "point #{x},#{y}"
+			_ => "other"
+		end
+	end
+	`
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"describe(1)", "tiny"},
+		{"describe(-3)", "negative -3"},
+		{"describe(9)", "small"},
+		{"describe(50)", "number 50"},
+		{"describe([])", "empty"},
+		{"describe([1, 2, 3])", "first 1, 2 more"},
+		{`describe({"type": "circle", "r": 2, "fill": "red"})`, "circle r=2"},
+		{`describe({"type": "square"})`, "other"},
+		{"describe(Point(0, 4))", "on y axis at 4"},
+		{"describe(Point(7, 4))", "far point"},
+		{"describe(Point(1, 2))", "point 1,2"},
+		{`describe("hi")`, "other"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(describe+tt.input), tt.expected)
+	}
+}
+
+func TestCasePatternConstants(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// A constant is compared by value instead of binding the subject
+		{"const LIMIT = 1\ncase 2 ::\n  LIMIT => \"at limit\"\n  n => \"other #{n}\"\nend", "other 2"},
+		{"const LIMIT = 1\ncase 1 ::\n  LIMIT => \"at limit\"\n  n => \"other #{n}\"\nend", "at limit"},
+		{"const ORIGIN = [0, 0]\ncase [[0, 0], 5] ::\n  [ORIGIN, d] => \"origin #{d}\"\n  _ => \"elsewhere\"\nend", "origin 5"},
+		// Capitalized names are values too, whether or not they are constant
+		{"var Max = 3\ncase 2 ::\n  Max => \"max\"\n  n => \"other #{n}\"\nend", "other 2"},
+		// Lowercase variables still bind
+		{"var limit = 1\ncase 2 ::\n  limit => \"bound #{limit}\"\nend", "bound 2"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestCasePatternBindingsStayInBranch(t *testing.T) {
+	input := `
+	var first = "outer"
+	case [1, 2] ::
+		[first, second] => first + second
+	end
+	first
+	`
+
+	testStringObject(t, testEval(input), "outer")
+}
+
+func TestCasePatternErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"case 1 ::\n  Missing(x) => x\nend", "identifier not found: Missing"},
+		// A misspelled variant does not bind everything
+		{"type Shape = Circle(r) | Empty\ncase Empty ::\n  Circle(r) => r\n  Emtpy => 0\nend", "identifier not found: Emtpy"},
+		{"struct P ::\n  x: number\nend\ncase P(1) ::\n  P(z: 1) => 1\nend", "unknown field 'z' for P"},
+		{"struct P ::\n  x: number\nend\ncase P(1) ::\n  P(1, 2) => 1\nend", "too many fields in P pattern: got 2, want 1"},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("input %q: expected error", tt.input)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("wrong error. want %q, got %q", tt.expected, err.Message)
+		}
+	}
+}
+
+func TestDestructuring(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var [a, b, ...rest] = [1, 2, 3, 4]; a + b + rest.length()", 5},
+		{"var [a, _, c] = [1, 2, 3, 4]; a + c", 4},
+		{"var [first, ...rest] = [7]; rest.length()", 0},
+		{`var {name, age = 30} = {"name": "Ana"}; name + age.to_string()`, "Ana30"},
+		{`var {address: {city}} = {"address": {"city": "Porto"}}; city`, "Porto"},
+		{`var {"full name": full} = {"full name": "Ana Lee"}; full`, "Ana Lee"},
+		{"var [x, [y, z] = [8, 9]] = [1]; x + y + z", 18},
+		{"var [a, b = a * 2] = [4]; b", 8},
+		{"struct P :: x: number, y: number end; var {x, y} = P(3, 4); x * y", 12},
+		{"fn pair() :: return 1, 2 end; var [a, b] = pair(); a + b", 3},
+		{"var total = 0; for [k, v] in [[1, 2], [3, 4]] :: total = total + k * v end; total", 14},
+		{`var names = ""; for i, {name} in [{"name": "p"}, {"name": "q"}] :: names = names + name + i.to_string() end; names`, "p0q1"},
+		{`fn dist([x1, y1], {x, y}) :: (x - x1) + (y - y1) end; dist([1, 2], {"x": 4, "y": 6})`, 7},
+		{"fn head([first, ...rest] = [0]) :: first end; head() + head([5, 6])", 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testNumberObject(t, evaluated, float64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`var [a, b] = "ab"`, "cannot destructure string as an array"},
+		{"var {a} = [1]", "cannot destructure array as a map"},
+		{"var [a, b, c] = [1, 2]", "not enough values to destructure: got 2, want 3"},
+		{`var {name, age} = {"name": "Ana"}`, "missing key 'age' to destructure"},
+		{"struct P :: x: number end; var {z} = P(1)", "unknown field 'z' for P"},
+		{"const [a] = [[1]]; a.push(2)", "cannot call push() on immutable array"},
+		{"for [k, v] in [1] :: k end", "cannot destructure number as an array"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
 func TestNestedControlFlow(t *testing.T) {
 	input := `
 	for i in [1, 2, 3, 4, 5] ::
@@ -578,6 +804,19 @@ func testStringObject(t *testing.T, obj object.Object, expected string) bool {
 	return true
 }
 
+// testInspect evaluates input and compares what it prints with expected
+func testInspect(t *testing.T, input string, expected string) bool {
+	result := testEval(input)
+	if result.Inspect() != expected {
+		t.Errorf("wrong result for %q. expected=%s, got=%s", input, expected, result.Inspect())
+		return false
+	}
+	return true
+}
+
+// moneyStruct declares the struct the operator and equality tests give methods to
+const moneyStruct = "struct Money ::\n  cents: number,\n  currency: string\nend\n"
+
 // Function and method tests
 
 func TestFunctionDefinitions(t *testing.T) {
@@ -667,6 +906,98 @@ func TestFunctionLiterals(t *testing.T) {
 	}
 }
 
+func TestFunctionParameters(t *testing.T) {
+	greet := `
+	fn greet(name, greeting = "Hello", punct = "!") ::
+		return greeting + ", " + name + punct
+	end
+	fn total(first, ...rest: number) ::
+		var sum = first
+		for n in rest ::
+			sum = sum + n
+		end
+		return sum
+	end
+	fn span(start, stop = start + 10) ::
+		return stop - start
+	end
+	`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`greet("Ana")`, "Hello, Ana!"},
+		{`greet("Ana", "Hi")`, "Hi, Ana!"},
+		{`greet(name: "Bo", punct: "?")`, "Hello, Bo?"},
+		{`greet("Cy", punct: ".")`, "Hello, Cy."},
+		{"total(1)", 1},
+		{"total(1, 2, 3)", 6},
+		{"span(5)", 10},
+		{"span(5, 6)", 1},
+		{"struct P :: x: number end; fn P.move(dx = 0, dy = 0) :: self.x + dx + dy end; P(1).move(dy: 5)", 6},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(greet + tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testNumberObject(t, evaluated, float64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestFunctionArgumentErrors(t *testing.T) {
+	greet := "fn greet(name, greeting = \"Hello\") :: greeting + name end\nfn total(first, ...rest: number) :: first end\n"
+
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"greet()", "wrong number of arguments for function 'greet': got 0, want 1 to 2"},
+		{`greet("a", "b", "c")`, "wrong number of arguments for function 'greet': got 3, want 1 to 2"},
+		{`greet(nme: "x")`, "unknown parameter 'nme' for function 'greet'"},
+		{`greet("x", name: "y")`, "parameter 'name' of function 'greet' given more than once"},
+		{`greet(greeting: "Yo")`, "missing argument for parameter 'name' of function 'greet'"},
+		{"total()", "wrong number of arguments for function 'total': got 0, want at least 1"},
+		{`total(1, 2, "3")`, "type error in function 'total': parameter 'rest' expects number, got string"},
+		{"var f = fn(x) :: x end; f(1, 2)", "wrong number of arguments for anonymous function: got 2, want 1"},
+		// Methods called through a map bind self and then check their arguments like any call
+		{"var o = {}\no.greet = fn(self, name) :: name end\no.greet()", "wrong number of arguments for anonymous function: got 1, want 2"},
+		{"var o = {}\no.greet = fn(self, name) :: name end\no.greet(1, 2)", "wrong number of arguments for anonymous function: got 3, want 2"},
+		{"var o = {}\no.hello = fn() :: \"hi\" end\no.hello()", "wrong number of arguments for anonymous function: got 1, want 0"},
+		{"var base = {}\nbase.greet = fn(self, name) :: name end\nvar o = Object.extend(base)\no.greet()", "wrong number of arguments for anonymous function: got 1, want 2"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(greet + tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestCallbacksBindArgumentsLeniently(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[1, 2].map_with_index(fn(x) :: return x * 10 end)", "[10, 20]"},
+		{`{"a": 1, "b": 2}.filter(fn(key) :: return key == "a" end)`, `{"a": 1}`},
+		{"[1, 2].map(fn(x, extra) :: return extra end)", "[null, null]"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -1088,27 +1419,46 @@ func TestMapLiterals(t *testing.T) {
 		t.Fatalf("Eval didn't return Map. got=%T (%+v)", evaluated, evaluated)
 	}
 
-	expected := map[string]int64{
-		"one":   1,
-		"two":   2,
-		"three": 3,
-		"4":     4,
-		"true":  5,
-		"false": 6,
+	expected := []struct {
+		key   object.Object
+		value int64
+	}{
+		{&object.String{Value: "one"}, 1},
+		{&object.String{Value: "two"}, 2},
+		{&object.String{Value: "three"}, 3},
+		{object.NewInteger(4), 4},
+		{object.TRUE, 5},
+		{object.FALSE, 6},
 	}
 
-	if len(result.Pairs) != len(expected) {
-		t.Fatalf("Map has wrong num of pairs. got=%d", len(result.Pairs))
+	if result.Len() != len(expected) {
+		t.Fatalf("Map has wrong num of pairs. got=%d", result.Len())
 	}
 
-	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+	for _, tt := range expected {
+		pair, ok := result.Get(map_key(tt.key))
 		if !ok {
-			t.Errorf("no pair for given key in Pairs. key=%q", expectedKey)
+			t.Errorf("no pair for given key in Pairs. key=%s", tt.key.Inspect())
 			continue
 		}
 
-		testIntegerObject(t, pair.Value, int(expectedValue))
+		testIntegerObject(t, pair.Value, int(tt.value))
+	}
+}
+
+func TestMapInsertionOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, `{"z": 1, "a": 2, "m": 3}`},
+		{"var m = {\"z\": 1}\nm[\"a\"] = 2\nm.b = 3\nm[\"z\"] = 4\nm", `{"z": 4, "a": 2, "b": 3}`},
+		{"var m = {\"z\": 1, \"a\": 2}\nm.delete(\"z\")\nm[\"z\"] = 3\nm", `{"a": 2, "z": 3}`},
+		{"var keys = \"\"\nfor v, k in {\"c\": 1, \"b\": 2, \"a\": 3} ::\n  keys += k\nend\nkeys", `"cba"`},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
 	}
 }
 
@@ -1180,104 +1530,433 @@ func TestAssignmentExpressions(t *testing.T) {
 	}
 }
 
-// Comment Tests
-
-func TestCommentsIgnored(t *testing.T) {
+func TestCompoundAssignment(t *testing.T) {
 	tests := []struct {
 		input    string
-		expected int
+		expected interface{}
 	}{
-		{
-			`# This is a comment
-			 var x = 5  # Another comment
-			 x  # Final comment`,
-			5,
-		},
-		{
-			`# Multiple
-			 # comment
-			 # lines
-			 var result = 42
-			 result`,
-			42,
-		},
-		{
-			`fn add(a, b) ::  # Function comment
-			   # Comment inside function
-			   return a + b  # Return comment
-			 end
-			 add(3, 7)`,
-			10,
-		},
+		{"var x = 5; x += 3; x", 8},
+		{"var x = 5; x -= 3; x", 2},
+		{"var x = 5; x *= 3; x", 15},
+		{"var x = 6; x /= 3; x", 2},
+		{"var x = 7; x %= 3; x", 1},
+		{"var x = 2; x ^= 3; x", 8},
+		{"var x = 1; var y = x += 2; y", 3},
+		{`var s = "ab"; s += "cd"; s`, "abcd"},
+		{`var m = {"k": 1}; m["k"] += 10; m["k"]`, 11},
+		{`var m = {"name": "a"}; m.name += "b"; m.name`, "ab"},
+		{"var a = [1, 2]; a[1] *= 5; a[1]", 10},
+		{"struct P :: n: number end; var p = P(2); p.n -= 5; p.n", -3},
+		{"var a = [1]; var b = a; a += 2; a += [3, 4]; b.length()", 4},
+		{"var a = [[1]]; a += [[2]]; a.length()", 2},
+		{"var total = 0; for n in [1, 2, 3] :: total += n end; total", 6},
+		{"var i = 0; fn bump() ::\n i += 1\n return i\n end; var a = [0, 0]; a[bump()] += 5; a[1] + i", 6},
 	}
 
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-		testIntegerObject(t, evaluated, tt.expected)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
 	}
 }
 
-// Error Handling Tests
-
-func TestErrorHandling(t *testing.T) {
+func TestCompoundAssignmentErrors(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedMessage string
 	}{
-		{
-			"5 + true",
-			"type mismatch: NUMBER + BOOLEAN",
-		},
-		{
-			"5 + true; 5;",
-			"type mismatch: NUMBER + BOOLEAN",
-		},
-		{
-			"-true",
-			"unknown operator: -BOOLEAN",
-		},
-		{
-			"true + false",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"5; true + false; 5",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"if (10 > 1) :: true + false end",
-			"unknown operator: BOOLEAN + BOOLEAN",
-		},
-		{
-			"foobar",
-			"identifier not found: foobar",
-		},
-		{
-			`"Hello" - "World"`,
-			"unknown operator: STRING - STRING",
-		},
+		{"const x = 1; x += 1", "cannot reassign constant 'x'"},
+		{"const a = [1]; a += 2", "cannot modify immutable array"},
+		{`const m = {"k": 1}; m["k"] += 1`, "cannot modify immutable map"},
+		{`const m = {"k": 1}; m.k += 1`, "cannot modify immutable map"},
+		{"var x: number = 1; x += \"a\"", "type mismatch: NUMBER + STRING"},
+		{`var s = "a"; s -= "b"`, "unknown operator: STRING - STRING"},
+		{"missing += 1", "identifier not found: missing"},
+		{"var x = 1; x /= 0", "division by zero"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-
-		errObj, ok := evaluated.(*object.Error)
+		errObj, ok := testEval(tt.input).(*object.Error)
 		if !ok {
-			t.Errorf("no error object returned. got=%T(%+v)",
-				evaluated, evaluated)
+			t.Errorf("no error object returned for %q", tt.input)
 			continue
 		}
-
 		if errObj.Message != tt.expectedMessage {
-			t.Errorf("wrong error message. expected=%q, got=%q",
-				tt.expectedMessage, errObj.Message)
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
 		}
 	}
 }
 
-// Edge Cases and Integration Tests
-
-func TestDivisionByZero(t *testing.T) {
+func TestNilSafeOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`var user = {"address": {"city": "Porto"}}; user?.address?.city`, "Porto"},
+		{`var user = {"name": "a"}; user?.address?.city`, nil},
+		{"var user = nil; user?.address?.city", nil},
+		{`var user = nil; user?.name?.upper()`, nil},
+		{`var name = "ana"; name?.upper()`, "ANA"},
+		{`var user = {"tags": ["x", "y"]}; user?.tags?[1]`, "y"},
+		{"var tags = nil; tags?[0]", nil},
+		{"var f = nil; f?.(1)", nil},
+		{"var f = fn(x) :: return x + 1 end; f?.(1)", 2},
+		{`var m = {"f": nil}; m.f?.()`, nil},
+		{`var m = {}; m.missing?.()`, nil},
+		{`var m = {"f": fn() :: return 7 end}; m.f?.()`, 7},
+		{"nil ?? 5", 5},
+		{"3 ?? 5", 3},
+		{`false ?? "x"`, false},
+		{`var user = {}; user?.age ?? 18`, 18},
+		{"var calls = 0; fn bump() ::\n calls += 1\n return calls\n end; var x = 1 ?? bump(); calls", 0},
+		{"var f = nil; var calls = 0; fn bump() ::\n calls += 1\n return calls\n end; f?.(bump()); calls", 0},
+		// A link that meets null skips the rest of the chain
+		{"var user = nil; user?.address.city", nil},
+		{"var user = nil; user?.address.city.upper()", nil},
+		{"var tags = nil; tags?[0].name[1]", nil},
+		{"var f = nil; f?.().result", nil},
+		{`var m = {}; m.missing?.().result`, nil},
+		{"var user = nil; var calls = 0; fn bump() ::\n calls += 1\n return calls\n end; user?.a[bump()].b(bump()); calls", 0},
+		{"var user = nil; user?.address.city ?? \"none\"", "none"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestNilSafeOperatorsOnlySkipNull(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"var user = nil; user.name", "method 'name' not found on NULL"},
+		{"var n = 5; n?.missing", "method 'missing' not found on Number"},
+		{`var user = {"address": nil}; user?.address.city`, "method 'city' not found on NULL"},
+		{"struct P :: x: number end; P(1)?.y", "method 'y' not found on P"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var double = x => x * 2; double(4)", 8},
+		{"var add = (a, b) => a + b; add(2, 3)", 5},
+		{"var answer = () => 42; answer()", 42},
+		{"var add = (a, b = 10) => a + b; add(1)", 11},
+		{"var sub = a => b => a - b; sub(5)(3)", 2},
+		{"[1, 2, 3].map(x => x * 2).sum()", 12},
+		{"[1, 2, 3, 4].filter(x => x % 2 == 0).length()", 2},
+		{"[3, 1, 2].sort_by(x => -x).first()", 3},
+		{"[1, 2, 3].find(x => x > 1)", 2},
+		{"[1, 2, 3].reduce((acc, x) => acc + x, 0)", 6},
+		{"var base = 10; var add_base = x => x + base; base = 20; add_base(1)", 21},
+		{"5 |> (x => x + 1)", 6},
+		{`var first = ([x, y]) => x; first(["a", "b"])`, "a"},
+		{"var label = case 2 ::\n n if [1, 2].any(x => x == n) => \"listed\"\n _ => \"other\"\n end; label", "listed"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+
+	fn, ok := testEval("x => x").(*object.Function)
+	if !ok {
+		t.Fatalf("arrow function should evaluate to a Function")
+	}
+	if len(fn.Parameters) != 1 || fn.Parameters[0].Name.Value != "x" {
+		t.Errorf("wrong parameters: %v", fn.Parameters)
+	}
+}
+
+func TestArrowFunctionErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"var f = (n): string => n; f(1)", "type error in anonymous function: return value expects string, got number"},
+		{"var f = (a, b) => a + b; f(1)", "wrong number of arguments for anonymous function: got 1, want 2"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestPipelineOperator(t *testing.T) {
+	setup := "fn double(x) :: return x * 2 end; fn add(x, y) :: return x + y end; fn scale(x, by = 1, offset = 0) :: return x * by + offset end; "
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"5 |> double", 10},
+		{"5 |> double |> add(1)", 11},
+		{"1 + 2 |> double", 6},
+		{"3 |> scale(offset: 1, by: 3)", 10},
+		{"[1, 2, 3].map(double) |> fn(xs) :: return xs.sum() end", 12},
+		{"-2 |> Math.pow(3)", -8},
+		{"struct P :: x: number end; (4 |> P).x", 4},
+		{"var ops = {\"inc\": fn(x) :: return x + 1 end}; 1 |> ops.inc", 2},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(setup + tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestPipelineErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"fn add(x, y) :: return x + y end; 1 |> add(1, 2)", "wrong number of arguments for function 'add': got 3, want 2"},
+		{"1 |> missing", "identifier not found: missing"},
+		{"fn double(x) :: return x * 2 end; \"a\" |> double", "type mismatch: STRING * NUMBER"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	setup := "fn numbers(limit) ::\n var i = 0\n while i < limit ::\n yield i\n i += 1\n end\n end\n" +
+		"fn naturals() ::\n var n = 0\n while true ::\n yield n\n n += 1\n end\n end\n"
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"var total = 0\nfor n in numbers(5) ::\n total += n\n end\ntotal", 10},
+		{"var last = 0\nfor i, n in numbers(3) ::\n last = i * 10 + n\n end\nlast", 22},
+		{"var found = 0\nfor n in naturals() ::\n if n * n > 50 ::\n found = n\n break\n end\n end\nfound", 8},
+		{"var g = naturals()\ng.next()\ng.next()\ng.next()", 2},
+		{"var g = naturals()\nfor n in g ::\n if n == 3 :: break end\n end\ng.next()", 4},
+		// A generator the loop header called for is closed when the loop stops early
+		{"var g = naturals()\nfn same() :: return g end\nfor n in same() ::\n break\n end\ng.next()", nil},
+		{"var g = numbers(1)\ng.next()\ng.next()", nil},
+		// A finished take or take_while leaves its source to resume
+		{"var g = naturals()\ng.take(3).collect()\ng.next()", 3},
+		{"var g = naturals()\nfor n in g.take_while(n => n < 2) ::\n end\ng.next()", 3},
+		{"numbers(4).map(n => n * 2).sum()", 12},
+		{"numbers(10).filter(n => n % 3 == 0).length()", 4},
+		{"numbers(3).to_array().length()", 3},
+		{"var steps = []\nfn traced() ::\n steps.push(1)\n yield 1\n steps.push(2)\n yield 2\n end\nvar g = traced()\ng.next()\nsteps.length()", 1},
+		{"var g = fn() ::\n yield \"a\"\n yield \"b\"\n end\nvar out = \"\"\nfor s in g() ::\n out += s\n end\nout", "ab"},
+		// Closing a paused generator runs the ensure blocks it is inside, without resuming the rest
+		{"var log = []\nfn guarded() ::\n try ::\n yield 1\n log.push(\"after\")\n ensure ::\n log.push(\"ensure\")\n end\n end\nvar g = guarded()\ng.next()\ng.close()\nlog.join(\",\")", "ensure"},
+		{"var log = []\nfn guarded() ::\n try ::\n yield 1\n yield 2\n ensure ::\n log.push(\"ensure\")\n end\n end\nfor n in guarded() ::\n break\n end\nlog.length()", 1},
+		{"var g = numbers(3)\ng.next()\ng.close()\ng.next()", nil},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(setup + tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestIteratorProtocol(t *testing.T) {
+	setup := "struct Countdown ::\n from: number\n end\n" +
+		"fn Countdown.iter() ::\n var n = self.from\n while n > 0 ::\n yield n\n n -= 1\n end\n end\n" +
+		"var count = 0\nfn tick() ::\n if count < 3 ::\n count += 1\n return count\n end\n return nil\n end\n"
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{"var total = 0\nfor n in Countdown(from: 4) ::\n total += n\n end\ntotal", 10},
+		{"Countdown(from: 5).filter(n => n % 2 == 1).length()", 3},
+		{"Countdown(from: 3).first()", 3},
+		{"var total = 0\nfor n in {\"next\": tick} ::\n total += n\n end\ntotal", 6},
+		{"{\"next\": tick}.map(n => n * 10).sum()", 60},
+		{"var total = 0\nfor n in {\"iter\": fn() :: return [4, 5] end} ::\n total += n\n end\ntotal", 9},
+		{"var keys = 0\nfor k in {\"a\": 1, \"b\": 2} ::\n keys += 1\n end\nkeys", 2},
+		// Methods run with the map as self, including ones inherited from a prototype
+		{"var counter = {\"n\": 0}\ncounter.next = fn(self) ::\n if self.n < 3 ::\n self.n += 1\n return self.n\n end\n return nil\n end\ncounter.sum()", 6},
+		{"var Bag = {}\nBag.iter = fn(self) :: return self.items end\nvar total = 0\nfor n in Object.extend(Bag, {\"items\": [2, 3]}) ::\n total += n\n end\ntotal", 5},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(setup + tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"fn broken() ::\n yield 1\n yield missing\n end\nvar total = 0\nfor n in broken() ::\n total += n\n end", "identifier not found: missing"},
+		{"fn early() ::\n yield 1\n return 2\n end\nearly().to_array()", "function 'early' is a generator and cannot return a value"},
+		{"for n in 5 ::\n n\n end", "object is not iterable: *object.Number"},
+		{"struct Loop ::\n n: number\n end\nfn Loop.iter() :: return self end\nfor x in Loop(n: 1) ::\n x\n end", "iter() of Loop must return another iterable"},
+		{"fn one() ::\n yield 1\n end\none().missing()", "method 'missing' not found on Iterator"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+// Comment Tests
+
+func TestCommentsIgnored(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int
+	}{
+		{
+			`# This is a comment
+			 var x = 5  # Another comment
+			 x  # Final comment`,
+			5,
+		},
+		{
+			`# Multiple
+			 # comment
+			 # lines
+			 var result = 42
+			 result`,
+			42,
+		},
+		{
+			`fn add(a, b) ::  # Function comment
+			   # Comment inside function
+			   return a + b  # Return comment
+			 end
+			 add(3, 7)`,
+			10,
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testIntegerObject(t, evaluated, tt.expected)
+	}
+}
+
+// Error Handling Tests
+
+func TestErrorHandling(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			"5 + true",
+			"type mismatch: NUMBER + BOOLEAN",
+		},
+		{
+			"5 + true; 5;",
+			"type mismatch: NUMBER + BOOLEAN",
+		},
+		{
+			"-true",
+			"unknown operator: -BOOLEAN",
+		},
+		{
+			"true + false",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"5; true + false; 5",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"if (10 > 1) :: true + false end",
+			"unknown operator: BOOLEAN + BOOLEAN",
+		},
+		{
+			"foobar",
+			"identifier not found: foobar",
+		},
+		{
+			`"Hello" - "World"`,
+			"unknown operator: STRING - STRING",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)",
+				evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q",
+				tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+// Edge Cases and Integration Tests
+
+func TestDivisionByZero(t *testing.T) {
 	tests := []struct {
 		input           string
 		expectedMessage string
@@ -1449,8 +2128,8 @@ func TestComplexLogicalExpressions(t *testing.T) {
 func TestRangeExpressions(t *testing.T) {
 	tests := []struct {
 		input       string
-		start       int
-		end         int
+		start       int64
+		end         int64
 		inclusive   bool
 		description string
 	}{
@@ -1544,34 +2223,78 @@ func TestRangeWithVariables(t *testing.T) {
 	testIntegerObject(t, evaluated, 15) // 1 + 2 + 3 + 4 + 5
 }
 
-// String Indexing Tests
-
-func TestStringIndexing(t *testing.T) {
+func TestRangeFractionalBounds(t *testing.T) {
+	// Fractional bounds keep the whole numbers between them
 	tests := []struct {
 		input    string
 		expected string
 	}{
-		{`"hello"[0]`, "h"},
-		{`"hello"[1]`, "e"},
-		{`"hello"[4]`, "o"},
-		{`"world"[0]`, "w"},
-		{`"a"[0]`, "a"},
+		{"Seq.from(0..2.5).collect()", "[0, 1, 2]"},
+		{"Seq.from(0.5...3).collect()", "[1, 2, 3]"},
+		{"Seq.from(1..1.5).collect()", "[1]"},
+		{"Seq.from(-1.5..1).collect()", "[-1, 0]"},
+		{"var found = []\nfor i in 3..(9 / 2) ::\n  found.push(i)\nend\nfound", "[3, 4]"},
+		// Bounds past 2^53 stay exact
+		{"var found = []\nfor i in 9007199254740993...9007199254740995 ::\n  found.push(i)\nend\nfound",
+			"[9007199254740993, 9007199254740994, 9007199254740995]"},
+		{"Seq.from(9223372036854775806...9223372036854775807).collect()", "[9223372036854775806, 9223372036854775807]"},
+		{"case 9007199254740993 ::\n  9007199254740992..9007199254740993 => 1\n  _ => 2\nend", "2"},
 	}
 
 	for _, tt := range tests {
-		evaluated := testEval(tt.input)
-		testStringObject(t, evaluated, tt.expected)
+		testInspect(t, tt.input, tt.expected)
 	}
 }
 
-func TestStringIndexWithVariable(t *testing.T) {
-	input := `
-	var str = "hello"
-	var i = 2
-	str[i]
-	`
-
-	evaluated := testEval(input)
+func TestRangeBoundsOutOf64Bits(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0..(2^70)", "range end 1180591620717411303424 does not fit in 64 bits"},
+		{"-(2^70)..0", "range start -1180591620717411303424 does not fit in 64 bits"},
+	}
+
+	for _, tt := range tests {
+		err, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("expected error for %q", tt.input)
+			continue
+		}
+		if err.Message != tt.expected {
+			t.Errorf("wrong error for %q. expected=%q, got=%q", tt.input, tt.expected, err.Message)
+		}
+	}
+}
+
+// String Indexing Tests
+
+func TestStringIndexing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"hello"[0]`, "h"},
+		{`"hello"[1]`, "e"},
+		{`"hello"[4]`, "o"},
+		{`"world"[0]`, "w"},
+		{`"a"[0]`, "a"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testStringObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestStringIndexWithVariable(t *testing.T) {
+	input := `
+	var str = "hello"
+	var i = 2
+	str[i]
+	`
+
+	evaluated := testEval(input)
 	testStringObject(t, evaluated, "l")
 }
 
@@ -2086,3 +2809,481 @@ func TestShadowingInNestedScopes(t *testing.T) {
 	evaluated := testEval(input)
 	testIntegerObject(t, evaluated, 30) // 20 (inner) + 10 (outer)
 }
+
+func TestStructConstruction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"struct Point :: x: number, y: number end; Point(x: 1, y: 2).x", 1},
+		{"struct Point :: x: number, y: number end; Point(3, 4).y", 4},
+		{"struct Point :: x: number, y: number end; Point(3, y: 5).y", 5},
+		{"struct Point :: x: number, y: number end; var p = Point(x: 1, y: 2); p.x = 7; p.x", 7},
+		{`struct User :: name: string end; User(name: "ana").name`, "ana"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testNumberObject(t, evaluated, float64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestStructInspect(t *testing.T) {
+	evaluated := testEval("struct Point :: x: number, y: number end; Point(y: 2, x: 1)")
+
+	if evaluated.Inspect() != "Point(x: 1, y: 2)" {
+		t.Errorf("wrong inspect. want %q, got %q", "Point(x: 1, y: 2)", evaluated.Inspect())
+	}
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"struct Point :: x: number, y: number end; Point(x: 1)", "missing field 'y' for Point"},
+		{"struct Point :: x: number, y: number end; Point(x: 1, y: 2, z: 3)", "unknown field 'z' for Point"},
+		{"struct Point :: x: number, y: number end; Point(1, x: 2, y: 3)", "field 'x' of Point given more than once"},
+		{"struct Point :: x: number, y: number end; Point(1, 2, 3)", "too many arguments for Point: got 3, want 2"},
+		{"struct Point :: x: number, y: number end; var p = Point(1, 2); p.z = 1", "unknown field 'z' on Point"},
+		{"struct Point :: x: number, y: number end; const p = Point(1, 2); p.x = 1", "cannot modify immutable Point"},
+		{"struct Point :: x: number, y: number end; Point(1, 2).length()", "method 'length' not found on Point"},
+		{"var Shape = 1\nfn Shape.area() :: return 0 end", "cannot declare method on Shape: not a struct"},
+		{"type Name = string\nfn Name.upper() :: return 0 end", "cannot declare method on Name: not a struct"},
+		{"fn Missing.area() :: return 0 end", "cannot declare method on Missing: not defined"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestStructMethods(t *testing.T) {
+	input := `
+	struct Counter ::
+		count: number
+	end
+
+	fn Counter.increment(step) ::
+		self.count = self.count + step
+		return self
+	end
+
+	fn Counter.doubled() ::
+		return self.count * 2
+	end
+
+	var c = Counter(count: 1)
+	c.increment(2).increment(3)
+	c.doubled
+	`
+
+	evaluated := testEval(input)
+	testNumberObject(t, evaluated, 12)
+}
+
+func TestStructIsA(t *testing.T) {
+	input := `
+	struct Point ::
+		x: number,
+		y: number
+	end
+	struct Size ::
+		w: number,
+		h: number
+	end
+	var p = Point(x: 1, y: 2)
+	var same = Point(x: 1, y: 2)
+	check ::
+		p isA Point
+		p isA "point"
+		p is same
+	end
+	`
+
+	evaluated := testEval(input)
+	testResult, ok := evaluated.(*object.TestResult)
+	if !ok {
+		t.Fatalf("Expected TestResult, got %T", evaluated)
+	}
+	if testResult.Failed > 0 {
+		t.Errorf("Expected struct assertions to pass, got failures: %v", testResult.Failures)
+	}
+
+	passed, _ := eval_isA_assertion(testEval("struct Point :: x: number end; Point(1)"), testEval("struct Size :: w: number end; Size"))
+	if passed {
+		t.Error("Expected Point instance not to be a Size")
+	}
+}
+
+func TestSumTypes(t *testing.T) {
+	shapes := `
+	type Shape = Circle(radius: number) | Rect(w, h) | Empty
+
+	fn area(shape: Shape) ::
+		return case shape ::
+			Circle(r) => 3 * r * r
+			Rect(w, h) => w * h
+			Empty => 0
+		end
+	end
+	`
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"area(Circle(2))", 12},
+		{"area(Rect(h: 5, w: 2))", 10},
+		{"area(Empty)", 0},
+		{"Rect(1, 2).h", 2},
+		{"Circle(1)", "Circle(radius: 1)"},
+		{"Empty", "Empty"},
+		// Methods declared on the sum type go to every variant, and a variant can declare its own
+		{"fn Shape.doubled() :: return area(self) * 2 end\nCircle(1).doubled() + Rect(2, 3).doubled() + Empty.doubled()", 18},
+		{"fn Shape.name() :: return \"shape\" end\nfn Empty.name() :: return \"empty\" end\nCircle(1).name() + Empty.name()", "\"shapeempty\""},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(shapes + tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testNumberObject(t, evaluated, float64(expected))
+		case string:
+			if evaluated.Inspect() != expected {
+				t.Errorf("wrong inspect. want %q, got %q", expected, evaluated.Inspect())
+			}
+		}
+	}
+}
+
+func TestSumTypeIsA(t *testing.T) {
+	input := `
+	type Shape = Circle(radius) | Empty
+	type Other = Point(x)
+	check ::
+		Circle(1) isA Shape
+		Empty isA Shape
+		Circle(1) isA Circle
+		Empty isA Empty
+		Point(1) isA Shape
+		5 isA Shape
+	end
+	`
+
+	testResult, ok := testEval(input).(*object.TestResult)
+	if !ok {
+		t.Fatalf("Expected TestResult, got %T", testEval(input))
+	}
+	if testResult.Passed != 4 || testResult.Failed != 2 {
+		t.Errorf("Expected 4 passed and 2 failed assertions, got %d and %d", testResult.Passed, testResult.Failed)
+	}
+}
+
+func TestSumTypeErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"type Shape = Circle(radius: number) | Empty; Circle(\"big\")", "type error in Circle: field 'radius' expects number, got string"},
+		{"type Shape = Circle(radius) | Empty; var s: Shape = 5", "type error: variable 's' expects Shape, got number"},
+		{"type Shape = Circle(radius) | Empty; Circel(1)", "identifier not found: Circel"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestTypeAnnotationErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			`fn add(x: number, y: number) :: x + y end; add("1", 2)`,
+			"type error in function 'add': parameter 'x' expects number, got string",
+		},
+		{
+			`fn name(): string :: return 42 end; name()`,
+			"type error in function 'name': return value expects string, got number",
+		},
+		{
+			`var double = fn(x: number): number :: return "x" end; double(1)`,
+			"type error in anonymous function: return value expects number, got string",
+		},
+		{
+			`var x: number = "ten"`,
+			"type error: variable 'x' expects number, got string",
+		},
+		{
+			`var x: number = 10; x = "ten"`,
+			"type error: variable 'x' expects number, got string",
+		},
+		{
+			`var names: Array[String] = ["a", 2]`,
+			"type error: variable 'names' expects Array[String], got array",
+		},
+		{
+			`var scores: Map[number] = {"a": "b"}`,
+			"type error: variable 'scores' expects Map[number], got map",
+		},
+		{
+			`type UserId = number; var id: UserId = "abc"`,
+			"type error: variable 'id' expects UserId, got string",
+		},
+		{
+			`var x: Missing = 1`,
+			"type error: variable 'x': unknown type 'Missing'",
+		},
+		{
+			`struct Point :: x: number end; Point(x: "1")`,
+			"type error in Point: field 'x' expects number, got string",
+		},
+		{
+			`struct Point :: x: number end; fn Point.label(): string :: return self.x end; Point(1).label()`,
+			"type error in function 'Point.label': return value expects string, got number",
+		},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestTypeAnnotationsAccepted(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{`fn add(x: number, y: number): number :: x + y end; add(1, 2)`, 3},
+		{`type Score = number; fn twice(s: Score): Score :: s * 2 end; twice(4)`, 8},
+		{`var names: Array[String] = ["a", "b"]; names.length()`, 2},
+		{`var anything: any = "x"; anything = 5; anything`, 5},
+		{`var x: number = 1
+		fn f() ::
+			var x = "shadow"
+			return 0
+		end
+		f()
+		x`, 1},
+		{`struct Point :: x: number end; fn dist(p: Point): number :: p.x end; dist(Point(x: 7))`, 7},
+		{`fn safe(x: number): number ::
+			if x < 0 :: return error("negative") end
+			return x
+		end
+		safe(3)`, 3},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testNumberObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestRuntimeErrorPositions(t *testing.T) {
+	input := `fn ratio(a, b) ::
+  return a / b
+end
+
+fn report(values) ::
+  println("computing")
+  return ratio(values.length(), 0)
+end
+
+var x = 1
+report([1, 2])`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Line != 2 || errObj.Column != 12 {
+		t.Errorf("wrong error position. expected=2:12, got=%d:%d", errObj.Line, errObj.Column)
+	}
+
+	expected := []object.StackFrame{
+		{Function: "<main>", Line: 11},
+		{Function: "report", Line: 7},
+		{Function: "ratio", Line: 2},
+	}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack length. expected=%d, got=%d: %v", len(expected), len(errObj.Stack), errObj.Stack)
+	}
+	for i, frame := range expected {
+		if errObj.Stack[i] != frame {
+			t.Errorf("frame %d: expected=%+v, got=%+v", i, frame, errObj.Stack[i])
+		}
+	}
+}
+
+func TestRuntimeErrorStackUnwindsAfterCalls(t *testing.T) {
+	input := `fn ok() ::
+  return 1
+end
+ok()
+ok()
+var y = -"text"`
+
+	evaluated := testEval(input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if len(errObj.Stack) != 1 || errObj.Stack[0].Function != "<main>" || errObj.Stack[0].Line != 6 {
+		t.Errorf("expected a single <main> frame at line 6, got=%v", errObj.Stack)
+	}
+}
+
+func TestUserErrorsHaveNoPosition(t *testing.T) {
+	evaluated := testEval(`fn fail() :: return error("nope") end
+fail()`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	if errObj.HasPosition() || errObj.Traceback() != "" {
+		t.Errorf("user errors are values and should not carry a traceback, got line %d", errObj.Line)
+	}
+}
+
+func TestTryStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		// Runtime errors are caught and bound
+		{"var r = 0\ntry ::\n  r = 1 / 0\ncatch err ::\n  r = err.message\nend\nr", "division by zero"},
+		// Thrown user errors and strings are caught
+		{"var r = 0\ntry ::\n  throw error(\"bad input\")\ncatch err ::\n  r = err.message\nend\nr", "bad input"},
+		{"var r = 0\ntry ::\n  throw \"oops\"\ncatch err ::\n  r = err.message\nend\nr", "oops"},
+		// Errors raised inside called functions are caught
+		{"fn fail() :: return 1 / 0 end\nvar r = 0\ntry ::\n  fail()\ncatch ::\n  r = 1\nend\nr", 1},
+		// Code after the failing statement does not run
+		{"var r = 0\ntry ::\n  r = 1\n  r = -\"x\"\n  r = 2\ncatch ::\n  r = r + 10\nend\nr", 11},
+		// Ensure runs after success, after a caught error and on return
+		{"var r = 0\ntry ::\n  r = 1\nensure ::\n  r = r + 10\nend\nr", 11},
+		{"var r = 0\ntry ::\n  r = 1 / 0\ncatch ::\n  r = 1\nensure ::\n  r = r + 10\nend\nr", 11},
+		{"var r = 0\nfn f() ::\n  try ::\n    return 1\n  ensure ::\n    r = 10\n  end\nend\nf() + r", 11},
+		// Rethrown errors reach the outer handler
+		{"var r = 0\ntry ::\n  try ::\n    throw \"inner\"\n  catch err ::\n    throw err\n  end\ncatch outer ::\n  r = outer.message\nend\nr", "inner"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testNumberObject(t, evaluated, float64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
+
+func TestTryErrorsPropagate(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		// Without catch the error continues after ensure runs
+		{"try ::\n  1 / 0\nensure ::\n  var done = true\nend", "division by zero"},
+		{"try ::\n  1 / 0\ncatch err ::\n  throw err\nend", "division by zero"},
+		{"throw 42", "throw expects an error or string, got NUMBER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.IsUserCreated {
+			t.Errorf("expected %q to propagate as a runtime error", tt.input)
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestRethrowKeepsOriginalPosition(t *testing.T) {
+	input := "try ::\n  var x = 1 / 0\ncatch err ::\n  throw err\nend"
+
+	errObj, ok := testEval(input).(*object.Error)
+	if !ok {
+		t.Fatal("no error object returned")
+	}
+	if errObj.Line != 2 {
+		t.Errorf("rethrown error should keep its original line. expected=2, got=%d", errObj.Line)
+	}
+}
+
+func TestErrorKindsAndCauses(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`error("failed").kind`, "error"},
+		{`error("bad port", "config").kind`, "config"},
+		{`error("bad port", "config", {"port": 99}).data["port"]`, 99},
+		{`isNull(error("failed").data["port"])`, true},
+		{`isNull(error("failed").cause)`, true},
+		{`error("invalid JSON", "parse").wrap("loading config").message`, "loading config"},
+		{`error("invalid JSON", "parse").wrap("loading config").kind`, "parse"},
+		{`error("invalid JSON", "parse").wrap("loading config").cause.message`, "invalid JSON"},
+		{`error("invalid JSON", "parse").wrap("a").wrap("b").is("parse")`, true},
+		{`error("invalid JSON", "parse").is("io")`, false},
+		{"var k = \"\"\ntry ::\n  1 / 0\ncatch err ::\n  k = err.kind\nend\nk", "runtime"},
+		{"var k = \"\"\ntry ::\n  var n: number = \"x\"\ncatch err ::\n  k = err.kind\nend\nk", "type"},
+		{"var k = \"\"\ntry ::\n  throw error(\"gone\", \"io\")\ncatch err ::\n  k = err.kind\nend\nk", "io"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testNumberObject(t, evaluated, float64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			testStringObject(t, evaluated, expected)
+		}
+	}
+}
@@ -74,6 +74,66 @@ func TestFileReadLines(t *testing.T) {
 	}
 }
 
+func TestFileLines(t *testing.T) {
+	// Create a temporary file with multiple lines
+	tmpFile := filepath.Join(os.TempDir(), "seda_test_lines.txt")
+	defer os.Remove(tmpFile)
+
+	os.WriteFile(tmpFile, []byte("alpha\nbeta\ngamma\n"), 0644)
+
+	input := `
+		var lines, err = File.lines("` + tmpFile + `")
+		var joined = ""
+		for i, line in lines ::
+			joined += "#{i}:#{line} "
+		end
+		joined
+	`
+	testStringObject(t, testEval(input), "0:alpha 1:beta 2:gamma ")
+
+	// Lines are read on demand, so a loop can stop before the end of the file
+	input = `
+		var lines, err = File.lines("` + tmpFile + `")
+		var first = lines.next()
+		var second = lines.next()
+		lines.close()
+		[first, second, lines.next()]
+	`
+	arr, ok := testEval(input).(*object.Array)
+	if !ok || len(arr.Elements) != 3 {
+		t.Fatalf("expected an array of 3 elements, got %v", arr)
+	}
+	testStringObject(t, arr.Elements[0], "alpha")
+	testStringObject(t, arr.Elements[1], "beta")
+	testNullObject(t, arr.Elements[2])
+
+	// Taking the first lines leaves the rest to read
+	input = `
+		var lines, err = File.lines("` + tmpFile + `")
+		var joined = ""
+		for line in lines.take(1) ::
+			joined += line + " "
+		end
+		for line in lines ::
+			joined += line + " "
+		end
+		joined
+	`
+	testStringObject(t, testEval(input), "alpha beta gamma ")
+
+	// A missing file is reported when opening, like File.read_lines
+	input = `
+		var lines, err = File.lines("/nonexistent/seda_lines.txt")
+		[lines, err.kind()]
+	`
+	arr, ok = testEval(input).(*object.Array)
+	if !ok || len(arr.Elements) != 2 {
+		t.Fatalf("expected an array of 2 elements, got %v", arr)
+	}
+	testNullObject(t, arr.Elements[0])
+	testStringObject(t, arr.Elements[1], "io")
+}
+
 func TestFileAppend(t *testing.T) {
 	// Create a temporary file
 	tmpFile := filepath.Join(os.TempDir(), "seda_test_append.txt")
@@ -335,6 +395,22 @@ func TestFileReadNonexistent(t *testing.T) {
 	testBooleanObject(t, result, true)
 }
 
+func TestFileErrorKind(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`var content, err = File.read("/nonexistent/file/path.txt")
+		err.kind`, "io"},
+		{`var content, err = File.read("/nonexistent/file/path.txt")
+		err.data["path"]`, "/nonexistent/file/path.txt"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
 func TestFileComplexOperations(t *testing.T) {
 	// Create a temp directory for this test
 	tmpDir := filepath.Join(os.TempDir(), "seda_test_complex")
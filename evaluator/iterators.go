@@ -0,0 +1,435 @@
+package evaluator
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/vpaulo/seda/ast"
+	"github.com/vpaulo/seda/object"
+)
+
+// collected_methods are the array methods iterators run over their collected values, besides the
+// lazy operators and searches in sequences.go, which take precedence. Methods that only make sense on
+// an array that is kept around, like push and pop, are left out.
+var collected_methods = map[string]bool{
+	"length": true, "first": true, "last": true, "rest": true, "each": true, "map_with_index": true,
+	"find": true, "find_index": true, "any": true, "all": true, "none": true, "sort": true,
+	"sort_by": true, "reverse": true, "unique": true, "slice": true, "concat": true, "flatten": true,
+	"contains": true, "index_of": true, "last_index_of": true, "join": true, "sum": true,
+	"average": true, "min": true, "max": true, "partition": true, "compact": true,
+}
+
+// generator runs the body of a generator function on its own goroutine, one yield at a time.
+// Only one side runs at once: next hands control to the body and waits for the value it yields.
+type generator struct {
+	fn       *object.Function
+	env      *object.Environment
+	frame    call_frame
+	resume   chan bool          // true runs the body to its next yield, false abandons it
+	values   chan object.Object // yielded values, closed when the body finishes
+	started  bool
+	finished bool
+	closing  bool // the body is unwinding through its ensure blocks after close
+}
+
+// abandoned holds generators whose iterator was garbage collected while their body was paused.
+// The collector finds them on its own goroutine, so they are only queued there and closed by
+// release_abandoned, where running their ensure blocks cannot interleave with the program.
+var abandoned struct {
+	sync.Mutex
+	generators []*generator
+}
+
+// new_generator returns the iterator a call to a generator function produces. The body starts on the first next.
+func new_generator(fn *object.Function, env *object.Environment) *object.Iterator {
+	release_abandoned()
+
+	g := &generator{fn: fn, env: env, resume: make(chan bool), values: make(chan object.Object)}
+	env.SetYield(g.yield)
+
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+	it := &object.Iterator{Name: name, Next: g.next, Close: g.close}
+	runtime.SetFinalizer(it, func(*object.Iterator) {
+		abandoned.Lock()
+		abandoned.generators = append(abandoned.generators, g)
+		abandoned.Unlock()
+	})
+	return it
+}
+
+// release_abandoned closes the generators nothing can resume any more, so their goroutines exit
+func release_abandoned() {
+	abandoned.Lock()
+	generators := abandoned.generators
+	abandoned.generators = nil
+	abandoned.Unlock()
+
+	for _, g := range generators {
+		g.close()
+	}
+}
+
+// run evaluates the body once the first value is asked for
+func (g *generator) run() {
+	defer close(g.values)
+	if !<-g.resume {
+		return
+	}
+
+	g.frame = push_call(g.fn)
+	result := Eval(g.fn.Body, g.env)
+	pop_call(g.frame)
+
+	result = stray_loop_exit(result)
+	if returned, ok := result.(*object.ReturnValue); ok && returned.Value != object.NULL {
+		g.values <- object.NewError("%s is a generator and cannot return a value", function_description(g.fn))
+		return
+	}
+	if is_runtime_error(result) {
+		g.values <- result
+	}
+}
+
+// yield hands value to next and pauses the body until the following value is asked for.
+// It reports false when the generator was closed instead, and the body should return.
+func (g *generator) yield(value object.Object) bool {
+	if g.closing {
+		return false
+	}
+	pop_call(g.frame)
+	g.values <- value
+	resumed := <-g.resume
+	g.frame = push_call(g.fn)
+	g.closing = !resumed
+	return resumed
+}
+
+func (g *generator) next() (object.Object, bool) {
+	if g.finished {
+		return nil, false
+	}
+	if !g.started {
+		g.started = true
+		go g.run()
+	}
+
+	g.resume <- true
+	value, ok := <-g.values
+	if !ok || is_runtime_error(value) {
+		g.finished = true
+	}
+	return value, ok
+}
+
+// close abandons a paused body, waiting while it returns through its ensure blocks so its goroutine exits
+func (g *generator) close() {
+	if g.started && !g.finished {
+		g.finished = true
+		g.resume <- false
+		for range g.values {
+		}
+	}
+	g.finished = true
+}
+
+func eval_yield_statement(node *ast.YieldStatement, env *object.Environment) object.Object {
+	yield := env.Yield()
+	if yield == nil {
+		return object.NewError("yield outside of a generator")
+	}
+
+	var value object.Object = object.NULL
+	if node.Value != nil {
+		value = Eval(node.Value, env)
+		if is_runtime_error(value) {
+			return value
+		}
+	}
+
+	// A closed generator returns from wherever it is paused, running the ensure blocks around it
+	if !yield(value) {
+		return &object.ReturnValue{Value: object.NULL}
+	}
+	return object.NULL
+}
+
+// iterate returns an iterator over arrays, sets, tuples, strings, ranges, iterators, and maps or struct instances
+// that follow the iteration protocol: next() returns each value in turn and null when there are no more,
+// iter() returns something else to iterate.
+func iterate(value object.Object) (*object.Iterator, *object.Error) {
+	switch value := value.(type) {
+	case *object.Iterator:
+		return value, nil
+	case *object.Array:
+		return slice_iterator("array", value.Elements), nil
+	case *object.Set:
+		return slice_iterator("set", value.Values()), nil
+	case *object.Tuple:
+		return slice_iterator("tuple", value.Elements), nil
+	case *object.String:
+		chars := []object.Object{}
+		for _, char := range value.Value {
+			chars = append(chars, &object.String{Value: string(char)})
+		}
+		return slice_iterator("string", chars), nil
+	case *object.Range:
+		current, last, more := value.Bounds()
+		return &object.Iterator{
+			Name: "range",
+			Next: func() (object.Object, bool) {
+				if !more {
+					return nil, false
+				}
+				more = current < last
+				current++
+				return object.NewInteger(current - 1), true
+			},
+			Close: func() {},
+		}, nil
+	}
+
+	if next, ok := iteration_method(value, "next"); ok {
+		return &object.Iterator{
+			Name: describe_type(value),
+			Next: func() (object.Object, bool) {
+				element := next()
+				if element == object.NULL {
+					return nil, false
+				}
+				return element, true
+			},
+			Close: func() {},
+		}, nil
+	}
+
+	if iter, ok := iteration_method(value, "iter"); ok {
+		result := iter()
+		if is_runtime_error(result) {
+			return nil, result.(*object.Error)
+		}
+		if result == value {
+			return nil, object.NewError("iter() of %s must return another iterable", describe_type(value))
+		}
+		return iterate(result)
+	}
+
+	return nil, object.NewError("object is not iterable: %T", value)
+}
+
+// slice_iterator iterates over values that are already in memory
+func slice_iterator(name string, elements []object.Object) *object.Iterator {
+	i := 0
+	return &object.Iterator{
+		Name: name,
+		Next: func() (object.Object, bool) {
+			if i >= len(elements) {
+				return nil, false
+			}
+			i++
+			return elements[i-1], true
+		},
+		Close: func() {},
+	}
+}
+
+// iteration_method finds the next or iter method of a map or struct instance, ready to call without arguments.
+// A map method, possibly inherited, runs with the map as self like an operator method; a function
+// stored under the key is called as it is.
+func iteration_method(value object.Object, name string) (func() object.Object, bool) {
+	switch value := value.(type) {
+	case *object.Map:
+		if fn, home := lookup_method(value, name); fn != nil {
+			method := bind_super(fn, value, home)
+			return func() object.Object { return call_function(method, []object.Object{value}, nil) }, true
+		}
+		pair, ok := lookup_pair(value, name)
+		if !ok {
+			return nil, false
+		}
+		switch pair.Value.(type) {
+		case *object.Function, *object.Builtin:
+			return func() object.Object { return apply_function(pair.Value, nil, nil) }, true
+		}
+	case *object.StructInstance:
+		if method, ok := value.Struct.Methods[name].(*object.Function); ok {
+			return func() object.Object { return call_function(bind_self(method, value), nil, nil) }, true
+		}
+	}
+	return nil, false
+}
+
+// is_protocol_iterable reports whether a map or struct instance has a next or iter method
+func is_protocol_iterable(value object.Object) bool {
+	_, has_next := iteration_method(value, "next")
+	_, has_iter := iteration_method(value, "iter")
+	return has_next || has_iter
+}
+
+// collect drains an iterator into an array
+func collect(it *object.Iterator) object.Object {
+	elements := []object.Object{}
+	for {
+		element, ok := it.Next()
+		if !ok {
+			return &object.Array{Elements: elements}
+		}
+		if is_runtime_error(element) {
+			return element
+		}
+		elements = append(elements, element)
+	}
+}
+
+// eval_for_iterator runs a for loop that pulls one value per pass, so lazy sources are never loaded whole.
+// Iterators only the loop can reach, because it created them or its header called the function that
+// did, are closed when it stops early; ones held in a variable can be resumed. An iterator that runs
+// out is left alone, since a derived one like lines.take(3) finishes before the source it reads.
+func eval_for_iterator(node *ast.ForStatement, iterable object.Object, loop_env *object.Environment) object.Object {
+	it, err := iterate(iterable)
+	if err != nil {
+		return err
+	}
+	finished := false
+	if object.Object(it) != iterable || is_call(node.Iterable) {
+		defer func() {
+			if !finished {
+				it.Close()
+			}
+		}()
+	}
+
+	var result object.Object = object.NULL
+	for i := 0; ; i++ {
+		element, ok := it.Next()
+		if !ok {
+			finished = true
+			return result
+		}
+		if is_runtime_error(element) {
+			return element
+		}
+
+		// Set index variable if present (the iteration count)
+		if node.Index != nil {
+			loop_env.Set(node.Index.Value, &object.Number{Value: float64(i)})
+		}
+		if err := bind_loop_variable(node, loop_env, element); err != nil {
+			return err
+		}
+
+		var done bool
+		result, done = loop_exit(Eval(node.Body, loop_env), node.Label)
+		if done {
+			return result
+		}
+	}
+}
+
+// is_call reports whether expression is a call, whose result usually only the loop holds
+func is_call(expression ast.Expression) bool {
+	switch expression.(type) {
+	case *ast.CallExpression, *ast.PipelineExpression:
+		return true
+	}
+	return false
+}
+
+// Iterator Methods
+
+func call_iterator_method(it *object.Iterator, method_name string, args []object.Object) object.Object {
+	switch method_name {
+	case "next":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Iterator.next. got=%d, want=0", len(args))
+		}
+		element, ok := it.Next()
+		if !ok {
+			return object.NULL
+		}
+		return element
+
+	case "close":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Iterator.close. got=%d, want=0", len(args))
+		}
+		it.Close()
+		return object.NULL
+
+	case "to_array":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Iterator.to_array. got=%d, want=0", len(args))
+		}
+		return collect(it)
+	}
+
+	if result, found := call_sequence_method(it, method_name, args); found {
+		return result
+	}
+
+	if collected_methods[method_name] {
+		elements := collect(it)
+		if is_runtime_error(elements) {
+			return elements
+		}
+		return call_array_method(elements.(*object.Array), method_name, args)
+	}
+	return object.NewError("method '%s' not found on Iterator", method_name)
+}
+
+// call_protocol_method runs an iterator method on a map or struct instance with a next or iter method
+func call_protocol_method(iterable object.Object, method_name string, args []object.Object) (object.Object, bool) {
+	if !sequence_methods[method_name] && !collected_methods[method_name] && method_name != "to_array" {
+		return nil, false
+	}
+
+	it, err := iterate(iterable)
+	if err != nil {
+		return err, true
+	}
+	return call_iterator_method(it, method_name, args), true
+}
+
+// line_iterator reads a file one line at a time, closing it once the lines run out or the iterator is abandoned
+func line_iterator(file *os.File, path string) *object.Iterator {
+	reader := bufio.NewReader(file)
+	done := false
+	finish := func() {
+		if !done {
+			done = true
+			file.Close()
+		}
+	}
+
+	it := &object.Iterator{
+		Name: "File.lines",
+		Next: func() (object.Object, bool) {
+			if done {
+				return nil, false
+			}
+			line, err := reader.ReadString('\n')
+			if err != nil && err != io.EOF {
+				finish()
+				// Unlike opening the file, a failed read stops whatever is iterating
+				failure := io_error(err, path)
+				failure.IsUserCreated = false
+				return failure, true
+			}
+			if err == io.EOF && line == "" {
+				finish()
+				return nil, false
+			}
+			return &object.String{Value: strings.TrimSuffix(line, "\n")}, true
+		},
+		Close: finish,
+	}
+	// A loop that drops the iterator part way still lets go of the file once it is collected
+	runtime.SetFinalizer(it, func(*object.Iterator) { finish() })
+	return it
+}
@@ -144,6 +144,29 @@ var data, err = JSON.parse("invalid json")
 	testBooleanObject(t, result, true)
 }
 
+func TestJSONParseErrorKind(t *testing.T) {
+	input := `
+var data, err = JSON.parse("invalid json")
+err.kind
+`
+	result := testEval(input)
+	testStringObject(t, result, "parse")
+}
+
+func TestJSONLargeIntegers(t *testing.T) {
+	input := `
+var data, err = JSON.parse("{\"id\": 12345678901234567890, \"ratio\": 0.5}")
+JSON.stringify(data)
+`
+	testStringObject(t, testEval(input), `{"id":12345678901234567890,"ratio":0.5}`)
+
+	input = `
+var data, err = JSON.parse("[1] x")
+!isNull(err)
+`
+	testBooleanObject(t, testEval(input), true)
+}
+
 func TestJSONStringifyNumber(t *testing.T) {
 	input := `JSON.stringify(42)`
 	result := testEval(input)
@@ -232,12 +255,31 @@ func TestJSONStringifyObject(t *testing.T) {
 		t.Fatalf("result is not String. got=%T (%+v)", result, result)
 	}
 
-	// JSON object keys may be in any order, so just check it contains the keys
-	if !contains(str.Value, `"name":"Alice"`) || !contains(str.Value, `"age":30`) {
+	// Keys are written in the order the map was built
+	if str.Value != `{"name":"Alice","age":30}` {
 		t.Errorf("wrong JSON. got=%s", str.Value)
 	}
 }
 
+func TestJSONKeyOrder(t *testing.T) {
+	input := `
+var data, err = JSON.parse("{\"zeta\": 1, \"alpha\": {\"y\": 2, \"b\": 3}, \"mid\": [{\"q\": 1, \"a\": 2}]}")
+data["beta"] = true
+JSON.stringify(data)
+`
+	testStringObject(t, testEval(input), `{"zeta":1,"alpha":{"y":2,"b":3},"mid":[{"q":1,"a":2}],"beta":true}`)
+
+	// Struct instances follow the order their fields are declared in
+	input = `
+struct Point ::
+  y: number
+  x: number
+end
+JSON.stringify(Point(y: 1, x: 2))
+`
+	testStringObject(t, testEval(input), `{"y":1,"x":2}`)
+}
+
 func TestJSONStringifyNestedObject(t *testing.T) {
 	input := `JSON.stringify({"user": {"name": "Bob", "age": 25}})`
 	result := testEval(input)
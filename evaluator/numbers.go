@@ -0,0 +1,387 @@
+package evaluator
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// max_power_bits bounds the size of an exact integer power; larger ones are computed as floats
+const max_power_bits = 1 << 20
+
+// parse_number reads the text of a number: whole numbers become exact integers, growing past 64 bits
+// when they need to, and anything with a fraction or exponent becomes a float
+func parse_number(text string) (*object.Number, bool) {
+	if !strings.ContainsAny(text, ".eE") {
+		if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return object.NewInteger(value), true
+		}
+		if value, ok := new(big.Int).SetString(text, 10); ok {
+			return object.NewBigInteger(value), true
+		}
+		return nil, false
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return nil, false
+	}
+	return &object.Number{Value: value}, true
+}
+
+func eval_number_infix_expression(operator string, left, right object.Object) object.Object {
+	left_num := left.(*object.Number)
+	right_num := right.(*object.Number)
+
+	switch operator {
+	case "&", "|", "~", "<<", ">>":
+		return eval_bitwise_infix_expression(operator, left_num, right_num)
+	}
+
+	// Decimals stay exact with anything that has an exact value, integers with each other
+	if left_num.Kind == object.DECIMAL || right_num.Kind == object.DECIMAL {
+		left_rat, left_ok := left_num.Rat()
+		right_rat, right_ok := right_num.Rat()
+		if left_ok && right_ok {
+			return eval_decimal_infix_expression(operator, left_rat, right_rat)
+		}
+	} else if left_num.IsInteger() && right_num.IsInteger() {
+		return eval_integer_infix_expression(operator, left_num, right_num)
+	}
+	return eval_float_infix_expression(operator, left_num.Value, right_num.Value)
+}
+
+func eval_float_infix_expression(operator string, left_val, right_val float64) object.Object {
+	switch operator {
+	case "+":
+		return &object.Number{Value: left_val + right_val}
+	case "-":
+		return &object.Number{Value: left_val - right_val}
+	case "*":
+		return &object.Number{Value: left_val * right_val}
+	case "/":
+		if right_val == 0 {
+			return object.NewError("division by zero")
+		}
+		return &object.Number{Value: left_val / right_val}
+	case "//":
+		if right_val == 0 {
+			return object.NewError("division by zero")
+		}
+		return &object.Number{Value: math.Floor(left_val / right_val)}
+	case "%":
+		if right_val == 0 {
+			return object.NewError("division by zero")
+		}
+		return &object.Number{Value: math.Mod(left_val, right_val)}
+	case "^":
+		return &object.Number{Value: math.Pow(left_val, right_val)}
+	case "<":
+		return native_bool(left_val < right_val)
+	case ">":
+		return native_bool(left_val > right_val)
+	case "<=":
+		return native_bool(left_val <= right_val)
+	case ">=":
+		return native_bool(left_val >= right_val)
+	case "==":
+		return native_bool(left_val == right_val)
+	case "!=":
+		return native_bool(left_val != right_val)
+	default:
+		return object.NewError("unknown operator: %s", operator)
+	}
+}
+
+// eval_integer_infix_expression works in 64 bits while results fit and moves to big integers when they overflow
+func eval_integer_infix_expression(operator string, left, right *object.Number) object.Object {
+	left_int, left_small := left.Int64()
+	right_int, right_small := right.Int64()
+	if left_small && right_small {
+		switch operator {
+		case "+":
+			if sum := left_int + right_int; (sum > left_int) == (right_int > 0) {
+				return object.NewInteger(sum)
+			}
+		case "-":
+			if difference := left_int - right_int; (difference < left_int) == (right_int > 0) {
+				return object.NewInteger(difference)
+			}
+		case "*":
+			if left_int == 0 || right_int == 0 {
+				return object.NewInteger(0)
+			}
+			if product := left_int * right_int; product/right_int == left_int && !(left_int == -1 && right_int == math.MinInt64) && !(right_int == -1 && left_int == math.MinInt64) {
+				return object.NewInteger(product)
+			}
+		case "/":
+			if right_int != 0 && left_int%right_int == 0 && !(left_int == math.MinInt64 && right_int == -1) {
+				return object.NewInteger(left_int / right_int)
+			}
+		case "%":
+			if right_int != 0 {
+				return object.NewInteger(left_int % right_int)
+			}
+		case "<":
+			return native_bool(left_int < right_int)
+		case ">":
+			return native_bool(left_int > right_int)
+		case "<=":
+			return native_bool(left_int <= right_int)
+		case ">=":
+			return native_bool(left_int >= right_int)
+		case "==":
+			return native_bool(left_int == right_int)
+		case "!=":
+			return native_bool(left_int != right_int)
+		}
+	}
+
+	left_val, right_val := left.BigInt(), right.BigInt()
+	switch operator {
+	case "+":
+		return object.NewBigInteger(new(big.Int).Add(left_val, right_val))
+	case "-":
+		return object.NewBigInteger(new(big.Int).Sub(left_val, right_val))
+	case "*":
+		return object.NewBigInteger(new(big.Int).Mul(left_val, right_val))
+	case "/":
+		if right_val.Sign() == 0 {
+			return object.NewError("division by zero")
+		}
+		// Exact quotients stay integers, the rest become floats
+		quotient, remainder := new(big.Int).QuoRem(left_val, right_val, new(big.Int))
+		if remainder.Sign() == 0 {
+			return object.NewBigInteger(quotient)
+		}
+		value, _ := new(big.Rat).SetFrac(left_val, right_val).Float64()
+		return &object.Number{Value: value}
+	case "//":
+		if right_val.Sign() == 0 {
+			return object.NewError("division by zero")
+		}
+		return object.NewBigInteger(floor_divide(left_val, right_val))
+	case "%":
+		if right_val.Sign() == 0 {
+			return object.NewError("division by zero")
+		}
+		return object.NewBigInteger(new(big.Int).Rem(left_val, right_val))
+	case "^":
+		if right_val.Sign() < 0 || !right_val.IsInt64() || left_val.BitLen()*int(min(right_val.Int64(), max_power_bits)) > max_power_bits {
+			return &object.Number{Value: math.Pow(left.Value, right.Value)}
+		}
+		return object.NewBigInteger(new(big.Int).Exp(left_val, right_val, nil))
+	case "<", ">", "<=", ">=", "==", "!=":
+		return compare_result(operator, left_val.Cmp(right_val))
+	default:
+		return object.NewError("unknown operator: %s", operator)
+	}
+}
+
+func eval_decimal_infix_expression(operator string, left_val, right_val *big.Rat) object.Object {
+	switch operator {
+	case "+":
+		return object.NewDecimal(new(big.Rat).Add(left_val, right_val))
+	case "-":
+		return object.NewDecimal(new(big.Rat).Sub(left_val, right_val))
+	case "*":
+		return object.NewDecimal(new(big.Rat).Mul(left_val, right_val))
+	case "/":
+		if right_val.Sign() == 0 {
+			return object.NewError("division by zero")
+		}
+		return object.NewDecimal(new(big.Rat).Quo(left_val, right_val))
+	case "//":
+		if right_val.Sign() == 0 {
+			return object.NewError("division by zero")
+		}
+		return object.NewBigInteger(floor_rat(new(big.Rat).Quo(left_val, right_val)))
+	case "%":
+		if right_val.Sign() == 0 {
+			return object.NewError("division by zero")
+		}
+		// The remainder keeps the sign of the left side, as it does for integers
+		quotient := new(big.Rat).Quo(left_val, right_val)
+		truncated := new(big.Int).Quo(quotient.Num(), quotient.Denom())
+		return object.NewDecimal(new(big.Rat).Sub(left_val, new(big.Rat).Mul(right_val, new(big.Rat).SetInt(truncated))))
+	case "^":
+		if !right_val.IsInt() || right_val.Sign() < 0 || !right_val.Num().IsInt64() || right_val.Num().Int64() > max_power_bits {
+			left_float, _ := left_val.Float64()
+			right_float, _ := right_val.Float64()
+			return &object.Number{Value: math.Pow(left_float, right_float)}
+		}
+		numerator := new(big.Int).Exp(left_val.Num(), right_val.Num(), nil)
+		denominator := new(big.Int).Exp(left_val.Denom(), right_val.Num(), nil)
+		return object.NewDecimal(new(big.Rat).SetFrac(numerator, denominator))
+	case "<", ">", "<=", ">=", "==", "!=":
+		return compare_result(operator, left_val.Cmp(right_val))
+	default:
+		return object.NewError("unknown operator: %s", operator)
+	}
+}
+
+// eval_bitwise_infix_expression applies &, |, ~ (xor) and the shifts to integers, treating negative ones as two's complement
+func eval_bitwise_infix_expression(operator string, left, right *object.Number) object.Object {
+	for _, operand := range []*object.Number{left, right} {
+		if !operand.IsInteger() {
+			return object.NewError("bitwise operator %s needs integers, got %s", operator, operand.Inspect())
+		}
+	}
+
+	left_int, left_small := left.Int64()
+	right_int, right_small := right.Int64()
+	if left_small && right_small {
+		switch operator {
+		case "&":
+			return object.NewInteger(left_int & right_int)
+		case "|":
+			return object.NewInteger(left_int | right_int)
+		case "~":
+			return object.NewInteger(left_int ^ right_int)
+		}
+	}
+
+	left_val, right_val := left.BigInt(), right.BigInt()
+	switch operator {
+	case "&":
+		return object.NewBigInteger(new(big.Int).And(left_val, right_val))
+	case "|":
+		return object.NewBigInteger(new(big.Int).Or(left_val, right_val))
+	case "~":
+		return object.NewBigInteger(new(big.Int).Xor(left_val, right_val))
+	}
+
+	if right_val.Sign() < 0 {
+		return object.NewError("negative shift count: %s", right.Inspect())
+	}
+	if operator == ">>" {
+		// Shifting right rounds down, so negative numbers stay negative
+		if !right_val.IsInt64() || right_val.Int64() > int64(left_val.BitLen()) {
+			return object.NewInteger(int64(left_val.Sign() >> 1))
+		}
+		return object.NewBigInteger(new(big.Int).Rsh(left_val, uint(right_val.Int64())))
+	}
+	if !right_val.IsInt64() || right_val.Int64() > max_power_bits {
+		return object.NewError("shift count too large: %s", right.Inspect())
+	}
+	return object.NewBigInteger(new(big.Int).Lsh(left_val, uint(right_val.Int64())))
+}
+
+// compare_result turns the result of a three-way comparison into the boolean a comparison operator gives
+func compare_result(operator string, comparison int) object.Object {
+	switch operator {
+	case "<":
+		return native_bool(comparison < 0)
+	case ">":
+		return native_bool(comparison > 0)
+	case "<=":
+		return native_bool(comparison <= 0)
+	case ">=":
+		return native_bool(comparison >= 0)
+	case "==":
+		return native_bool(comparison == 0)
+	default:
+		return native_bool(comparison != 0)
+	}
+}
+
+// floor_divide divides rounding towards negative infinity, where big.Int's Quo truncates and Div is Euclidean
+func floor_divide(left, right *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(left, right, new(big.Int))
+	if remainder.Sign() != 0 && remainder.Sign() != right.Sign() {
+		quotient.Sub(quotient, big.NewInt(1))
+	}
+	return quotient
+}
+
+// floor_rat returns the largest integer not above value
+func floor_rat(value *big.Rat) *big.Int {
+	return floor_divide(value.Num(), value.Denom())
+}
+
+// numbers_equal compares exact numbers exactly and allows a small tolerance when either is a float
+func numbers_equal(left, right *object.Number) bool {
+	if left.Kind == object.FLOAT || right.Kind == object.FLOAT {
+		epsilon := 1e-8 // Tolerance for floating-point comparison (10 nanounits)
+		return math.Abs(left.Value-right.Value) < epsilon
+	}
+	return eval_number_infix_expression("==", left, right) == object.TRUE
+}
+
+// negate_number returns -value, keeping it exact
+func negate_number(value *object.Number) *object.Number {
+	switch value.Kind {
+	case object.INTEGER:
+		if value.Int != math.MinInt64 {
+			return object.NewInteger(-value.Int)
+		}
+		return object.NewBigInteger(new(big.Int).Neg(value.BigInt()))
+	case object.BIG_INTEGER:
+		return object.NewBigInteger(new(big.Int).Neg(value.Big))
+	case object.DECIMAL:
+		return object.NewDecimal(new(big.Rat).Neg(value.Decimal))
+	}
+	return &object.Number{Value: -value.Value}
+}
+
+// complement_number returns ~value, which is -value - 1 in two's complement
+func complement_number(value *object.Number) object.Object {
+	if !value.IsInteger() {
+		return object.NewError("bitwise operator ~ needs integers, got %s", value.Inspect())
+	}
+	if small, ok := value.Int64(); ok {
+		return object.NewInteger(^small)
+	}
+	return object.NewBigInteger(new(big.Int).Not(value.BigInt()))
+}
+
+// round_number rounds a number to an integer: floor and ceil round down and up, round goes to the
+// nearest one with halves away from zero. Integers are returned as they are.
+func round_number(value *object.Number, method string) *object.Number {
+	if value.Kind == object.DECIMAL {
+		switch method {
+		case "floor":
+			return object.NewBigInteger(floor_rat(value.Decimal))
+		case "ceil":
+			return object.NewBigInteger(new(big.Int).Neg(floor_rat(new(big.Rat).Neg(value.Decimal))))
+		}
+		rounded, _ := new(big.Int).SetString(value.Decimal.FloatString(0), 10)
+		return object.NewBigInteger(rounded)
+	}
+	if value.Kind != object.FLOAT {
+		return value
+	}
+
+	switch method {
+	case "floor":
+		return &object.Number{Value: math.Floor(value.Value)}
+	case "ceil":
+		return &object.Number{Value: math.Ceil(value.Value)}
+	}
+	return &object.Number{Value: math.Round(value.Value)}
+}
+
+// decimal_builtin makes an exact decimal from a number or a string like "19.99"
+func decimal_builtin(args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments for decimal. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Number:
+		value, ok := arg.Rat()
+		if !ok {
+			return object.NewError("cannot convert %s to a decimal", arg.Inspect())
+		}
+		return object.NewDecimal(value)
+	case *object.String:
+		value, ok := new(big.Rat).SetString(strings.TrimSpace(arg.Value))
+		if !ok || strings.Contains(arg.Value, "/") {
+			return object.NewError("invalid decimal: %q", arg.Value)
+		}
+		return object.NewDecimal(value)
+	}
+	return object.NewError("argument to decimal must be NUMBER or STRING, got %s", args[0].Type())
+}
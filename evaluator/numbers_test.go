@@ -0,0 +1,163 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// Number Tests
+
+func TestExactIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12345678901", "12345678901"},
+		{"9007199254740993", "9007199254740993"},
+		{"9007199254740992 + 1", "9007199254740993"},
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"2 ^ 100", "1267650600228229401496703205376"},
+		{"(2 ^ 64) / (2 ^ 32)", "4294967296"},
+		{"(2 ^ 64) - (2 ^ 64) + 5", "5"},
+		{"123456789012345678901234567890 % 7", "0"},
+		{"123456789012345678901234567891 % 7", "1"},
+		{"-(9223372036854775807 + 1)", "-9223372036854775808"},
+		{"6 / 3", "2"},
+		{"7 / 2", "3.5"},
+		{"7 // 2", "3"},
+		{"-7 // 2", "-4"},
+		{"7.5 // 2", "3"},
+		{"-7 % 3", "-1"},
+		{"5.5 % 2", "1.5"},
+		{"2 ^ -1", "0.5"},
+		{"var x = 17\nx //= 5\nx", "3"},
+		{"(12345678901).to_string()", `"12345678901"`},
+		{`"#{2 ^ 70}"`, `"1180591620717411303424"`},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestIntegerComparison(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"9007199254740993 == 9007199254740992", false},
+		{"9007199254740993 > 9007199254740992", true},
+		{"12345678901234567890 == 12345678901234567890", true},
+		{"12345678901234567890 < 12345678901234567891", true},
+		{"2 ^ 64 > 2 ^ 63", true},
+		{"3 == 3.0", true},
+	}
+
+	for _, tt := range tests {
+		testBooleanObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestDecimals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`decimal("0.1") + decimal("0.2")`, "0.3"},
+		{`decimal("19.99") * 3`, "59.97"},
+		{`decimal("0.1") + 0.2`, "0.3"},
+		{`decimal(1) / 3`, "0.3333333333333333333333333333"},
+		{`decimal("10") / 4`, "2.5"},
+		{`decimal("7.5") // 2`, "3"},
+		{`decimal("-7.5") % 2`, "-1.5"},
+		{`decimal("1.5") ^ 2`, "2.25"},
+		{`decimal("2.5").round()`, "3"},
+		{`decimal("-2.5").floor()`, "-3"},
+		{`decimal("2.1").ceil()`, "3"},
+		{`decimal("-0.5").abs()`, "0.5"},
+		{`decimal("0.1") == 0.1`, "true"},
+		{`decimal("0.3") > decimal("0.25")`, "true"},
+		{`decimal("2.0").is_integer()`, "true"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+
+	errors := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`decimal("abc")`, `invalid decimal: "abc"`},
+		{`decimal(true)`, "argument to decimal must be NUMBER or STRING, got BOOLEAN"},
+		{`decimal(1) / 0`, "division by zero"},
+		{"7 // 0", "division by zero"},
+	}
+
+	for _, tt := range errors {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestBitwiseOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"12 & 10", "8"},
+		{"12 | 10", "14"},
+		{"12 ~ 10", "6"},
+		{"~5", "-6"},
+		{"~-1", "0"},
+		{"1 << 10", "1024"},
+		{"1024 >> 3", "128"},
+		{"-16 >> 2", "-4"},
+		{"-1 >> 100", "-1"},
+		{"5 >> 100", "0"},
+		{"1 << 70", "1180591620717411303424"},
+		{"(1 << 70) >> 68", "4"},
+		{"(1 << 70 | 1) & 3", "1"},
+		{"~(1 << 70)", "-1180591620717411303425"},
+		{"4.0 | 1", "5"},
+		{"var flags = 4\nflags |= 1\nflags &= ~4\nflags <<= 3\nflags ~= 1\nflags >>= 1\nflags", "4"},
+		{"case 3 ::\n  1 | 2 => \"low\"\n  (1 | 2) => \"three\"\n  _ => \"other\"\nend", `"three"`},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+
+	errors := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"1.5 & 1", "bitwise operator & needs integers, got 1.5"},
+		{"1 << 0.5", "bitwise operator << needs integers, got 0.5"},
+		{"~2.5", "bitwise operator ~ needs integers, got 2.5"},
+		{`decimal("1.5") | 1`, "bitwise operator | needs integers, got 1.5"},
+		{"1 << -1", "negative shift count: -1"},
+		{`~"a"`, "unknown operator: ~STRING"},
+		{`"a" & "b"`, "unknown operator: STRING & STRING"},
+	}
+
+	for _, tt := range errors {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -26,6 +26,16 @@ func call_object_method(receiver object.Object, method_name string, args []objec
 		return call_error_method(obj, method_name, args)
 	case *object.Time:
 		return call_time_method(obj, method_name, args)
+	case *object.StructInstance:
+		return call_struct_method(obj, method_name, args)
+	case *object.Iterator:
+		return call_iterator_method(obj, method_name, args)
+	case *object.Set:
+		return call_set_method(obj, method_name, args)
+	case *object.Tuple:
+		return call_tuple_method(obj, method_name, args)
+	case *object.Super:
+		return call_super_method(obj, method_name, args)
 	default:
 		return object.NewError("method '%s' not found on %s", method_name, receiver.Type())
 	}
@@ -792,9 +802,11 @@ func call_array_method(arr *object.Array, method_name string, args []object.Obje
 		// Bubble sort for simplicity
 		for i := 0; i < len(sorted); i++ {
 			for j := i + 1; j < len(sorted); j++ {
-				num1, ok1 := sorted[i].(*object.Number)
-				num2, ok2 := sorted[j].(*object.Number)
-				if ok1 && ok2 && num1.Value > num2.Value {
+				after, err := sorts_after(sorted[i], sorted[j])
+				if err != nil {
+					return err
+				}
+				if after {
 					sorted[i], sorted[j] = sorted[j], sorted[i]
 				}
 			}
@@ -1032,9 +1044,8 @@ func call_array_method(arr *object.Array, method_name string, args []object.Obje
 			return object.NewError("wrong number of arguments for Array.contains. got=%d, want=1", len(args))
 		}
 
-		searchKey := args[0].String()
 		for _, elem := range arr.Elements {
-			if elem.String() == searchKey {
+			if is_equal(elem, args[0]) {
 				return object.TRUE
 			}
 		}
@@ -1045,9 +1056,8 @@ func call_array_method(arr *object.Array, method_name string, args []object.Obje
 			return object.NewError("wrong number of arguments for Array.index_of. got=%d, want=1", len(args))
 		}
 
-		searchKey := args[0].String()
 		for i, elem := range arr.Elements {
-			if elem.String() == searchKey {
+			if is_equal(elem, args[0]) {
 				return &object.Number{Value: float64(i)}
 			}
 		}
@@ -1058,10 +1068,9 @@ func call_array_method(arr *object.Array, method_name string, args []object.Obje
 			return object.NewError("wrong number of arguments for Array.last_index_of. got=%d, want=1", len(args))
 		}
 
-		searchKey := args[0].String()
 		lastIdx := -1
 		for i, elem := range arr.Elements {
-			if elem.String() == searchKey {
+			if is_equal(elem, args[0]) {
 				lastIdx = i
 			}
 		}
@@ -1298,7 +1307,7 @@ func check_custom_property(properties map[string]object.Object, method_name stri
 	// If it's a function, call it with the receiver instance as first argument (self)
 	if fn, ok := prop.(*object.Function); ok {
 		method_args := append([]object.Object{receiver}, args...)
-		return apply_function_from_method(fn, method_args), true
+		return call_function(fn, method_args, nil), true
 	}
 
 	// If it's not a function, just return the property value (for zero-arg access)
@@ -1316,18 +1325,23 @@ func check_type_registry(registry *object.Map, method_name string, receiver obje
 		return nil, false
 	}
 
-	pair, ok := registry.Pairs[method_name]
+	// Functions assigned with Array.name = fn ... are kept as custom properties of the registry
+	method, ok := registry.Properties[method_name]
 	if !ok {
-		return nil, false
+		pair, ok := registry.Get(method_name)
+		if !ok {
+			return nil, false
+		}
+		method = pair.Value
 	}
 
 	// The user-defined method should receive the receiver as first argument
 	method_args := append([]object.Object{receiver}, args...)
 
 	// Call the function
-	switch function := pair.Value.(type) {
+	switch function := method.(type) {
 	case *object.Function:
-		return apply_function_from_method(function, method_args), true
+		return call_function(function, method_args, nil), true
 	case *object.Builtin:
 		return function.Fn(method_args...), true
 	default:
@@ -1337,22 +1351,30 @@ func check_type_registry(registry *object.Map, method_name string, receiver obje
 
 // apply_function_from_method is a helper to apply user-defined functions as methods
 // This is needed because we can't import from evaluator due to circular dependency
+// Arguments are bound leniently, since callbacks like those of map and filter are handed more than they may
+// declare. Methods the user calls, even through self, go through call_function instead.
 func apply_function_from_method(fn *object.Function, args []object.Object) object.Object {
-	env := object.NewEnclosedEnvironment(fn.Env)
-
-	for param_idx, param := range fn.Parameters {
-		if param_idx < len(args) {
-			env.Set(param.Name.Value, args[param_idx])
-		}
+	env, err := extend_function_env(fn, args, nil, true)
+	if err != nil {
+		return err
+	}
+	if fn.Generator {
+		return new_generator(fn, env)
 	}
 
 	// We need to evaluate the function body
 	// But we can't call Eval directly due to circular import
 	// So we'll use a workaround by storing a reference to the evaluator
 	if eval_func != nil {
+		frame := push_call(fn)
 		evaluated := eval_func(fn.Body, env)
+		pop_call(frame)
 		if returnValue, ok := evaluated.(*object.ReturnValue); ok {
-			return returnValue.Value
+			evaluated = returnValue.Value
+		}
+		evaluated = stray_loop_exit(evaluated)
+		if err := check_return_type(fn, evaluated); err != nil {
+			return err
 		}
 		return evaluated
 	}
@@ -1402,31 +1424,31 @@ func call_number_method(num *object.Number, method_name string, args []object.Ob
 		if len(args) != 0 {
 			return object.NewError("wrong number of arguments for Number.to_string. got=%d, want=0", len(args))
 		}
-		return &object.String{Value: fmt.Sprintf("%.10g", num.Value)}
+		return &object.String{Value: num.Inspect()}
 
 	case "abs":
 		if len(args) != 0 {
 			return object.NewError("wrong number of arguments for Number.abs. got=%d, want=0", len(args))
 		}
-		return &object.Number{Value: math.Abs(num.Value)}
-
-	case "floor":
-		if len(args) != 0 {
-			return object.NewError("wrong number of arguments for Number.floor. got=%d, want=0", len(args))
+		if num.Value < 0 || num.Kind == object.DECIMAL && num.Decimal.Sign() < 0 {
+			return negate_number(num)
 		}
-		return &object.Number{Value: math.Floor(num.Value)}
+		return num
 
-	case "ceil":
+	case "floor", "ceil", "round":
 		if len(args) != 0 {
-			return object.NewError("wrong number of arguments for Number.ceil. got=%d, want=0", len(args))
+			return object.NewError("wrong number of arguments for Number.%s. got=%d, want=0", method_name, len(args))
 		}
-		return &object.Number{Value: math.Ceil(num.Value)}
+		return round_number(num, method_name)
 
-	case "round":
+	case "is_integer":
 		if len(args) != 0 {
-			return object.NewError("wrong number of arguments for Number.round. got=%d, want=0", len(args))
+			return object.NewError("wrong number of arguments for Number.is_integer. got=%d, want=0", len(args))
+		}
+		if num.Kind == object.DECIMAL {
+			return native_bool(num.Decimal.IsInt())
 		}
-		return &object.Number{Value: math.Round(num.Value)}
+		return native_bool(num.Value == math.Trunc(num.Value))
 
 	case "sqrt":
 		if len(args) != 0 {
@@ -1454,8 +1476,8 @@ func call_number_method(num *object.Number, method_name string, args []object.Ob
 // Map Methods
 
 func call_map_method(map_obj *object.Map, method_name string, args []object.Object) object.Object {
-	// Check for instance-specific custom properties
-	if result, found := check_custom_property(map_obj.Properties, method_name, map_obj, args); found {
+	// Check for instance-specific custom properties, then the ones inherited from prototypes
+	if result, found := call_inherited_property(map_obj, method_name, map_obj, args); found {
 		return result
 	}
 
@@ -1464,9 +1486,246 @@ func call_map_method(map_obj *object.Map, method_name string, args []object.Obje
 		return result
 	}
 
+	// Maps with a next or iter method take the iterator methods over the values they produce
+	if is_protocol_iterable(map_obj) {
+		if result, found := call_protocol_method(map_obj, method_name, args); found {
+			return result
+		}
+	}
+
+	switch method_name {
+	case "length":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Map.length. got=%d, want=0", len(args))
+		}
+		return &object.Number{Value: float64(map_obj.Len())}
+
+	case "is_empty":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Map.is_empty. got=%d, want=0", len(args))
+		}
+		return native_bool(map_obj.Len() == 0)
+
+	case "keys", "values", "entries", "to_array":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Map.%s. got=%d, want=0", method_name, len(args))
+		}
+		result := []object.Object{}
+		for _, pair := range map_obj.Entries() {
+			switch method_name {
+			case "keys":
+				result = append(result, pair.Key)
+			case "values":
+				result = append(result, pair.Value)
+			default:
+				// Entries are [key, value] arrays, ready for for [k, v] in ...
+				result = append(result, &object.Array{Elements: []object.Object{pair.Key, pair.Value}})
+			}
+		}
+		return &object.Array{Elements: result}
+
+	case "has":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Map.has. got=%d, want=1", len(args))
+		}
+		_, ok := map_obj.Get(map_key(args[0]))
+		return native_bool(ok)
+
+	case "get":
+		// The default is returned for missing keys, null when there is none
+		if len(args) < 1 || len(args) > 2 {
+			return object.NewError("wrong number of arguments for Map.get. got=%d, want=1 or 2", len(args))
+		}
+		if pair, ok := map_obj.Get(map_key(args[0])); ok {
+			return pair.Value
+		}
+		if len(args) == 2 {
+			return args[1]
+		}
+		return object.NULL
+
+	case "delete":
+		// Check immutability
+		if map_obj.IsImmutable {
+			return object.NewError("cannot call delete() on immutable map")
+		}
+
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Map.delete. got=%d, want=1", len(args))
+		}
+
+		// Returns the value that was removed, or null if the key was missing
+		key := map_key(args[0])
+		pair, ok := map_obj.Get(key)
+		if !ok {
+			return object.NULL
+		}
+		map_obj.Delete(key)
+		return pair.Value
+
+	case "clear":
+		// Check immutability
+		if map_obj.IsImmutable {
+			return object.NewError("cannot call clear() on immutable map")
+		}
+
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Map.clear. got=%d, want=0", len(args))
+		}
+		map_obj.Clear()
+		return map_obj
+
+	case "merge", "deep_merge":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Map.%s. got=%d, want=1", method_name, len(args))
+		}
+
+		other, ok := args[0].(*object.Map)
+		if !ok {
+			return object.NewError("argument to Map.%s must be MAP, got %s", method_name, args[0].Type())
+		}
+		return merge_maps(map_obj, other, method_name == "deep_merge")
+
+	case "filter":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Map.filter. got=%d, want=1", len(args))
+		}
+
+		fn, ok := args[0].(*object.Function)
+		if !ok {
+			return object.NewError("argument to Map.filter must be FUNCTION, got %s", args[0].Type())
+		}
+
+		result := object.NewMap()
+		for _, key := range map_obj.Keys() {
+			pair, _ := map_obj.Get(key)
+			condition := apply_function_from_method(fn, []object.Object{pair.Key, pair.Value})
+			if is_error(condition) {
+				return condition
+			}
+			if is_truthy(condition) {
+				result.Set(key, pair)
+			}
+		}
+		return result
+
+	case "map_values":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Map.map_values. got=%d, want=1", len(args))
+		}
+
+		fn, ok := args[0].(*object.Function)
+		if !ok {
+			return object.NewError("argument to Map.map_values must be FUNCTION, got %s", args[0].Type())
+		}
+
+		result := object.NewMap()
+		for _, key := range map_obj.Keys() {
+			pair, _ := map_obj.Get(key)
+			mapped := apply_function_from_method(fn, []object.Object{pair.Value})
+			if is_error(mapped) {
+				return mapped
+			}
+			result.Set(key, object.MapPair{Key: pair.Key, Value: mapped})
+		}
+		return result
+
+	case "invert":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Map.invert. got=%d, want=0", len(args))
+		}
+
+		// Values become keys; when two keys share a value the one added last wins
+		result := object.NewMap()
+		for _, pair := range map_obj.Entries() {
+			result.Set(map_key(pair.Value), object.MapPair{Key: pair.Value, Value: pair.Key})
+		}
+		return result
+
+	case "group_by":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Map.group_by. got=%d, want=1", len(args))
+		}
+
+		fn, ok := args[0].(*object.Function)
+		if !ok {
+			return object.NewError("argument to Map.group_by must be FUNCTION, got %s", args[0].Type())
+		}
+
+		// Each group is a map of the pairs that gave the same result
+		groups := object.NewMap()
+		for _, key := range map_obj.Keys() {
+			pair, _ := map_obj.Get(key)
+			group := apply_function_from_method(fn, []object.Object{pair.Key, pair.Value})
+			if is_error(group) {
+				return group
+			}
+			group_key := map_key(group)
+			existing, ok := groups.Get(group_key)
+			if !ok {
+				existing = object.MapPair{Key: group, Value: object.NewMap()}
+				groups.Set(group_key, existing)
+			}
+			existing.Value.(*object.Map).Set(key, pair)
+		}
+		return groups
+	}
+
 	return object.NewError("method '%s' not found on Map", method_name)
 }
 
+// map_methods are the built-in methods of call_map_method
+var map_methods = map[string]bool{
+	"length": true, "is_empty": true, "keys": true, "values": true, "entries": true, "to_array": true,
+	"has": true, "get": true, "delete": true, "clear": true, "merge": true, "deep_merge": true,
+	"filter": true, "map_values": true, "invert": true, "group_by": true,
+}
+
+// merge_maps returns a new map with the pairs of left, overwritten by those of right; keys only in right go last.
+// A deep merge combines nested maps found under the same key instead of replacing them.
+func merge_maps(left, right *object.Map, deep bool) *object.Map {
+	result := object.NewMap()
+	for _, key := range left.Keys() {
+		pair, _ := left.Get(key)
+		result.Set(key, pair)
+	}
+	for _, key := range right.Keys() {
+		pair, _ := right.Get(key)
+		if existing, ok := result.Get(key); ok && deep {
+			left_map, left_ok := existing.Value.(*object.Map)
+			right_map, right_ok := pair.Value.(*object.Map)
+			if left_ok && right_ok {
+				result.Set(key, object.MapPair{Key: pair.Key, Value: merge_maps(left_map, right_map, true)})
+				continue
+			}
+		}
+		result.Set(key, pair)
+	}
+	return result
+}
+
+// has_map_method reports whether a map has a built-in method, a custom method of its own or of a prototype,
+// or one registered on Map
+func has_map_method(map_obj *object.Map, method_name string) bool {
+	if map_methods[method_name] {
+		return true
+	}
+	for current := map_obj; current != nil; current = current.Proto {
+		if _, ok := current.Properties[method_name]; ok {
+			return true
+		}
+	}
+	if map_registry != nil {
+		if _, ok := map_registry.Properties[method_name]; ok {
+			return true
+		}
+		if _, ok := map_registry.Get(method_name); ok {
+			return true
+		}
+	}
+	return false
+}
+
 // Boolean Methods
 
 func call_boolean_method(bool *object.Boolean, method_name string, args []object.Object) object.Object {
@@ -1496,18 +1755,125 @@ func call_error_method(err *object.Error, method_name string, args []object.Obje
 		}
 		// Return just the error message without "ERROR: " prefix
 		return &object.String{Value: err.Message}
+	case "kind":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Error.kind. got=%d, want=0", len(args))
+		}
+		return &object.String{Value: err.ErrorKind()}
+	case "cause":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Error.cause. got=%d, want=0", len(args))
+		}
+		if err.Cause == nil {
+			return object.NULL
+		}
+		return err.Cause
+	case "data":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Error.data. got=%d, want=0", len(args))
+		}
+		if err.Data == nil {
+			return object.NewMap()
+		}
+		return err.Data
+	case "wrap":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Error.wrap. got=%d, want=1", len(args))
+		}
+		message, ok := args[0].(*object.String)
+		if !ok {
+			return object.NewError("argument to Error.wrap must be STRING, got %s", args[0].Type())
+		}
+		// The wrapped error is kept as a value so it can be inspected through .cause
+		cause := *err
+		cause.IsUserCreated = true
+		wrapped := new_user_error(err.ErrorKind(), message.Value, nil)
+		wrapped.Cause = &cause
+		return wrapped
+	case "is":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Error.is. got=%d, want=1", len(args))
+		}
+		kind, ok := args[0].(*object.String)
+		if !ok {
+			return object.NewError("argument to Error.is must be STRING, got %s", args[0].Type())
+		}
+		return native_bool(err.Is(kind.Value))
+	case "message":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Error.message. got=%d, want=0", len(args))
+		}
+		return &object.String{Value: err.Message}
+	case "file":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Error.file. got=%d, want=0", len(args))
+		}
+		return &object.String{Value: err.File}
+	case "line":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Error.line. got=%d, want=0", len(args))
+		}
+		return &object.Number{Value: float64(err.Line)}
+	case "traceback":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Error.traceback. got=%d, want=0", len(args))
+		}
+		return &object.String{Value: err.Traceback()}
 	}
 
 	return object.NewError("method '%s' not found on Error", method_name)
 }
 
+// Struct Methods
+
+func call_struct_method(instance *object.StructInstance, method_name string, args []object.Object) object.Object {
+	method, ok := instance.Struct.Methods[method_name]
+	if !ok {
+		// Instances with a next or iter method take the iterator methods over the values they produce
+		if is_protocol_iterable(instance) {
+			if result, found := call_protocol_method(instance, method_name, args); found {
+				return result
+			}
+		}
+		return object.NewError("method '%s' not found on %s", method_name, instance.Struct.Name)
+	}
+
+	fn, ok := method.(*object.Function)
+	if !ok {
+		return object.NewError("'%s' is not a function", method_name)
+	}
+
+	// Struct methods are called by the user directly, so their arguments are checked strictly
+	return call_function(bind_self(fn, instance), args, nil)
+}
+
+// bind_self returns a copy of fn whose environment has self bound to the receiver
+func bind_self(fn *object.Function, receiver object.Object) *object.Function {
+	return bind_name(fn, "self", receiver)
+}
+
+// bind_name returns a copy of fn whose environment has name bound to value
+func bind_name(fn *object.Function, name string, value object.Object) *object.Function {
+	env := object.NewEnclosedEnvironment(fn.Env)
+	env.Set(name, value)
+
+	return &object.Function{
+		Name:       fn.Name,
+		Parameters: fn.Parameters,
+		ReturnType: fn.ReturnType,
+		Body:       fn.Body,
+		Env:        env,
+		WhereBlock: fn.WhereBlock,
+		Generator:  fn.Generator,
+	}
+}
+
 // Global Functions (kept as builtin functions for print/println)
 
 var global_functions = map[string]*object.Builtin{
-	"print":   {Fn: print_builtin},
-	"println": {Fn: println_builtin},
 	"isNull":  {Fn: is_null_builtin},
 	"error":   {Fn: error_builtin},
+	"decimal": {Fn: decimal_builtin},
 }
 
 // get_global_function returns a global function by name
@@ -1520,6 +1886,9 @@ func print_builtin(args ...object.Object) object.Object {
 		if i > 0 {
 			fmt.Print(" ")
 		}
+		if str, found := custom_string(arg); found {
+			arg = str
+		}
 		fmt.Print(arg.String())
 	}
 	return object.NULL
@@ -1530,6 +1899,9 @@ func println_builtin(args ...object.Object) object.Object {
 		if i > 0 {
 			fmt.Print(" ")
 		}
+		if str, found := custom_string(arg); found {
+			arg = str
+		}
 		fmt.Print(arg.String())
 	}
 	fmt.Println()
@@ -1546,9 +1918,10 @@ func is_null_builtin(args ...object.Object) object.Object {
 	return object.FALSE
 }
 
+// error_builtin creates a user error: error(message), error(message, kind) or error(message, kind, data)
 func error_builtin(args ...object.Object) object.Object {
-	if len(args) != 1 {
-		return object.NewError("wrong number of arguments. got=%d, want=1", len(args))
+	if len(args) < 1 || len(args) > 3 {
+		return object.NewError("wrong number of arguments. got=%d, want=1 to 3", len(args))
 	}
 
 	// Convert argument to string for error message
@@ -1561,8 +1934,36 @@ func error_builtin(args ...object.Object) object.Object {
 	}
 
 	// Create a user-created error
-	err := object.NewError("%s", message)
-	err.IsUserCreated = true
+	err := new_user_error("error", message, nil)
+
+	if len(args) > 1 && args[1] != object.NULL {
+		kind, ok := args[1].(*object.String)
+		if !ok {
+			return object.NewError("second argument to error must be STRING, got %s", args[1].Type())
+		}
+		err.Kind = kind.Value
+	}
+
+	if len(args) > 2 && args[2] != object.NULL {
+		data, ok := args[2].(*object.Map)
+		if !ok {
+			return object.NewError("third argument to error must be MAP, got %s", args[2].Type())
+		}
+		err.Data = data
+	}
+
+	return err
+}
+
+// new_user_error creates an error value of the given kind with optional attached data
+func new_user_error(kind string, message string, data map[string]object.Object) *object.Error {
+	err := &object.Error{Message: message, IsUserCreated: true, Kind: kind}
+	if data != nil {
+		err.Data = object.NewMap()
+		for key, value := range data {
+			err.Data.Set(key, object.MapPair{Key: &object.String{Value: key}, Value: value})
+		}
+	}
 	return err
 }
 
@@ -712,3 +712,68 @@ func TestArrayMethodErrors(t *testing.T) {
 		}
 	}
 }
+
+// Map Methods Tests
+
+func TestMapMethods(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 2, "a": 1}.keys()`, `["b", "a"]`},
+		{`{"b": 2, "a": 1}.values()`, `[2, 1]`},
+		{`{"b": 2, "a": 1}.entries()`, `[["b", 2], ["a", 1]]`},
+		{`{"a": 1}.to_array()`, `[["a", 1]]`},
+		{`{"a": 1, "b": 2}.length()`, "2"},
+		{`{}.is_empty()`, "true"},
+		{`{"a": 1}.has("a")`, "true"},
+		{`{1: "one"}.has(1)`, "true"},
+		{`{"a": 1}.get("a", 0)`, "1"},
+		{`{"a": 1}.get("b", 0)`, "0"},
+		{`{"a": 1}.get("b")`, "null"},
+		{`var m = {"a": 1, "b": 2}` + "\nvar removed = m.delete(\"a\")\n[removed, m.keys()]", `[1, ["b"]]`},
+		{`var m = {"a": 1}` + "\nm.clear()\nm.length()", "0"},
+		{`{"a": 1, "b": 2}.merge({"c": 4, "b": 3})`, `{"a": 1, "b": 3, "c": 4}`},
+		{`{"a": {"x": 1}}.merge({"a": {"y": 2}})`, `{"a": {"y": 2}}`},
+		{`{"a": {"x": 1}}.deep_merge({"a": {"y": 2}})`, `{"a": {"x": 1, "y": 2}}`},
+		{`{"a": 1, "b": 2}.filter((key, value) => value > 1)`, `{"b": 2}`},
+		{`{"a": 1}.map_values(value => value * 10)`, `{"a": 10}`},
+		{`{"a": 1, "b": 2}.invert()[2]`, `"b"`},
+		{`{"a": 1, "b": 2, "c": 3}.group_by((key, value) => value % 2)`, `{1: {"a": 1, "c": 3}, 0: {"b": 2}}`},
+		{`{"a": 1, "b": 2}.invert()`, `{1: "a", 2: "b"}`},
+		{`var m = {"keys": 1}` + "\nm.keys", "1"},
+		// Calling a method a data key shares its name with still runs the method
+		{`{"length": 5}.length()`, "1"},
+		{`{"keys": ["x"]}.keys()`, `["keys"]`},
+		{`{"length": () => 5}.length()`, "5"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestMapMethodErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{`const m = {"a": 1}` + "\nm.delete(\"a\")", "cannot call delete() on immutable map"},
+		{`const m = {"a": 1}` + "\nm.clear()", "cannot call clear() on immutable map"},
+		{`{"a": 1}.get()`, "wrong number of arguments for Map.get. got=0, want=1 or 2"},
+		{`{"a": 1}.merge([1])`, "argument to Map.merge must be MAP, got ARRAY"},
+		{`{"a": 1}.filter(1)`, "argument to Map.filter must be FUNCTION, got NUMBER"},
+		{`{"a": 1}.missing()`, "method 'missing' not found on Map"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package evaluator
+
+import (
+	"strings"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// operator_methods names the method an infix operator calls on a map, array or struct instance
+var operator_methods = map[string]string{
+	"+":  "__add",
+	"-":  "__sub",
+	"*":  "__mul",
+	"/":  "__div",
+	"%":  "__mod",
+	"==": "__eq",
+	"<":  "__lt",
+	"<=": "__le",
+	">":  "__gt",
+	">=": "__ge",
+}
+
+// call_operator_method calls the method receiver defines under name: a struct method, a function
+// property, possibly inherited from a prototype, or a function in the Map or Array registry.
+// The receiver is passed as self.
+// found is false when the receiver has no such method.
+func call_operator_method(receiver object.Object, name string, args ...object.Object) (object.Object, bool) {
+	switch receiver := receiver.(type) {
+	case *object.StructInstance:
+		fn, ok := receiver.Struct.Methods[name].(*object.Function)
+		if !ok {
+			return nil, false
+		}
+		return call_function(bind_self(fn, receiver), args, nil), true
+	case *object.Map:
+		if fn, home := lookup_method(receiver, name); fn != nil {
+			method_args := append([]object.Object{receiver}, args...)
+			return call_function(bind_super(fn, receiver, home), method_args, nil), true
+		}
+		return check_type_registry(map_registry, name, receiver, args)
+	case *object.Array:
+		if _, ok := receiver.Properties[name].(*object.Function); ok {
+			return check_custom_property(receiver.Properties, name, receiver, args)
+		}
+		return check_type_registry(array_registry, name, receiver, args)
+	}
+	return nil, false
+}
+
+// eval_overloaded_operator applies an infix operator through the methods of the left operand.
+// == and != use the same equality hook as is_equal, and a comparison without its own method
+// is worked out from __lt and ==.
+func eval_overloaded_operator(operator string, left, right object.Object) (object.Object, bool) {
+	if operator == "==" || operator == "!=" {
+		result, found := call_equals_hook(left, right)
+		if !found || is_error(result) {
+			return result, found
+		}
+		return native_bool(is_truthy(result) == (operator == "==")), true
+	}
+
+	name, ok := operator_methods[operator]
+	if !ok {
+		return nil, false
+	}
+	if result, found := call_operator_method(left, name, right); found {
+		return result, true
+	}
+
+	switch operator {
+	case "<=", ">", ">=":
+		less, found := call_operator_method(left, "__lt", right)
+		if !found || is_error(less) {
+			return less, found
+		}
+		if operator == ">=" {
+			return native_bool(!is_truthy(less)), true
+		}
+		if is_truthy(less) {
+			return native_bool(operator == "<="), true
+		}
+		equal := eval_infix_expression("==", left, right)
+		if is_error(equal) {
+			return equal, true
+		}
+		return native_bool(is_truthy(equal) == (operator == "<=")), true
+	}
+	return nil, false
+}
+
+// custom_string calls the to_string method of a map, array or struct instance, so print and
+// string interpolation can show user types their own way. Collections without one are written
+// like Inspect does, with to_string used for the values they hold, so [Money(5, "EUR")] shows
+// the money the same way as Money(5, "EUR") alone. The result is a string or an error.
+func custom_string(obj object.Object) (object.Object, bool) {
+	result, found := call_operator_method(obj, "to_string")
+	if !found {
+		return nested_string(obj)
+	}
+	if is_error(result) {
+		return result, true
+	}
+	if _, ok := result.(*object.String); !ok {
+		return &object.String{Value: result.Inspect()}, true
+	}
+	return result, true
+}
+
+// nested_string writes an array, tuple, set, map or struct instance through custom_string of its values
+func nested_string(obj object.Object) (object.Object, bool) {
+	var parts []string
+	var err object.Object
+	open, close := "[", "]"
+	switch obj := obj.(type) {
+	case *object.Array:
+		parts, err = inspect_all(obj.Elements)
+	case *object.Tuple:
+		parts, err = inspect_all(obj.Elements)
+		open, close = "(", ")"
+		if len(parts) == 1 {
+			close = ",)"
+		}
+	case *object.Set:
+		if obj.Len() == 0 {
+			return nil, false
+		}
+		parts, err = inspect_all(obj.Values())
+		open, close = "{", "}"
+	case *object.Map:
+		for _, pair := range obj.Entries() {
+			var entry []string
+			if entry, err = inspect_all([]object.Object{pair.Key, pair.Value}); err != nil {
+				break
+			}
+			parts = append(parts, entry[0]+": "+entry[1])
+		}
+		open, close = "{", "}"
+	case *object.StructInstance:
+		if obj.Struct.IsUnitVariant() {
+			return nil, false
+		}
+		for _, field := range obj.Struct.Fields {
+			value, ok := obj.Fields[field.Name.Value]
+			if !ok {
+				value = object.NULL
+			}
+			var shown []string
+			if shown, err = inspect_all([]object.Object{value}); err != nil {
+				break
+			}
+			parts = append(parts, field.Name.Value+": "+shown[0])
+		}
+		open, close = obj.Struct.Name+"(", ")"
+	default:
+		return nil, false
+	}
+	if err != nil {
+		return err, true
+	}
+	return &object.String{Value: open + strings.Join(parts, ", ") + close}, true
+}
+
+// inspect_all writes values held in a collection: through custom_string when they have a to_string
+// method or hold values that do, and with Inspect otherwise
+func inspect_all(values []object.Object) ([]string, object.Object) {
+	parts := make([]string, len(values))
+	for i, value := range values {
+		str, found := custom_string(value)
+		if !found {
+			parts[i] = value.Inspect()
+			continue
+		}
+		if is_error(str) {
+			return nil, str
+		}
+		parts[i] = str.(*object.String).Value
+	}
+	return parts, nil
+}
+
+// sorts_after reports whether sort puts left after right: numbers by value, and values with an
+// __lt method, like structs that overload <, by calling it. Other values keep their order.
+func sorts_after(left, right object.Object) (bool, object.Object) {
+	if num1, ok := left.(*object.Number); ok {
+		num2, ok := right.(*object.Number)
+		return ok && num1.Value > num2.Value, nil
+	}
+	less, found := call_operator_method(right, "__lt", left)
+	if !found {
+		return false, nil
+	}
+	if is_error(less) {
+		return false, less
+	}
+	return is_truthy(less), nil
+}
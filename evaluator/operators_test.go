@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// Operator Overloading Tests
+
+func TestOperatorMethods(t *testing.T) {
+	money := moneyStruct +
+		"fn Money.__add(other) ::\n  return Money(self.cents + other.cents, self.currency)\nend\n" +
+		"fn Money.__mul(factor) ::\n  return Money(self.cents * factor, self.currency)\nend\n" +
+		"fn Money.__lt(other) ::\n  return self.cents < other.cents\nend\n" +
+		"fn Money.__index(i) ::\n  return self.cents * i\nend\n" +
+		"fn Money.to_string() ::\n  return \"#{self.cents} #{self.currency}\"\nend\n"
+	vector := "fn vec(x, y) ::\n  const v = {\"x\": x, \"y\": y}\n" +
+		"  v.__add = fn(self, other) :: return vec(self.x + other.x, self.y + other.y) end\n" +
+		"  v.__sub = fn(self, other) :: return vec(self.x - other.x, self.y - other.y) end\n" +
+		"  v.__eq = fn(self, other) :: return self.x == other.x end\n" +
+		"  v.__index = fn(self, key) :: return \"no #{key}\" end\n" +
+		"  v.to_string = fn(self) :: return \"V(#{self.x}, #{self.y})\" end\n" +
+		"  return v\nend\n"
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{money + `(Money(5, "EUR") + Money(7, "EUR")).cents`, "12"},
+		{money + `(Money(5, "EUR") * 3).cents`, "15"},
+		{money + `var total = Money(1, "EUR")` + "\ntotal += Money(2, \"EUR\")\ntotal.cents", "3"},
+		{money + `Money(5, "EUR") < Money(7, "EUR")`, "true"},
+		{money + `Money(5, "EUR") > Money(7, "EUR")`, "false"},
+		{money + `Money(5, "EUR") <= Money(5, "EUR")`, "true"},
+		{money + `Money(5, "EUR") >= Money(7, "EUR")`, "false"},
+		{money + `Money(5, "EUR")[3]`, "15"},
+		{money + "var price = Money(5, \"EUR\")\n\"cost: #{price}\"", `"cost: 5 EUR"`},
+		// to_string shows values held in collections, and sort orders by __lt
+		{money + `var items = [Money(5, "EUR"), "x"]` + "\n\"#{items}\"", `"[5 EUR, "x"]"`},
+		{money + `var totals = {"total": Tuple(Money(1, "EUR"))}` + "\n\"#{totals}\"", `"{"total": (1 EUR,)}"`},
+		{money + `var prices = [Money(7, "EUR"), Money(5, "EUR"), Money(6, "EUR")]` + "\n\"#{prices.sort()}\"", `"[5 EUR, 6 EUR, 7 EUR]"`},
+		{vector + `"#{vec(1, 2) + vec(3, 4)}"`, `"V(4, 6)"`},
+		{vector + `"#{vec(3, 4) - vec(1, 1)}"`, `"V(2, 3)"`},
+		{vector + "vec(1, 2) == vec(1, 5)", "true"},
+		{vector + "vec(1, 2) != vec(2, 2)", "true"},
+		{vector + `vec(1, 2)["x"]`, "1"},
+		{vector + `vec(1, 2)["z"]`, `"no z"`},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestOperatorMethodsFromRegistry(t *testing.T) {
+	// The registries are shared by every program, so take the methods off again afterwards
+	t.Cleanup(func() {
+		delete(global_array_object.Properties, "__add")
+		delete(global_map_object.Properties, "to_string")
+	})
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Array.__add = fn(self, other) :: return self.concat(other) end\n[1] + [2]", "[1, 2]"},
+		{"Map.to_string = fn(self) :: return \"map\" end\nvar m = {\"a\": 1}\n\"#{m}\"", `"map"`},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestOperatorMethodErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"struct P ::\n  x: number\nend\nP(1) + P(2)", "unknown operator: STRUCT_INSTANCE + STRUCT_INSTANCE"},
+		{"struct P ::\n  x: number\nend\nP(1)[0]", "index operator not supported: STRUCT_INSTANCE"},
+		{"struct P ::\n  x: number\nend\nfn P.to_string() ::\n  return 1 / 0\nend\n\"#{P(1)}\"", "division by zero"},
+		{`{"a": 1} - {"a": 1}`, "unknown operator: MAP - MAP"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
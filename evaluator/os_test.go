@@ -2,6 +2,7 @@ package evaluator
 
 import (
 	"os"
+	"os/exec"
 	"runtime"
 	"testing"
 
@@ -277,6 +278,26 @@ func TestOSErrorHandling(t *testing.T) {
 	}
 }
 
+func TestOSExecErrorKind(t *testing.T) {
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false command not available")
+	}
+
+	input := `
+		var output, err = OS.exec("false")
+		var details = [err.kind, err.data["exit_code"]]
+		details
+	`
+	result := testEval(input)
+
+	arr, ok := result.(*object.Array)
+	if !ok || len(arr.Elements) != 2 {
+		t.Fatalf("result is not a 2 element Array. got=%T (%+v)", result, result)
+	}
+	testStringObject(t, arr.Elements[0], "exec")
+	testNumberObject(t, arr.Elements[1], 1)
+}
+
 func TestOSComplexOperations(t *testing.T) {
 	// Set multiple environment variables and retrieve them
 	input := `
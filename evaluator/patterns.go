@@ -0,0 +1,379 @@
+package evaluator
+
+import (
+	"unicode"
+
+	"github.com/vpaulo/seda/ast"
+	"github.com/vpaulo/seda/object"
+)
+
+// eval_case_branches evaluates the first branch whose pattern matches subject and whose guard holds
+func eval_case_branches(subject object.Object, branches []*ast.CaseBranch, env *object.Environment) object.Object {
+	for _, branch := range branches {
+		if branch == nil {
+			continue
+		}
+
+		bindings := make(map[string]object.Object)
+		matched, err := match_pattern(branch.Pattern, subject, env, bindings)
+		if err != nil {
+			return err
+		}
+		if !matched {
+			continue
+		}
+
+		// Bound names are visible in the guard and the result
+		branch_env := env
+		if len(bindings) > 0 {
+			branch_env = object.NewEnclosedEnvironment(env)
+			for name, value := range bindings {
+				branch_env.Set(name, value)
+			}
+		}
+
+		if branch.Guard != nil {
+			guard := Eval(branch.Guard, branch_env)
+			if is_error(guard) {
+				return guard
+			}
+			if !is_truthy(guard) {
+				continue
+			}
+		}
+
+		return Eval(branch.Result, branch_env)
+	}
+
+	// No match found
+	return object.NULL
+}
+
+// match_pattern reports whether value matches pattern, recording the names it binds.
+// Patterns that are not structural are evaluated and compared with is_equal.
+func match_pattern(pattern ast.Expression, value object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value == "_" {
+			return true, nil
+		}
+		// Variants without fields match themselves
+		if variant, ok := unit_variant(pattern.Value, env); ok {
+			instance, ok := value.(*object.StructInstance)
+			return ok && instance.Struct == variant, nil
+		}
+		// Capitalized names and constants are compared by value, so a case can match against named values
+		// and a misspelled variant is reported instead of binding everything
+		if unicode.IsUpper([]rune(pattern.Value)[0]) || env.IsConstant(pattern.Value) {
+			expected := Eval(pattern, env)
+			if is_error(expected) {
+				return false, expected
+			}
+			return is_equal(value, expected), nil
+		}
+		// Other names match anything and bind it
+		bindings[pattern.Value] = value
+		return true, nil
+
+	case *ast.TypePattern:
+		matches, err := type_matches(pattern.Type, value, env)
+		if err != nil {
+			return false, err
+		}
+		if matches && pattern.Name.Value != "_" {
+			bindings[pattern.Name.Value] = value
+		}
+		return matches, nil
+
+	case *ast.AlternativePattern:
+		for _, alternative := range pattern.Alternatives {
+			attempt := make(map[string]object.Object)
+			matched, err := match_pattern(alternative, value, env, attempt)
+			if err != nil {
+				return false, err
+			}
+			if matched {
+				for name, bound := range attempt {
+					bindings[name] = bound
+				}
+				return true, nil
+			}
+		}
+		return false, nil
+
+	case *ast.ArrayPattern:
+		return match_array_pattern(pattern, value, env, bindings)
+
+	case *ast.MapPattern:
+		return match_map_pattern(pattern, value, env, bindings)
+
+	case *ast.StructPattern:
+		return match_struct_pattern(pattern, value, env, bindings)
+
+	case *ast.RangeExpression:
+		bounds := Eval(pattern, env)
+		if is_error(bounds) {
+			return false, bounds
+		}
+		r, ok := bounds.(*object.Range)
+		if !ok {
+			return is_equal(value, bounds), nil
+		}
+		num, ok := value.(*object.Number)
+		if !ok {
+			return false, nil
+		}
+		below := "<"
+		if r.Inclusive {
+			below = "<="
+		}
+		return eval_number_infix_expression(">=", num, object.NewInteger(r.Start)) == object.TRUE &&
+			eval_number_infix_expression(below, num, object.NewInteger(r.End)) == object.TRUE, nil
+	}
+
+	expected := Eval(pattern, env)
+	if is_error(expected) {
+		return false, expected
+	}
+	return is_equal(value, expected), nil
+}
+
+// match_array_pattern matches arrays element by element; a rest element takes whatever is left over
+func match_array_pattern(pattern *ast.ArrayPattern, value object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+	arr, ok := value.(*object.Array)
+	if !ok {
+		return false, nil
+	}
+
+	rest_index := -1
+	for i, element := range pattern.Elements {
+		if _, ok := element.(*ast.RestPattern); ok {
+			rest_index = i
+		}
+	}
+
+	if rest_index == -1 {
+		if len(arr.Elements) != len(pattern.Elements) {
+			return false, nil
+		}
+		return match_elements(pattern.Elements, arr.Elements, env, bindings)
+	}
+
+	before, after := pattern.Elements[:rest_index], pattern.Elements[rest_index+1:]
+	if len(arr.Elements) < len(before)+len(after) {
+		return false, nil
+	}
+
+	matched, err := match_elements(before, arr.Elements[:len(before)], env, bindings)
+	if !matched || err != nil {
+		return false, err
+	}
+	tail := len(arr.Elements) - len(after)
+	matched, err = match_elements(after, arr.Elements[tail:], env, bindings)
+	if !matched || err != nil {
+		return false, err
+	}
+
+	rest := pattern.Elements[rest_index].(*ast.RestPattern)
+	if rest.Name.Value != "_" {
+		remaining := make([]object.Object, tail-len(before))
+		copy(remaining, arr.Elements[len(before):tail])
+		bindings[rest.Name.Value] = &object.Array{Elements: remaining}
+	}
+	return true, nil
+}
+
+// match_elements matches patterns and values pairwise
+func match_elements(patterns []ast.Expression, values []object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+	for i, element := range patterns {
+		matched, err := match_pattern(element, values[i], env, bindings)
+		if !matched || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// match_map_pattern matches maps that have every listed key; other keys are ignored
+func match_map_pattern(pattern *ast.MapPattern, value object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+	m, ok := value.(*object.Map)
+	if !ok {
+		return false, nil
+	}
+
+	for i, key_node := range pattern.Keys {
+		key := Eval(key_node, env)
+		if is_error(key) {
+			return false, key
+		}
+		pair, ok := m.Get(map_key(key))
+		if !ok {
+			return false, nil
+		}
+		matched, err := match_pattern(pattern.Values[i], pair.Value, env, bindings)
+		if !matched || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// match_struct_pattern matches instances of the named struct, comparing fields in declaration order or by name
+func match_struct_pattern(pattern *ast.StructPattern, value object.Object, env *object.Environment, bindings map[string]object.Object) (bool, object.Object) {
+	obj, ok := env.Get(pattern.Name.Value)
+	if !ok {
+		return false, object.NewError("identifier not found: %s", pattern.Name.Value)
+	}
+	if variant, ok := obj.(*object.StructInstance); ok && variant.Struct.IsUnitVariant() {
+		obj = variant.Struct
+	}
+	struct_type, ok := obj.(*object.Struct)
+	if !ok {
+		return false, object.NewError("'%s' in pattern is not a struct", pattern.Name.Value)
+	}
+	if len(pattern.Arguments) > len(struct_type.Fields) {
+		return false, object.NewError("too many fields in %s pattern: got %d, want %d",
+			struct_type.Name, len(pattern.Arguments), len(struct_type.Fields))
+	}
+
+	instance, ok := value.(*object.StructInstance)
+	if !ok || instance.Struct != struct_type {
+		return false, nil
+	}
+
+	for i, arg := range pattern.Arguments {
+		field_name := struct_type.Fields[i].Name.Value
+		field_pattern := arg
+		if named, ok := arg.(*ast.NamedArgument); ok {
+			field_name, field_pattern = named.Name.Value, named.Value
+			if !struct_type.HasField(field_name) {
+				return false, object.NewError("unknown field '%s' for %s", field_name, struct_type.Name)
+			}
+		}
+
+		field_value, ok := instance.Fields[field_name]
+		if !ok {
+			field_value = object.NULL
+		}
+		matched, err := match_pattern(field_pattern, field_value, env, bindings)
+		if !matched || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// unit_variant looks up a sum type variant without fields, which patterns compare instead of binding
+func unit_variant(name string, env *object.Environment) (*object.Struct, bool) {
+	obj, ok := env.Get(name)
+	if !ok {
+		return nil, false
+	}
+	instance, ok := obj.(*object.StructInstance)
+	if !ok || !instance.Struct.IsUnitVariant() {
+		return nil, false
+	}
+	return instance.Struct, true
+}
+
+// destructure binds the names in a var, for or parameter pattern to the matching parts of value.
+// Unlike case patterns, a value without the shape of the pattern is an error.
+func destructure(pattern ast.Expression, value object.Object, env *object.Environment, bind func(string, object.Object)) *object.Error {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		if pattern.Value != "_" {
+			bind(pattern.Value, value)
+		}
+		return nil
+
+	case *ast.DefaultPattern:
+		// Defaults are evaluated after the names bound before them
+		if value == nil || value == object.NULL {
+			value = Eval(pattern.Default, env)
+			if is_runtime_error(value) {
+				return value.(*object.Error)
+			}
+		}
+		return destructure(pattern.Target, value, env, bind)
+
+	case *ast.ArrayPattern:
+		return destructure_array(pattern, value, env, bind)
+
+	case *ast.MapPattern:
+		return destructure_map(pattern, value, env, bind)
+	}
+
+	return object.NewError("cannot destructure with %s", pattern.String())
+}
+
+// destructure_array binds elements in order; elements beyond the pattern are ignored unless it has a rest element
+func destructure_array(pattern *ast.ArrayPattern, value object.Object, env *object.Environment, bind func(string, object.Object)) *object.Error {
+	var elements []object.Object
+	switch value := value.(type) {
+	case *object.Array:
+		elements = value.Elements
+	case *object.MultiValue:
+		elements = value.Values
+	case *object.Tuple:
+		elements = value.Elements
+	default:
+		return object.NewError("cannot destructure %s as an array", describe_type(value))
+	}
+
+	for i, element := range pattern.Elements {
+		if rest, ok := element.(*ast.RestPattern); ok {
+			remaining := []object.Object{}
+			if i < len(elements) {
+				remaining = append(remaining, elements[i:]...)
+			}
+			if rest.Name.Value != "_" {
+				bind(rest.Name.Value, &object.Array{Elements: remaining})
+			}
+			return nil
+		}
+
+		var part object.Object
+		if i < len(elements) {
+			part = elements[i]
+		} else if _, ok := element.(*ast.DefaultPattern); !ok {
+			return object.NewError("not enough values to destructure: got %d, want %d", len(elements), len(pattern.Elements))
+		}
+		if err := destructure(element, part, env, bind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// destructure_map binds the listed keys of a map or fields of a struct instance; other keys are ignored
+func destructure_map(pattern *ast.MapPattern, value object.Object, env *object.Environment, bind func(string, object.Object)) *object.Error {
+	for i, key_node := range pattern.Keys {
+		key := Eval(key_node, env)
+		if is_runtime_error(key) {
+			return key.(*object.Error)
+		}
+
+		var part object.Object
+		switch value := value.(type) {
+		case *object.Map:
+			if pair, ok := value.Get(map_key(key)); ok {
+				part = pair.Value
+			}
+		case *object.StructInstance:
+			if !value.Struct.HasField(key.String()) {
+				return object.NewError("unknown field '%s' for %s", key.String(), value.Struct.Name)
+			}
+			part = value.Fields[key.String()]
+		default:
+			return object.NewError("cannot destructure %s as a map", describe_type(value))
+		}
+
+		if _, ok := pattern.Values[i].(*ast.DefaultPattern); part == nil && !ok {
+			return object.NewError("missing key '%s' to destructure", key.String())
+		}
+		if err := destructure(pattern.Values[i], part, env, bind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
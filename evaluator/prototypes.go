@@ -0,0 +1,153 @@
+package evaluator
+
+import (
+	"github.com/vpaulo/seda/object"
+)
+
+// lookup_pair finds the data key name on m or, when m does not hold it, on its prototypes
+func lookup_pair(m *object.Map, name string) (object.MapPair, bool) {
+	for current := m; current != nil; current = current.Proto {
+		if pair, ok := current.Get(name); ok {
+			return pair, true
+		}
+	}
+	return object.MapPair{}, false
+}
+
+// lookup_method finds the custom method name on m or its prototypes, with the map that holds it
+func lookup_method(m *object.Map, name string) (*object.Function, *object.Map) {
+	for current := m; current != nil; current = current.Proto {
+		if fn, ok := current.Properties[name].(*object.Function); ok {
+			return fn, current
+		}
+	}
+	return nil, nil
+}
+
+// call_inherited_property looks name up in the custom properties of start and its prototypes.
+// A method runs with receiver as self, and a property that is not a function is returned as is.
+func call_inherited_property(start *object.Map, name string, receiver object.Object, args []object.Object) (object.Object, bool) {
+	for current := start; current != nil; current = current.Proto {
+		prop, ok := current.Properties[name]
+		if !ok {
+			continue
+		}
+		if fn, ok := prop.(*object.Function); ok {
+			method_args := append([]object.Object{receiver}, args...)
+			return call_function(bind_super(fn, receiver, current), method_args, nil), true
+		}
+		if len(args) == 0 {
+			return prop, true
+		}
+		return object.NewError("property '%s' is not a function", name), true
+	}
+	return nil, false
+}
+
+// bind_super returns a copy of fn with super bound for a method found on home, so super.name
+// runs the version of name on the prototypes of home. Methods of maps without one are unchanged.
+func bind_super(fn *object.Function, receiver object.Object, home *object.Map) *object.Function {
+	if home.Proto == nil {
+		return fn
+	}
+	return bind_name(fn, "super", &object.Super{Receiver: receiver, Proto: home.Proto})
+}
+
+func call_super_method(super *object.Super, method_name string, args []object.Object) object.Object {
+	if result, found := call_inherited_property(super.Proto, method_name, super.Receiver, args); found {
+		return result
+	}
+	if pair, ok := lookup_pair(super.Proto, method_name); ok && len(args) == 0 {
+		return pair.Value
+	}
+	return object.NewError("method '%s' not found on super", method_name)
+}
+
+// init_object_module creates the Object module, which links maps to the prototypes they inherit from
+func init_object_module() *object.Map {
+	object_module := object.NewMap()
+
+	// Object.extend(parent, fields = {}) - a new map with the given fields that inherits from parent
+	object_module.Set("extend", object.MapPair{
+		Key: &object.String{Value: "extend"},
+		Value: &object.Builtin{
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) < 1 || len(args) > 2 {
+					return object.NewError("wrong number of arguments for Object.extend. got=%d, want=1 or 2", len(args))
+				}
+				parent, ok := args[0].(*object.Map)
+				if !ok {
+					return object.NewError("first argument to Object.extend must be MAP, got %s", args[0].Type())
+				}
+				child := object.NewMap()
+				child.Proto = parent
+				if len(args) == 2 {
+					fields, ok := args[1].(*object.Map)
+					if !ok {
+						return object.NewError("second argument to Object.extend must be MAP, got %s", args[1].Type())
+					}
+					for _, pair := range fields.Entries() {
+						child.Set(map_key(pair.Key), pair)
+					}
+					for name, prop := range fields.Properties {
+						if child.Properties == nil {
+							child.Properties = make(map[string]object.Object)
+						}
+						child.Properties[name] = prop
+					}
+				}
+				return child
+			},
+		},
+	})
+
+	// Object.proto(value) - the map value inherits from, or nil
+	object_module.Set("proto", object.MapPair{
+		Key: &object.String{Value: "proto"},
+		Value: &object.Builtin{
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 1 {
+					return object.NewError("wrong number of arguments for Object.proto. got=%d, want=1", len(args))
+				}
+				if m, ok := args[0].(*object.Map); ok && m.Proto != nil {
+					return m.Proto
+				}
+				return object.NULL
+			},
+		},
+	})
+
+	// Object.set_proto(value, parent) - makes value inherit from parent, or from nothing when parent is nil
+	object_module.Set("set_proto", object.MapPair{
+		Key: &object.String{Value: "set_proto"},
+		Value: &object.Builtin{
+			Fn: func(args ...object.Object) object.Object {
+				if len(args) != 2 {
+					return object.NewError("wrong number of arguments for Object.set_proto. got=%d, want=2", len(args))
+				}
+				m, ok := args[0].(*object.Map)
+				if !ok {
+					return object.NewError("first argument to Object.set_proto must be MAP, got %s", args[0].Type())
+				}
+				if m.IsImmutable {
+					return object.NewError("cannot change the prototype of an immutable map")
+				}
+				if args[1] == object.NULL {
+					m.Proto = nil
+					return m
+				}
+				parent, ok := args[1].(*object.Map)
+				if !ok {
+					return object.NewError("second argument to Object.set_proto must be MAP or nil, got %s", args[1].Type())
+				}
+				if parent.Inherits(m) {
+					return object.NewError("prototype chain cannot contain a cycle")
+				}
+				m.Proto = parent
+				return m
+			},
+		},
+	})
+
+	return object_module
+}
@@ -0,0 +1,108 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// Prototype Tests
+
+const animals = `var Animal = {"legs": 4}
+Animal.speak = fn(self) ::
+  return self.name + " makes a sound"
+end
+Animal.describe = fn(self) ::
+  return self.name + " has " + self.legs.to_string() + " legs"
+end
+var Dog = Object.extend(Animal, {"sound": "Woof"})
+Dog.speak = fn(self) ::
+  return self.name + " says " + self.sound
+end
+Dog.describe = fn(self) ::
+  return super.describe() + " and barks"
+end
+var Puppy = Object.extend(Dog)
+Puppy.describe = fn(self) ::
+  return "Small: " + super.describe
+end
+var rex = Object.extend(Dog, {"name": "Rex"})
+var bit = Object.extend(Puppy, {"name": "Bit"})
+`
+
+func TestPrototypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{animals + "rex.speak()", `"Rex says Woof"`},
+		{animals + "rex.describe", `"Rex has 4 legs and barks"`},
+		{animals + "bit.describe()", `"Small: Bit has 4 legs and barks"`},
+		{animals + "bit.speak", `"Bit says Woof"`},
+		{animals + `rex["legs"]`, "4"},
+		{animals + "rex.legs", "4"},
+		{animals + "rex", `{"name": "Rex"}`},
+		{animals + "rex.keys()", `["name"]`},
+		{animals + "Object.proto(bit) == Puppy", "true"},
+		{animals + "Object.proto(Animal)", "null"},
+		{animals + "rex.legs = 3\n[rex.legs, Animal.legs]", "[3, 4]"},
+		{animals + "Object.extend(Dog, {\"name\": \"A\"}) == Object.extend(Dog, {\"name\": \"A\"})", "true"},
+		{animals + "Object.extend(Dog, {\"name\": \"A\"}) == Object.extend(Puppy, {\"name\": \"A\"})", "false"},
+		{animals + "var cat = {\"name\": \"Tom\"}\nObject.set_proto(cat, Animal)\ncat.speak", `"Tom makes a sound"`},
+		{animals + "Animal.__add = fn(self, other) :: return self.name + other.name end\nrex + bit", `"RexBit"`},
+		{animals + "Animal.to_string = fn(self) :: return \"<\" + self.name + \">\" end\n\"#{bit}\"", `"<Bit>"`},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestPrototypeIsA(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[bit, Dog]", true},
+		{"[bit, Animal]", true},
+		{"[rex, Puppy]", false},
+		{"[Animal, Animal]", true},
+		{"[5, Animal]", false},
+	}
+
+	for _, tt := range tests {
+		pair, ok := testEval(animals + tt.input).(*object.Array)
+		if !ok {
+			t.Fatalf("could not evaluate %q", tt.input)
+		}
+		if ok, _ := eval_isA_assertion(pair.Elements[0], pair.Elements[1]); ok != tt.expected {
+			t.Errorf("%s isA = %t, want %t", tt.input, ok, tt.expected)
+		}
+	}
+}
+
+func TestPrototypeErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"Object.extend(5)", "first argument to Object.extend must be MAP, got NUMBER"},
+		{"Object.extend({}, [1])", "second argument to Object.extend must be MAP, got ARRAY"},
+		{"Object.extend()", "wrong number of arguments for Object.extend. got=0, want=1 or 2"},
+		{"var a = {}\nvar b = Object.extend(a)\nObject.set_proto(a, b)", "prototype chain cannot contain a cycle"},
+		{"const a = {}\nObject.set_proto(a, {})", "cannot change the prototype of an immutable map"},
+		{"var a = Object.extend({})\na.f = fn(self) :: return super.missing() end\na.f()", "method 'missing' not found on super"},
+		{"var a = {}\na.f = fn(self) :: return super.f() end\na.f()", "identifier not found: super"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// Seq Module Tests
+
+func TestSeqOperators(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Seq.from([1, 2, 3]).map(x => x * 10).collect()", "[10, 20, 30]"},
+		{"Seq.from(1..10).filter(x => x % 3 == 0).collect()", "[3, 6, 9]"},
+		{"Seq.from(1..10).take(3).collect()", "[1, 2, 3]"},
+		{"Seq.from(1..10).drop(7).collect()", "[8, 9]"},
+		{"Seq.from([1, 2, 5, 1]).take_while(x => x < 3).collect()", "[1, 2]"},
+		{`Seq.from([1, 2, 3]).zip(["a", "b"]).collect()`, `[[1, "a"], [2, "b"]]`},
+		{"Seq.from([1, 2, 3, 4, 5]).chunk(2).collect()", "[[1, 2], [3, 4], [5]]"},
+		{"Seq.from([1, 2, 3, 4]).window(3).collect()", "[[1, 2, 3], [2, 3, 4]]"},
+		{"Seq.from([1, 2]).window(3).collect()", "[]"},
+		{`Seq.from("ab").enumerate().collect()`, `[[0, "a"], [1, "b"]]`},
+		{"Seq.from([1, 2]).flat_map(x => [x, x * 10]).collect()", "[1, 10, 2, 20]"},
+		{"Seq.from([1, 2, 3]).reduce((sum, x) => sum + x, 0)", "6"},
+		{"Seq.from(1..10).count()", "9"},
+		{"Seq.from(1..10).count(x => x > 5)", "4"},
+		{"Seq.count_from(5).take(3).collect()", "[5, 6, 7]"},
+		{"Seq.count_from(0, 0.5).take(3).collect()", "[0, 0.5, 1]"},
+		{"Seq.count_from(1).map(x => x * x).filter(x => x % 2 == 1).take(3).collect()", "[1, 9, 25]"},
+		{"Seq.count_from(0).zip(Seq.count_from(100)).take(2).collect()", "[[0, 100], [1, 101]]"},
+		{"[3, 4] |> Seq.map(x => x + 1) |> Seq.collect", "[4, 5]"},
+		{"Seq.take(1..100, 2).collect()", "[1, 2]"},
+		{"Seq.from([1, 2, 3]).map(x => x * 2).sum()", "12"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestSeqIsLazy(t *testing.T) {
+	// Only the values that reach take are mapped
+	input := `
+		var calls = 0
+		fn square(x) ::
+			calls += 1
+			return x * x
+		end
+		var firsts = Seq.count_from(1).map(square).take(3).collect()
+		calls
+	`
+	testIntegerObject(t, testEval(input), 3)
+
+	// Generators only run as far as the sequence pulls them
+	input = `
+		var produced = 0
+		fn naturals() ::
+			var n = 0
+			while true ::
+				produced += 1
+				yield n
+				n += 1
+			end
+		end
+		var pairs = naturals().window(2).take(2).collect()
+		produced
+	`
+	testIntegerObject(t, testEval(input), 3)
+
+	// Operators return iterators until a terminal operation runs
+	result := testEval("Seq.from([1, 2]).map(x => x).filter(x => x)")
+	it, ok := result.(*object.Iterator)
+	if !ok {
+		t.Fatalf("lazy operators should return an Iterator, got %T", result)
+	}
+	if it.Inspect() != "iterator array.map.filter" {
+		t.Errorf("wrong iterator name, got %q", it.Inspect())
+	}
+}
+
+func TestSeqSearchesStopEarly(t *testing.T) {
+	// Searches pull values only until they have their answer, so infinite sources work
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Seq.count_from(1).find(x => x > 3)", "4"},
+		{"Seq.count_from(1).first()", "1"},
+		{"Seq.count_from(0).find_index(x => x * x > 50)", "8"},
+		{"Seq.count_from(0).any(x => x == 10)", "true"},
+		{"Seq.count_from(0).all(x => x < 5)", "false"},
+		{"Seq.count_from(0).none(x => x > 3)", "false"},
+		{"Seq.count_from(0).contains(7)", "true"},
+		{"Seq.count_from(0, 2).index_of(6)", "3"},
+		{"Seq.find(Seq.count_from(1), x => x % 7 == 0)", "7"},
+		// The values after a hit stay in the iterator
+		{"var it = Seq.count_from(1)\nit.find(x => x > 2)\nit.next()", "4"},
+		// Finite sources give the same answers as arrays
+		{"Seq.from([]).first()", "null"},
+		{"Seq.from([1, 2]).find(x => x > 5)", "null"},
+		{"Seq.from([1, 2]).find_index(x => x > 5)", "-1"},
+		{"Seq.from([1, 2]).all(x => x > 0)", "true"},
+		{"Seq.from([1, 2]).index_of(3)", "-1"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestSeqErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"Seq.from(5)", "object is not iterable: *object.Number"},
+		{"Seq.from([1]).map(1)", "argument to Seq.map must be FUNCTION, got NUMBER"},
+		{"Seq.from([1]).take()", "wrong number of arguments for Seq.take. got=0, want=1"},
+		{"Seq.from([1]).chunk(0)", "chunk size must be positive"},
+		{"Seq.from([1]).zip(5)", "argument to Seq.zip must be iterable, got NUMBER"},
+		{"Seq.map(5, x => x)", "first argument to Seq.map must be iterable, got NUMBER"},
+		{"Seq.count_from(\"a\")", "first argument to Seq.count_from must be NUMBER, got STRING"},
+		{"Seq.from([1, 0]).map(x => 1 / x).collect()", "division by zero"},
+		{"Seq.count_from(0).find(x => 1 / x)", "division by zero"},
+		{"Seq.from([1]).first(1)", "wrong number of arguments for Seq.first. got=1, want=0"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -0,0 +1,404 @@
+package evaluator
+
+import (
+	"github.com/vpaulo/seda/object"
+)
+
+// sequence_methods are the iterator methods that stream: the lazy operators return a new iterator that pulls
+// from the one they were called on, and the terminal operations consume it without building arrays along the way.
+// The searches stop at the first value that answers them, so they also work on infinite sources.
+// They mirror the array methods of the same name and are shared with the Seq module.
+var sequence_methods = map[string]bool{
+	"map": true, "filter": true, "take": true, "drop": true, "take_while": true, "zip": true,
+	"chunk": true, "window": true, "enumerate": true, "flat_map": true,
+	"collect": true, "reduce": true, "count": true,
+	"first": true, "find": true, "find_index": true, "any": true, "all": true, "none": true,
+	"contains": true, "index_of": true,
+}
+
+// call_sequence_method applies a lazy operator or terminal operation to an iterator
+func call_sequence_method(it *object.Iterator, method_name string, args []object.Object) (object.Object, bool) {
+	if !sequence_methods[method_name] {
+		return nil, false
+	}
+
+	switch method_name {
+	case "map":
+		fn, err := sequence_function(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		return derive(it, method_name, func() (object.Object, bool) {
+			element, ok := it.Next()
+			if !ok || is_runtime_error(element) {
+				return element, ok
+			}
+			return apply_function_from_method(fn, []object.Object{element}), true
+		}), true
+
+	case "filter":
+		fn, err := sequence_function(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		return derive(it, method_name, func() (object.Object, bool) {
+			for {
+				element, ok := it.Next()
+				if !ok || is_runtime_error(element) {
+					return element, ok
+				}
+				condition := apply_function_from_method(fn, []object.Object{element})
+				if is_runtime_error(condition) {
+					return condition, true
+				}
+				if is_truthy(condition) {
+					return element, true
+				}
+			}
+		}), true
+
+	case "take_while":
+		fn, err := sequence_function(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		done := false
+		return derive(it, method_name, func() (object.Object, bool) {
+			if done {
+				return nil, false
+			}
+			element, ok := it.Next()
+			if !ok || is_runtime_error(element) {
+				return element, ok
+			}
+			condition := apply_function_from_method(fn, []object.Object{element})
+			if is_runtime_error(condition) {
+				return condition, true
+			}
+			if !is_truthy(condition) {
+				done = true
+				return nil, false
+			}
+			return element, true
+		}), true
+
+	case "take":
+		count, err := sequence_count(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		taken := 0
+		return derive(it, method_name, func() (object.Object, bool) {
+			if taken >= count {
+				return nil, false
+			}
+			taken++
+			return it.Next()
+		}), true
+
+	case "drop":
+		count, err := sequence_count(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		return derive(it, method_name, func() (object.Object, bool) {
+			for ; count > 0; count-- {
+				element, ok := it.Next()
+				if !ok || is_runtime_error(element) {
+					return element, ok
+				}
+			}
+			return it.Next()
+		}), true
+
+	case "enumerate":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Seq.enumerate. got=%d, want=0", len(args)), true
+		}
+		index := 0
+		return derive(it, method_name, func() (object.Object, bool) {
+			element, ok := it.Next()
+			if !ok || is_runtime_error(element) {
+				return element, ok
+			}
+			index++
+			return &object.Array{Elements: []object.Object{&object.Number{Value: float64(index - 1)}, element}}, true
+		}), true
+
+	case "zip":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Seq.zip. got=%d, want=1", len(args)), true
+		}
+		other, err := iterate(args[0])
+		if err != nil {
+			return object.NewError("argument to Seq.zip must be iterable, got %s", args[0].Type()), true
+		}
+		zipped := derive(it, method_name, func() (object.Object, bool) {
+			left, ok := it.Next()
+			if !ok || is_runtime_error(left) {
+				return left, ok
+			}
+			right, ok := other.Next()
+			if !ok || is_runtime_error(right) {
+				return right, ok
+			}
+			return &object.Array{Elements: []object.Object{left, right}}, true
+		})
+		zipped.Close = func() {
+			it.Close()
+			other.Close()
+		}
+		return zipped, true
+
+	case "chunk", "window":
+		size, err := sequence_count(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		if size <= 0 {
+			return object.NewError("%s size must be positive", method_name), true
+		}
+		if method_name == "chunk" {
+			return derive(it, method_name, func() (object.Object, bool) {
+				chunk := []object.Object{}
+				for len(chunk) < size {
+					element, ok := it.Next()
+					if !ok {
+						break
+					}
+					if is_runtime_error(element) {
+						return element, true
+					}
+					chunk = append(chunk, element)
+				}
+				return &object.Array{Elements: chunk}, len(chunk) > 0
+			}), true
+		}
+		window := []object.Object{}
+		return derive(it, method_name, func() (object.Object, bool) {
+			// The first window fills up, the ones after slide along by one value
+			if len(window) == size {
+				window = window[1:]
+			}
+			for len(window) < size {
+				element, ok := it.Next()
+				if !ok || is_runtime_error(element) {
+					return element, ok
+				}
+				window = append(window, element)
+			}
+			elements := make([]object.Object, size)
+			copy(elements, window)
+			return &object.Array{Elements: elements}, true
+		}), true
+
+	case "flat_map":
+		fn, err := sequence_function(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		var inner *object.Iterator
+		flattened := derive(it, method_name, func() (object.Object, bool) {
+			for {
+				if inner != nil {
+					if element, ok := inner.Next(); ok {
+						return element, true
+					}
+					inner = nil
+				}
+				element, ok := it.Next()
+				if !ok || is_runtime_error(element) {
+					return element, ok
+				}
+				// Arrays and iterators are spread, anything else is a single value
+				switch mapped := apply_function_from_method(fn, []object.Object{element}).(type) {
+				case *object.Array:
+					inner = slice_iterator("array", mapped.Elements)
+				case *object.Iterator:
+					inner = mapped
+				default:
+					return mapped, true
+				}
+			}
+		})
+		flattened.Close = func() {
+			if inner != nil {
+				inner.Close()
+			}
+			it.Close()
+		}
+		return flattened, true
+
+	case "collect":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Seq.collect. got=%d, want=0", len(args)), true
+		}
+		return collect(it), true
+
+	case "reduce":
+		if len(args) != 2 {
+			return object.NewError("wrong number of arguments for Seq.reduce. got=%d, want=2", len(args)), true
+		}
+		fn, ok := args[0].(*object.Function)
+		if !ok {
+			return object.NewError("first argument to Seq.reduce must be FUNCTION, got %s", args[0].Type()), true
+		}
+		accumulator := args[1]
+		for {
+			element, ok := it.Next()
+			if !ok {
+				return accumulator, true
+			}
+			if is_runtime_error(element) {
+				return element, true
+			}
+			accumulator = apply_function_from_method(fn, []object.Object{accumulator, element})
+			if is_error(accumulator) {
+				it.Close()
+				return accumulator, true
+			}
+		}
+
+	case "count":
+		// Without a predicate every value counts
+		if len(args) > 1 {
+			return object.NewError("wrong number of arguments for Seq.count. got=%d, want=0 or 1", len(args)), true
+		}
+		var fn *object.Function
+		if len(args) == 1 {
+			var ok bool
+			if fn, ok = args[0].(*object.Function); !ok {
+				return object.NewError("argument to Seq.count must be FUNCTION, got %s", args[0].Type()), true
+			}
+		}
+		count := 0
+		for {
+			element, ok := it.Next()
+			if !ok {
+				return &object.Number{Value: float64(count)}, true
+			}
+			if is_runtime_error(element) {
+				return element, true
+			}
+			if fn != nil {
+				condition := apply_function_from_method(fn, []object.Object{element})
+				if is_error(condition) {
+					it.Close()
+					return condition, true
+				}
+				if !is_truthy(condition) {
+					continue
+				}
+			}
+			count++
+		}
+
+	case "first":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Seq.first. got=%d, want=0", len(args)), true
+		}
+		element, ok := it.Next()
+		if !ok {
+			return object.NULL, true
+		}
+		return element, true
+
+	case "find", "find_index", "any", "all", "none":
+		fn, err := sequence_function(method_name, args)
+		if err != nil {
+			return err, true
+		}
+		// all looks for the first value that fails the predicate, the others for one that passes
+		index, element, failure := search(it, func(element object.Object) (bool, object.Object) {
+			condition := apply_function_from_method(fn, []object.Object{element})
+			if is_error(condition) {
+				return false, condition
+			}
+			return is_truthy(condition) != (method_name == "all"), nil
+		})
+		if failure != nil {
+			return failure, true
+		}
+		switch method_name {
+		case "find":
+			return element, true
+		case "find_index":
+			return &object.Number{Value: float64(index)}, true
+		case "any":
+			return native_bool(index >= 0), true
+		default:
+			return native_bool(index < 0), true
+		}
+
+	case "contains", "index_of":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Seq.%s. got=%d, want=1", method_name, len(args)), true
+		}
+		index, _, failure := search(it, func(element object.Object) (bool, object.Object) {
+			return is_equal(element, args[0]), nil
+		})
+		if failure != nil {
+			return failure, true
+		}
+		if method_name == "contains" {
+			return native_bool(index >= 0), true
+		}
+		return &object.Number{Value: float64(index)}, true
+	}
+
+	return nil, false
+}
+
+// search pulls values until matches accepts one, returning its position and the value, or -1 and null when
+// none does. The values after it are left in the iterator.
+func search(it *object.Iterator, matches func(object.Object) (bool, object.Object)) (index int, found, failure object.Object) {
+	for ; ; index++ {
+		element, ok := it.Next()
+		if !ok {
+			return -1, object.NULL, nil
+		}
+		if is_runtime_error(element) {
+			return 0, nil, element
+		}
+		hit, failure := matches(element)
+		if failure != nil {
+			it.Close()
+			return 0, nil, failure
+		}
+		if hit {
+			return index, element, nil
+		}
+	}
+}
+
+// derive returns an iterator that produces values from source through next, and closes source when it is closed
+func derive(source *object.Iterator, operator string, next func() (object.Object, bool)) *object.Iterator {
+	return &object.Iterator{Name: source.Name + "." + operator, Next: next, Close: source.Close}
+}
+
+// sequence_function validates the callback of a lazy operator
+func sequence_function(method_name string, args []object.Object) (*object.Function, *object.Error) {
+	if len(args) != 1 {
+		return nil, object.NewError("wrong number of arguments for Seq.%s. got=%d, want=1", method_name, len(args))
+	}
+	fn, ok := args[0].(*object.Function)
+	if !ok {
+		return nil, object.NewError("argument to Seq.%s must be FUNCTION, got %s", method_name, args[0].Type())
+	}
+	return fn, nil
+}
+
+// sequence_count validates the size argument of take, drop, chunk and window
+func sequence_count(method_name string, args []object.Object) (int, *object.Error) {
+	if len(args) != 1 {
+		return 0, object.NewError("wrong number of arguments for Seq.%s. got=%d, want=1", method_name, len(args))
+	}
+	n, ok := args[0].(*object.Number)
+	if !ok {
+		return 0, object.NewError("argument to Seq.%s must be NUMBER, got %s", method_name, args[0].Type())
+	}
+	if n.Value < 0 {
+		return 0, nil
+	}
+	return int(n.Value), nil
+}
@@ -0,0 +1,268 @@
+package evaluator
+
+import (
+	"github.com/vpaulo/seda/ast"
+	"github.com/vpaulo/seda/object"
+)
+
+func eval_set_literal(node *ast.SetLiteral, env *object.Environment) object.Object {
+	set := object.NewSet()
+	for _, element := range node.Elements {
+		value := Eval(element, env)
+		if is_error(value) {
+			return value
+		}
+		if !set.Add(value) {
+			return object.NewError("unhashable type: %s", value.Type())
+		}
+	}
+	return set
+}
+
+// set_builtin builds a set: Set() is empty, Set(values) takes the distinct values of an array, string, range or iterator
+func set_builtin(args ...object.Object) object.Object {
+	if len(args) > 1 {
+		return object.NewError("wrong number of arguments for Set. got=%d, want=0 or 1", len(args))
+	}
+	if len(args) == 0 {
+		return object.NewSet()
+	}
+	return to_set("Set", args[0])
+}
+
+// tuple_builtin builds a tuple of its arguments: Tuple(1, "a")
+func tuple_builtin(args ...object.Object) object.Object {
+	elements := make([]object.Object, len(args))
+	copy(elements, args)
+	return &object.Tuple{Elements: elements}
+}
+
+// to_set returns value when it is already a set, or a new set of the values it iterates over
+func to_set(name string, value object.Object) object.Object {
+	if set, ok := value.(*object.Set); ok {
+		return set
+	}
+	it, err := iterate(value)
+	if err != nil {
+		return object.NewError("argument to %s must be iterable, got %s", name, value.Type())
+	}
+	set := object.NewSet()
+	for {
+		element, ok := it.Next()
+		if !ok {
+			return set
+		}
+		if is_runtime_error(element) {
+			return element
+		}
+		if !set.Add(element) {
+			it.Close()
+			return object.NewError("unhashable type: %s", element.Type())
+		}
+	}
+}
+
+// Set Methods
+
+func call_set_method(set *object.Set, method_name string, args []object.Object) object.Object {
+	switch method_name {
+	case "length":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Set.length. got=%d, want=0", len(args))
+		}
+		return &object.Number{Value: float64(set.Len())}
+
+	case "is_empty":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Set.is_empty. got=%d, want=0", len(args))
+		}
+		return native_bool(set.Len() == 0)
+
+	case "contains":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Set.contains. got=%d, want=1", len(args))
+		}
+		return native_bool(set.Contains(args[0]))
+
+	case "add":
+		if set.IsImmutable {
+			return object.NewError("cannot call add() on immutable set")
+		}
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Set.add. got=%d, want=1", len(args))
+		}
+		if !set.Add(args[0]) {
+			return object.NewError("unhashable type: %s", args[0].Type())
+		}
+		return set
+
+	case "remove":
+		if set.IsImmutable {
+			return object.NewError("cannot call remove() on immutable set")
+		}
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Set.remove. got=%d, want=1", len(args))
+		}
+		return native_bool(set.Remove(args[0]))
+
+	case "union", "intersection", "difference", "symmetric_difference":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Set.%s. got=%d, want=1", method_name, len(args))
+		}
+		other := to_set("Set."+method_name, args[0])
+		if is_error(other) {
+			return other
+		}
+		return combine_sets(method_name, set, other.(*object.Set))
+
+	case "is_subset", "is_superset", "is_disjoint":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Set.%s. got=%d, want=1", method_name, len(args))
+		}
+		other := to_set("Set."+method_name, args[0])
+		if is_error(other) {
+			return other
+		}
+		switch method_name {
+		case "is_subset":
+			return native_bool(is_subset(set, other.(*object.Set)))
+		case "is_superset":
+			return native_bool(is_subset(other.(*object.Set), set))
+		default:
+			return native_bool(combine_sets("intersection", set, other.(*object.Set)).Len() == 0)
+		}
+
+	case "filter", "map":
+		if len(args) != 1 {
+			return object.NewError("wrong number of arguments for Set.%s. got=%d, want=1", method_name, len(args))
+		}
+		fn, ok := args[0].(*object.Function)
+		if !ok {
+			return object.NewError("argument to Set.%s must be FUNCTION, got %s", method_name, args[0].Type())
+		}
+		result := object.NewSet()
+		for _, element := range set.Values() {
+			value := apply_function_from_method(fn, []object.Object{element})
+			if is_error(value) {
+				return value
+			}
+			if method_name == "filter" {
+				if is_truthy(value) {
+					result.Add(element)
+				}
+				continue
+			}
+			if !result.Add(value) {
+				return object.NewError("unhashable type: %s", value.Type())
+			}
+		}
+		return result
+
+	case "to_array":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Set.to_array. got=%d, want=0", len(args))
+		}
+		return &object.Array{Elements: set.Values()}
+	}
+
+	// Everything else reads the elements like an array does
+	if collected_methods[method_name] {
+		return call_array_method(&object.Array{Elements: set.Values()}, method_name, args)
+	}
+	return object.NewError("method '%s' not found on Set", method_name)
+}
+
+// combine_sets returns a new set from the elements of left and right, keeping the order of left
+func combine_sets(operation string, left, right *object.Set) *object.Set {
+	result := object.NewSet()
+	switch operation {
+	case "union":
+		for _, element := range left.Values() {
+			result.Add(element)
+		}
+		for _, element := range right.Values() {
+			result.Add(element)
+		}
+	case "intersection":
+		for _, element := range left.Values() {
+			if right.Contains(element) {
+				result.Add(element)
+			}
+		}
+	case "difference":
+		for _, element := range left.Values() {
+			if !right.Contains(element) {
+				result.Add(element)
+			}
+		}
+	case "symmetric_difference":
+		for _, element := range left.Values() {
+			if !right.Contains(element) {
+				result.Add(element)
+			}
+		}
+		for _, element := range right.Values() {
+			if !left.Contains(element) {
+				result.Add(element)
+			}
+		}
+	}
+	return result
+}
+
+// is_subset reports whether every element of left is in right
+func is_subset(left, right *object.Set) bool {
+	for _, element := range left.Values() {
+		if !right.Contains(element) {
+			return false
+		}
+	}
+	return true
+}
+
+// eval_set_infix_expression gives sets the operators of their bitwise counterparts:
+// | is union, & intersection, ~ symmetric difference and - difference
+func eval_set_infix_expression(operator string, left, right *object.Set) object.Object {
+	switch operator {
+	case "|":
+		return combine_sets("union", left, right)
+	case "&":
+		return combine_sets("intersection", left, right)
+	case "~":
+		return combine_sets("symmetric_difference", left, right)
+	case "-":
+		return combine_sets("difference", left, right)
+	case "==":
+		return native_bool(is_equal(left, right))
+	case "!=":
+		return native_bool(!is_equal(left, right))
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// Tuple Methods
+
+func call_tuple_method(tuple *object.Tuple, method_name string, args []object.Object) object.Object {
+	switch method_name {
+	case "length":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Tuple.length. got=%d, want=0", len(args))
+		}
+		return &object.Number{Value: float64(len(tuple.Elements))}
+
+	case "to_array":
+		if len(args) != 0 {
+			return object.NewError("wrong number of arguments for Tuple.to_array. got=%d, want=0", len(args))
+		}
+		elements := make([]object.Object, len(tuple.Elements))
+		copy(elements, tuple.Elements)
+		return &object.Array{Elements: elements}
+	}
+
+	// Methods that read the elements behave as on an array of a copy, so the tuple never changes
+	if collected_methods[method_name] {
+		return call_array_method(call_tuple_method(tuple, "to_array", nil).(*object.Array), method_name, args)
+	}
+	return object.NewError("method '%s' not found on Tuple", method_name)
+}
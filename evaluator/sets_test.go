@@ -0,0 +1,111 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/vpaulo/seda/object"
+)
+
+// Set and Tuple Tests
+
+func TestSets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{3, 1, 3, 2}", "{3, 1, 2}"},
+		{"Set([1, 2, 1])", "{1, 2}"},
+		{"Set()", "Set()"},
+		{`Set("abba")`, `{"a", "b"}`},
+		{"Set(1..4)", "{1, 2, 3}"},
+		{"{1, 1.0, 2}.length()", "2"},
+		{"{1, 2}.contains(2)", "true"},
+		{"{1, 2}.contains([2])", "false"},
+		{"{1, 2}.union({2, 3})", "{1, 2, 3}"},
+		{"{1, 2, 3}.intersection([2, 3, 4])", "{2, 3}"},
+		{"{1, 2, 3}.difference({2})", "{1, 3}"},
+		{"{1, 2}.symmetric_difference({2, 3})", "{1, 3}"},
+		{"{1, 2} | {3}", "{1, 2, 3}"},
+		{"{1, 2} & {2}", "{2}"},
+		{"{1, 2} - {2}", "{1}"},
+		{"{1, 2} ~ {2, 3}", "{1, 3}"},
+		{"{1, 2}.is_subset({1, 2, 3})", "true"},
+		{"{1, 2}.is_superset({3})", "false"},
+		{"{1, 2}.is_disjoint({3})", "true"},
+		{"{1, 2} == {2, 1}", "true"},
+		{"{1, 2} != {1}", "true"},
+		{"var s = Set()\ns.add(1)\ns.add(1)\ns.add(2)\ns.remove(1)\ns", "{2}"},
+		{"{1, 2, 3}.filter(x => x > 1)", "{2, 3}"},
+		{"{1, 2, 3}.map(x => x % 2)", "{1, 0}"},
+		{"{3, 1, 2}.sort()", "[1, 2, 3]"},
+		{"{1, 2, 3}.sum()", "6"},
+		{"{1, 2}.to_array()", "[1, 2]"},
+		{"var total = 0\nfor x in {1, 2, 3} ::\n  total += x\nend\ntotal", "6"},
+		{"{Tuple(1, 2), Tuple(1, 2)}.length()", "1"},
+		// Numbers that compare equal are one element, as they are one map key
+		{"Set([0.1 + 0.2]).contains(0.3)", "true"},
+		{"{0.1 + 0.2, 0.3}.length()", "1"},
+		{"{Tuple(0.1 + 0.2, 1)}.contains(Tuple(0.3, 1.0))", "true"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+}
+
+func TestTuples(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`Tuple(1, "a")`, `(1, "a")`},
+		{"Tuple(1)", "(1,)"},
+		{"Tuple(1, 2)[1]", "2"},
+		{"Tuple(1, 2)[5]", "null"},
+		{"Tuple(1, 2, 3).length()", "3"},
+		{"Tuple(1, 2) == Tuple(1, 2)", "true"},
+		{"Tuple(1, 2) != Tuple(2, 1)", "true"},
+		{"Tuple(3, 1).sort()", "[1, 3]"},
+		{"Tuple(1, 2).to_array()", "[1, 2]"},
+		{"var [x, y] = Tuple(1, 2)\nx + y", "3"},
+		{"var grid = {}\ngrid[Tuple(0, 1)] = \"wall\"\ngrid[Tuple(0, 1)]", `"wall"`},
+		{"var grid = {Tuple(2, 3): 5}\ngrid[Tuple(2, 3)]", "5"},
+	}
+
+	for _, tt := range tests {
+		testInspect(t, tt.input, tt.expected)
+	}
+
+	if !is_equal(testEval("Tuple(1, [2])"), testEval("Tuple(1, [2])")) {
+		t.Errorf("tuples with equal elements should be equal")
+	}
+}
+
+func TestSetErrors(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"{1, [2]}", "unhashable type: ARRAY"},
+		{"Set([{}])", "unhashable type: MAP"},
+		{"Set(5)", "argument to Set must be iterable, got NUMBER"},
+		{"Set(1, 2)", "wrong number of arguments for Set. got=2, want=0 or 1"},
+		{"{1}.union(5)", "argument to Set.union must be iterable, got NUMBER"},
+		{"{1}.add(Tuple([1]))", "unhashable type: TUPLE"},
+		{"const fixed = {1}\nfixed.add(2)", "cannot call add() on immutable set"},
+		{"{1}.push(2)", "method 'push' not found on Set"},
+		{"Tuple(1).push(2)", "method 'push' not found on Tuple"},
+		{"{1} + {2}", "unknown operator: SET + SET"},
+	}
+
+	for _, tt := range tests {
+		errObj, ok := testEval(tt.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tt.input)
+			continue
+		}
+		if errObj.Message != tt.expectedMessage {
+			t.Errorf("wrong error message for %q. expected=%q, got=%q", tt.input, tt.expectedMessage, errObj.Message)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package evaluator
+
+import (
+	"reflect"
+
+	"github.com/vpaulo/seda/ast"
+	"github.com/vpaulo/seda/lexer"
+	"github.com/vpaulo/seda/object"
+)
+
+// call_frame is an active call to a user-defined function
+type call_frame struct {
+	function    string
+	caller_file string
+	call_line   int // line of the caller statement that made the call
+}
+
+// call_stack holds the user function calls being evaluated, outermost first
+var call_stack []call_frame
+
+// current_file and current_line track the statement being evaluated so calls know where they came from
+var current_file string
+var current_line int
+
+// node_position returns where a node starts, tolerating nil nodes left by parse errors
+func node_position(node ast.Node) lexer.Token {
+	if node == nil {
+		return lexer.Token{}
+	}
+	if value := reflect.ValueOf(node); value.Kind() == reflect.Ptr && value.IsNil() {
+		return lexer.Token{}
+	}
+	return node.Position()
+}
+
+// track_statement records the statement about to be evaluated
+func track_statement(statement ast.Statement, env *object.Environment) {
+	if position := node_position(statement); position.Line > 0 {
+		current_file, current_line = env.SourceFile, position.Line
+	}
+}
+
+// push_call records a call to fn made from the current statement
+func push_call(fn *object.Function) call_frame {
+	name := fn.Name
+	if name == "" {
+		name = "<anonymous>"
+	}
+
+	frame := call_frame{function: name, caller_file: current_file, call_line: current_line}
+	call_stack = append(call_stack, frame)
+	return frame
+}
+
+// pop_call removes the innermost call and returns to the caller's statement
+func pop_call(frame call_frame) {
+	call_stack = call_stack[:len(call_stack)-1]
+	current_file, current_line = frame.caller_file, frame.call_line
+}
+
+// locate_error records where a runtime error originated and the calls that led to it.
+// Errors that already know their origin are left untouched as they propagate outwards.
+func locate_error(err *object.Error, node ast.Node, env *object.Environment) {
+	if err.IsUserCreated || err.HasPosition() {
+		return
+	}
+
+	position := node_position(node)
+	if position.Line == 0 {
+		return
+	}
+
+	err.File, err.Line, err.Column = env.SourceFile, position.Line, position.Column
+	err.Stack = capture_stack(env.SourceFile, position.Line)
+}
+
+// capture_stack builds a traceback ending at the given line of the innermost call
+func capture_stack(file string, line int) []object.StackFrame {
+	frames := []object.StackFrame{}
+	function := "<main>"
+	for _, frame := range call_stack {
+		frames = append(frames, object.StackFrame{Function: function, File: frame.caller_file, Line: frame.call_line})
+		function = frame.function
+	}
+	return append(frames, object.StackFrame{Function: function, File: file, Line: line})
+}
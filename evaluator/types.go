@@ -0,0 +1,199 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vpaulo/seda/ast"
+	"github.com/vpaulo/seda/object"
+)
+
+// max_type_alias_depth guards against type aliases that refer to each other
+const max_type_alias_depth = 64
+
+// type_matches checks if a value satisfies a type annotation.
+// Type aliases and struct names are resolved through env.
+func type_matches(annotation *ast.TypeAnnotation, value object.Object, env *object.Environment) (bool, *object.Error) {
+	return type_matches_depth(annotation, value, env, 0)
+}
+
+func type_matches_depth(annotation *ast.TypeAnnotation, value object.Object, env *object.Environment, depth int) (bool, *object.Error) {
+	if depth > max_type_alias_depth {
+		return false, object.NewError("type alias '%s' refers to itself", annotation.Name)
+	}
+
+	switch strings.ToLower(annotation.Name) {
+	case "any":
+		return true, nil
+	case "number":
+		return value.Type() == object.NUMBER_OBJ, nil
+	case "string":
+		return value.Type() == object.STRING_OBJ, nil
+	case "boolean", "bool":
+		return value.Type() == object.BOOLEAN_OBJ, nil
+	case "null", "nil":
+		return value.Type() == object.NULL_OBJ, nil
+	case "function", "fn":
+		switch value.Type() {
+		case object.FUNCTION_OBJ, object.BUILTIN_OBJ, object.STRUCT_OBJ:
+			return true, nil
+		}
+		return false, nil
+	case "range":
+		return value.Type() == object.RANGE_OBJ, nil
+	case "iterator":
+		return value.Type() == object.ITERATOR_OBJ, nil
+	case "set":
+		return value.Type() == object.SET_OBJ, nil
+	case "tuple":
+		return value.Type() == object.TUPLE_OBJ, nil
+	case "time":
+		return value.Type() == object.TIME_OBJ, nil
+	case "error":
+		return value.Type() == object.ERROR_OBJ, nil
+	case "array":
+		array, ok := value.(*object.Array)
+		if !ok {
+			return false, nil
+		}
+		// Array[T] checks every element against T
+		if len(annotation.Parameters) == 0 {
+			return true, nil
+		}
+		for _, element := range array.Elements {
+			matches, err := type_matches_depth(annotation.Parameters[0], element, env, depth)
+			if err != nil || !matches {
+				return false, err
+			}
+		}
+		return true, nil
+	case "map":
+		map_obj, ok := value.(*object.Map)
+		if !ok {
+			return false, nil
+		}
+		// Map[V] checks values, Map[K, V] checks keys and values
+		if len(annotation.Parameters) == 0 {
+			return true, nil
+		}
+		value_type := annotation.Parameters[len(annotation.Parameters)-1]
+		for _, pair := range map_obj.Entries() {
+			if len(annotation.Parameters) > 1 {
+				matches, err := type_matches_depth(annotation.Parameters[0], pair.Key, env, depth)
+				if err != nil || !matches {
+					return false, err
+				}
+			}
+			matches, err := type_matches_depth(value_type, pair.Value, env, depth)
+			if err != nil || !matches {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	// User-defined types: aliases and structs
+	if resolved, ok := env.Get(annotation.Name); ok {
+		switch user_type := resolved.(type) {
+		case *object.TypeAlias:
+			if user_type.IsSumType() {
+				instance, ok := value.(*object.StructInstance)
+				return ok && instance.Struct.SumType == user_type, nil
+			}
+			return type_matches_depth(user_type.TypeAnnotation, value, env, depth+1)
+		case *object.Struct:
+			instance, ok := value.(*object.StructInstance)
+			return ok && instance.Struct == user_type, nil
+		}
+	}
+
+	return false, object.NewError("unknown type '%s'", annotation.Name)
+}
+
+// describe_type returns the user-facing type name of a value for error messages
+func describe_type(value object.Object) string {
+	switch v := value.(type) {
+	case *object.StructInstance:
+		return v.Struct.Name
+	case *object.Builtin:
+		return "function"
+	}
+	return strings.ToLower(get_user_friendly_type_name(string(value.Type())))
+}
+
+// check_value_type returns a type error describing what was being checked, or nil if value matches.
+// context is only called to build the message of a failure, since checks run on every assignment and call.
+func check_value_type(annotation *ast.TypeAnnotation, value object.Object, env *object.Environment, context func() string) *object.Error {
+	if annotation == nil {
+		return nil
+	}
+
+	matches, err := type_matches(annotation, value, env)
+	if err != nil {
+		return type_error(object.NewError("%s: %s", context(), err.Message))
+	}
+	if !matches {
+		return type_error(object.NewError("%s expects %s, got %s", context(), annotation.String(), describe_type(value)))
+	}
+	return nil
+}
+
+// type_error marks an error as a type annotation failure
+func type_error(err *object.Error) *object.Error {
+	err.Kind = "type"
+	return err
+}
+
+// check_variable_type validates a value assigned to a typed variable
+func check_variable_type(annotation *ast.TypeAnnotation, name string, value object.Object, env *object.Environment) *object.Error {
+	if annotation == nil {
+		return nil
+	}
+	return check_value_type(annotation, value, env, func() string {
+		return fmt.Sprintf("type error: variable '%s'", name)
+	})
+}
+
+// check_field_type validates a value stored in a struct field
+func check_field_type(struct_type *object.Struct, name string, value object.Object) *object.Error {
+	for _, field := range struct_type.Fields {
+		if field.Name.Value == name {
+			return check_value_type(field.Type, value, struct_type.Env, func() string {
+				return fmt.Sprintf("type error in %s: field '%s'", struct_type.Name, name)
+			})
+		}
+	}
+	return nil
+}
+
+// function_description names a function for error messages
+func function_description(fn *object.Function) string {
+	if fn.Name == "" {
+		return "anonymous function"
+	}
+	return fmt.Sprintf("function '%s'", fn.Name)
+}
+
+// check_parameter_type validates an argument bound to an annotated parameter
+func check_parameter_type(fn *object.Function, param *ast.Parameter, value object.Object) *object.Error {
+	return check_value_type(param.Type, value, fn.Env, func() string {
+		return fmt.Sprintf("type error in %s: parameter '%s'", function_description(fn), param.Name.Value)
+	})
+}
+
+// check_return_type validates a function result against its declared return type
+func check_return_type(fn *object.Function, result object.Object) *object.Error {
+	if fn.ReturnType == nil || result == nil {
+		return nil
+	}
+
+	// Errors propagate untouched and multiple return values are not annotated as a whole
+	switch result.Type() {
+	case object.ERROR_OBJ, object.MULTI_VALUE_OBJ:
+		return nil
+	}
+
+	return check_value_type(fn.ReturnType, result, fn.Env, func() string {
+		return fmt.Sprintf("type error in %s: return value", function_description(fn))
+	})
+}
@@ -68,6 +68,14 @@ check "Array.map_with_index() method" ::
   result[2] is 32
 end
 
+check "Arrow function callbacks" ::
+  arr.map(x => x * 2) is [6, 2, 8, 2, 10, 18]
+  arr.filter(x => x > 3) is [4, 5, 9]
+  arr.reduce((sum, x) => sum + x, 0) is 23
+  [3, 1, 4].sort_by(x => -x).first() is 4
+  arr.find(x => x % 2 == 0) is 4
+end
+
 # =====================================
 # Finding Methods
 # =====================================
@@ -15,6 +15,57 @@ check "arithmetic operations" ::
   10 - 4 is 6
   6 * 7 is 42
   20 / 4 is 5
+  7 / 2 is 3.5
+  7 // 2 is 3
+end
+
+# Exact Numbers
+check "exact numbers" ::
+  12345678901 * 1000 is 12345678901000
+  (2 ^ 64).to_string() is "18446744073709551616"
+  9007199254740993 - 9007199254740992 is 1
+  (decimal("0.1") + decimal("0.2")).to_string() is "0.3"
+  (decimal("19.99") * 3).to_string() is "59.97"
+end
+
+# Bitwise Operations
+check "bitwise operations" ::
+  12 & 10 is 8
+  12 | 10 is 14
+  12 ~ 10 is 6
+  ~0 is -1
+  1 << 4 is 16
+  -16 >> 2 is -4
+  (513 >> 8) & 255 is 2
+end
+
+# Compound Assignment
+fn compound_updates() ::
+  var count = 10
+  count += 5
+  count -= 3
+  count *= 2
+  count /= 4
+
+  var greeting = "Hello"
+  greeting += ", world"
+
+  var items = [1]
+  items += 2
+  items += [3, 4]
+
+  var tally = {"apples": 1}
+  tally["apples"] += 2
+  return count, greeting, items, tally
+end
+
+check "compound assignment" ::
+  var count, greeting, items, tally = compound_updates()
+
+  count is 6
+  greeting is "Hello, world"
+  items is [1, 2, 3, 4]
+  tally.apples is 3
 end
 
 # String Operations
@@ -62,6 +113,29 @@ check "map operations" ::
   var obj = {"name": "Alice", "age": 25}
   obj["name"] is "Alice"
   obj["age"] is 25
+
+  var scores = {"ana": 90, "bo": 70, "cy": 85}
+  scores.keys() is ["ana", "bo", "cy"]
+  scores.has("bo") is true
+  scores.get("dee", 0) is 0
+  scores.filter((name, score) => score > 80).length() is 2
+  scores.map_values(score => score / 10)["ana"] is 9
+  scores.merge({"dee": 60}).length() is 4
+  scores.invert()[70] is "bo"
+end
+
+check "sets and tuples" ::
+  var tags = {"go", "seda", "go"}
+  tags.length() is 2
+  tags contains "seda"
+  (tags | {"lua"}).length() is 3
+  (tags & {"go", "ruby"}) is {"go"}
+  tags.difference(["go"]) is {"seda"}
+  {"go"}.is_subset(tags) is true
+
+  var grid = {Tuple(0, 1): "wall"}
+  grid[Tuple(0, 1)] is "wall"
+  Tuple(0, 1) == Tuple(0, 1) is true
 end
 
 # Functions
@@ -78,6 +152,34 @@ check "functions" ::
   greet("Alice") is "Hello, Alice"
 end
 
+# Default values, named arguments and rest parameters
+fn describe(name, greeting = "Hello", ...titles) ::
+  var label = greeting + ", " + name
+  for title in titles ::
+    label = label + " " + title
+  end
+  return label
+end
+
+check "parameters" ::
+  describe("Ana") is "Hello, Ana"
+  describe("Ana", "Hi") is "Hi, Ana"
+  describe(greeting: "Hey", name: "Bo") is "Hey, Bo"
+  describe("Cy", "Dear", "PhD", "MD") is "Dear, Cy PhD MD"
+  describe() raises "wrong number of arguments"
+end
+
+# Pipelines
+fn total(items) ::
+  return items.sum()
+end
+
+check "pipelines" ::
+  5 |> add(3) is 8
+  "Alice" |> greet is "Hello, Alice"
+  [1, 2, 3, 4].filter(fn(n) :: return n > 1 end) |> total |> add(1) is 10
+end
+
 # Closures
 fn makeCounter() ::
   var count = 0
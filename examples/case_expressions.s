@@ -154,6 +154,40 @@ check "Case expression in array indexing" ::
   priority is "high"
 end
 
+# Patterns destructure arrays, maps and structs and bind names for the branch
+struct Point ::
+  x: number,
+  y: number
+end
+
+fn describe(value) ::
+  return case value ::
+    0 | 1 => "tiny"
+    n: number if n < 0 => "negative"
+    2..10 => "small"
+    n: number => "number #{n}"
+    [] => "empty"
+    [first, ...rest] => "#{first} and #{rest.length()} more"
+    {"type": "circle", "r": r} => "circle of radius #{r}"
+    Point(0, y) => "on the y axis at #{y}"
+    Point(x, y) => "point #{x},#{y}"
+    _ => "something else"
+  end
+end
+
+check "Case patterns" ::
+  describe(1) is "tiny"
+  describe(-4) is "negative"
+  describe(7) is "small"
+  describe(42) is "number 42"
+  describe([]) is "empty"
+  describe([1, 2, 3]) is "1 and 2 more"
+  describe({"type": "circle", "r": 3}) is "circle of radius 3"
+  describe(Point(0, 5)) is "on the y axis at 5"
+  describe(Point(2, 3)) is "point 2,3"
+  describe("text") is "something else"
+end
+
 # Test that case expressions return correct values
 check "case expression tests" ::
   case "A" ::
@@ -65,7 +65,6 @@ check "for loops with maps" ::
 end
 
 # While Loops
-#|
 fn factorial(n) ::
   var result = 1
   var i = 1
@@ -81,6 +80,95 @@ check "while loops" ::
   factorial(3) is 6
   factorial(1) is 1
 end
-|#
+
+# Continue and labeled loops
+fn sum_odd(numbers) ::
+  var total = 0
+  for n in numbers ::
+    if n % 2 == 0 ::
+      continue
+    end
+    total = total + n
+  end
+  return total
+end
+
+fn find_pair(numbers, target) ::
+  var found = nil
+  search: for a in numbers ::
+    for b in numbers ::
+      if a + b == target ::
+        found = [a, b]
+        break search
+      end
+    end
+  end
+  return found
+end
+
+check "continue and labeled break" ::
+  sum_odd([1, 2, 3, 4, 5]) is 9
+  find_pair([1, 4, 6], 10) is [4, 6]
+  find_pair([1, 2], 10) is nil
+end
+
+# Generators pause at each yield until the loop asks for the next value
+fn fibonacci() ::
+  var a = 0
+  var b = 1
+  while true ::
+    yield a
+    var next = a + b
+    a = b
+    b = next
+  end
+end
+
+fn first_fibs(count) ::
+  var fibs = []
+  for i, n in fibonacci() ::
+    if i == count ::
+      break
+    end
+    fibs.push(n)
+  end
+  return fibs
+end
+
+struct Countdown ::
+  from: number
+end
+
+# An iter method makes instances iterable
+fn Countdown.iter() ::
+  var n = self.from
+  while n > 0 ::
+    yield n
+    n -= 1
+  end
+end
+
+fn launch(from) ::
+  var calls = []
+  for n in Countdown(from) ::
+    calls.push(n)
+  end
+  return calls
+end
+
+check "generators and iterable structs" ::
+  first_fibs(7) is [0, 1, 1, 2, 3, 5, 8]
+  fibonacci().next() is 0
+  launch(3) is [3, 2, 1]
+  Countdown(4).filter(n => n % 2 == 0).collect() is [4, 2]
+  Countdown(3).sum() is 6
+end
+
+check "lazy sequences" ::
+  Seq.count_from(1).map(x => x * x).take(3).collect() is [1, 4, 9]
+  fibonacci().take_while(n => n < 10).collect() is [0, 1, 1, 2, 3, 5, 8]
+  Seq.from([1, 2, 3, 4]).window(2).collect() is [[1, 2], [2, 3], [3, 4]]
+  ([1, 2, 3] |> Seq.map(x => x * 2) |> Seq.reduce((a, b) => a + b, 0)) is 12
+end
 
 println("✓ All control flow tests passed!")
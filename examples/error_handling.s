@@ -45,4 +45,86 @@ check "Function that propagates errors, fail case" ::
   err.to_string is "division by zero"
 end
 
+# try/catch intercepts runtime errors and thrown errors
+fn load_config(text) ::
+    var config, err = JSON.parse(text)
+    if !isNull(err) ::
+        throw err  # Raise the error so callers can catch it
+    end
+    return config
+end
+
+check "Catching a thrown error" ::
+  var message = ""
+  try ::
+    load_config("{broken")
+  catch err ::
+    message = err.message
+  end
+
+  message startsWith "invalid JSON"
+end
+
+check "Catching a runtime error and always running ensure" ::
+  var steps = []
+  try ::
+    var ratio = 10 / 0
+    steps.push("unreachable")
+  catch err ::
+    steps.push(err.message)
+  ensure ::
+    steps.push("cleanup")
+  end
+
+  steps is ["division by zero", "cleanup"]
+end
+
+check "Rethrowing to an outer handler" ::
+  var handled = ""
+  try ::
+    try ::
+      throw "disk full"
+    catch err ::
+      throw err
+    end
+  catch outer ::
+    handled = outer.message
+  end
+
+  handled is "disk full"
+end
+
+# Errors carry a kind, optional data and the error they wrap
+fn read_settings(path) ::
+    var content, err = File.read(path)
+    if !isNull(err) ::
+        return nil, err.wrap("cannot load settings")
+    end
+    return content, nil
+end
+
+check "Branching on error kinds" ::
+  var settings, err = read_settings("/missing/settings.json")
+
+  err.message is "cannot load settings"
+  err.kind is "io"
+  err.is("io") isTrue
+  err.cause.data["path"] is "/missing/settings.json"
+end
+
+fn validate_port(port) ::
+    if port > 65535 ::
+        return nil, error("port out of range", "config", {"port": port})
+    end
+    return port, nil
+end
+
+check "Custom kinds and data" ::
+  var port, err = validate_port(70000)
+
+  err.kind is "config"
+  err.data["port"] is 70000
+  err.is("io") isFalse
+end
+
 println("✓ All error handling tests passed!")
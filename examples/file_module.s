@@ -94,6 +94,21 @@ check "File.read_lines - read as array" ::
   var _ = File.delete(test_file)
 end
 
+# Test File.lines - read lines on demand
+check "File.lines - iterate without loading the file" ::
+  var test_file = "/tmp/seda_test_lazy_lines.txt"
+  var _ = File.write(test_file, "first\nsecond\nthird\n")
+
+  var lines, err = File.lines(test_file)
+  isNull(err) isTrue
+  lines.next() is "first"
+  lines.to_array() is ["second", "third"]
+  lines.next() is nil
+
+  # Clean up
+  var _ = File.delete(test_file)
+end
+
 # Test File.append - append to file
 check "File.append - append content" ::
   var test_file = "/tmp/seda_test_append.txt"
@@ -61,6 +61,18 @@ check "JSON.parse - null value" ::
   isNull(obj["value"]) isTrue
 end
 
+# Test JSON.parse - optional fields with nil-safe operators
+check "JSON.parse - optional fields" ::
+  var json_str = "{\"person\": {\"name\": \"Bob\", \"tags\": [\"admin\"]}, \"manager\": null}"
+  var obj, err = JSON.parse(json_str)
+  obj?.person?.name is "Bob"
+  obj?.person?.tags?[0] is "admin"
+  isNull(obj?.manager?.name) isTrue
+  isNull(obj?.person?.address?.city) isTrue
+  obj?.person?.address?.city ?? "unknown" is "unknown"
+  obj?.manager?.tags?[0] ?? "none" is "none"
+end
+
 # Test JSON.parse - string values
 check "JSON.parse - string values" ::
   var json_str = "{\"message\": \"hello world\", \"greeting\": \"hi\"}"
@@ -160,10 +172,14 @@ check "JSON round-trip - parse then stringify" ::
   var obj, err = JSON.parse(original)
   isNull(err) isTrue
   var json = JSON.stringify(obj)
-  json contains "\"name\""
-  json contains "\"Alice\""
-  json contains "\"age\""
-  json contains "30"
+  json is original
+end
+
+# Test key order: maps keep the order their keys were added in
+check "JSON keeps key order" ::
+  var config = {"zone": "eu", "app": "seda", "debug": false}
+  JSON.stringify(config) is "{\"zone\":\"eu\",\"app\":\"seda\",\"debug\":false}"
+  "#{config}" is "{\"zone\": \"eu\", \"app\": \"seda\", \"debug\": false}"
 end
 
 # Test round-trip: stringify then parse
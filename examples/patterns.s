@@ -1,6 +1,6 @@
 println("Running patterns tests...")
 # Custom Type Patterns - Advanced OOP Patterns
-# Demonstrating inheritance-like behavior, composition, and factories
+# Demonstrating prototype inheritance, composition, and factories
 
 # ==========================================
 # 1. FACTORY PATTERN - Creating similar objects
@@ -230,55 +230,54 @@ check "State pattern" ::
 end
 
 # ==========================================
-# 5. INHERITANCE-LIKE PATTERN
+# 5. PROTOTYPE INHERITANCE
 # ==========================================
 
-fn createAnimal(name, species) ::
-  var animal = {"name": name, "species": species}
-
-  animal.speak = fn(self) ::
-    return self["name"] + " makes a sound"
-  end
+# Methods live on a prototype map and every animal made from it shares them
+var Animal = {}
 
-  animal.describe = fn(self) ::
-    return self["name"] + " is a " + self["species"]
-  end
+Animal.speak = fn(self) ::
+  return self["name"] + " makes a sound"
+end
 
-  return animal
+Animal.describe = fn(self) ::
+  return self["name"] + " is a " + self["species"]
 end
 
-fn createDog(name, breed) ::
-  # Create base animal
-  var dog = createAnimal(name, "Dog")
+fn createAnimal(name, species) ::
+  return Object.extend(Animal, {"name": name, "species": species})
+end
 
-  # Add dog-specific properties
-  dog["breed"] = breed
+# Dog inherits from Animal, overriding speak and extending describe through super
+var Dog = Object.extend(Animal, {"species": "Dog"})
 
-  # Override speak method
-  dog.speak = fn(self) ::
-    return self["name"] + " barks: Woof!"
-  end
+Dog.speak = fn(self) ::
+  return self["name"] + " barks: Woof!"
+end
 
-  # Add new method
-  dog.fetch = fn(self) ::
-    return self["name"] + " fetches the ball!"
-  end
+Dog.fetch = fn(self) ::
+  return self["name"] + " fetches the ball!"
+end
 
-  # Extend describe method
-  const originalDescribe = dog.describe
-  dog.describe = fn(self) ::
-    return originalDescribe + " (Breed: " + self["breed"] + ")"
-  end
+Dog.describe = fn(self) ::
+  return super.describe + " (Breed: " + self["breed"] + ")"
+end
 
-  return dog
+fn createDog(name, breed) ::
+  return Object.extend(Dog, {"name": name, "breed": breed})
 end
 
 check "Inheritance pattern" ::
   var myDog = createDog("Max", "Golden Retriever")
+  var myCat = createAnimal("Tom", "Cat")
 
   myDog.describe is "Max is a Dog (Breed: Golden Retriever)"
   myDog.speak is "Max barks: Woof!"
   myDog.fetch is "Max fetches the ball!"
+  myCat.speak is "Tom makes a sound"
+  myDog isA Dog
+  myDog isA Animal
+  myCat isA Animal
 end
 
 # ==========================================
@@ -470,7 +470,7 @@ module TodoList ::
         return todo
       end
     end
-    return null
+    return nil
   end
 
   # Update a todo
@@ -495,7 +495,7 @@ module TodoList ::
         return todos[i]
       end
     end
-    return null
+    return nil
   end
 
   # Delete a todo
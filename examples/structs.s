@@ -0,0 +1,128 @@
+# Struct Test Suite
+
+println("Running struct tests...")
+
+struct Point ::
+  x: number,
+  y: number
+end
+
+# Methods bind self to the instance
+fn Point.sum() ::
+  return self.x + self.y
+end
+
+fn Point.scale(factor) ::
+  return Point(x: self.x * factor, y: self.y * factor)
+end
+
+fn Point.move_by(dx, dy) ::
+  self.x = self.x + dx
+  self.y = self.y + dy
+end
+
+check "construction with named arguments" ::
+  var p = Point(x: 1, y: 2)
+
+  p.x is 1
+  p.y is 2
+  p isA Point
+  p isA "Point"
+end
+
+check "construction with positional arguments" ::
+  var p = Point(3, 4)
+
+  p.x is 3
+  p.y is 4
+end
+
+check "field validation" ::
+  Point(x: 1) raises "missing field 'y'"
+  Point(x: 1, y: 2, z: 3) raises "unknown field 'z'"
+  Point(1, 2, 3) raises "too many arguments"
+end
+
+var moved = Point(x: 1, y: 2)
+moved.x = 10
+
+check "field assignment" ::
+  moved.x is 10
+  moved.y is 2
+end
+
+var shifted = Point(x: 2, y: 3)
+shifted.move_by(1, 1)
+
+check "methods" ::
+  var p = Point(x: 2, y: 3)
+  var doubled = p.scale(2)
+
+  doubled.x is 4
+  doubled.y is 6
+  p.sum is 5
+  shifted.sum is 7
+end
+
+check "struct equality" ::
+  var a = Point(x: 1, y: 2)
+  var b = Point(x: 1, y: 2)
+  var c = Point(x: 2, y: 1)
+
+  a is b
+  a isNot c
+  (a == b) is true
+  {a: "start"}[b] is "start"
+end
+
+struct Money ::
+  cents: number,
+  currency: string
+end
+
+# An equals method decides equality; hash keeps map keys consistent with it
+fn Money.equals(other) ::
+  return self.cents == other.cents
+end
+
+fn Money.hash() ::
+  return self.cents
+end
+
+check "custom equality" ::
+  var euros = Money(cents: 500, currency: "EUR")
+  var dollars = Money(cents: 500, currency: "USD")
+
+  euros is dollars
+  {euros: "five"}[dollars] is "five"
+end
+
+const origin = Point(x: 0, y: 0)
+
+check "const instances are immutable" ::
+  origin.x = 5 raises "cannot modify immutable Point"
+end
+
+# Sum types list a closed set of variants; each variant is constructed like a struct
+type Shape = Circle(radius: number) | Rect(w: number, h: number) | Empty
+
+fn area(shape: Shape) ::
+  return case shape ::
+    Circle(r) => 3 * r * r
+    Rect(w, h) => w * h
+    Empty => 0
+  end
+end
+
+check "sum types" ::
+  area(Circle(2)) is 12
+  area(Rect(w: 2, h: 5)) is 10
+  area(Empty) is 0
+
+  Circle(1) isA Shape
+  Empty isA Shape
+  Circle(1) isA Circle
+  Rect(1, 2).h is 2
+end
+
+println("✓ All struct tests passed!")
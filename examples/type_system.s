@@ -52,4 +52,39 @@ check "complex type aliases" ::
   vector.first is 1
 end
 
+# Runtime Enforcement of Annotations
+fn add(a: number, b: number): number ::
+  return a + b
+end
+
+fn broken_name(): string ::
+  return 42
+end
+
+type Tags = Array[String]
+
+fn tag_count(tags: Tags): number ::
+  return tags.length()
+end
+
+check "annotated parameters and returns" ::
+  add(1, 2) is 3
+  add("1", 2) raises "parameter 'a' expects number, got string"
+  broken_name() raises "return value expects string, got number"
+end
+
+check "generic and aliased annotations" ::
+  var tags = ["a", "b"]
+  var mixed = ["a", 1]
+
+  tag_count(tags) is 2
+  tag_count(mixed) raises "parameter 'tags' expects Tags, got array"
+end
+
+var counter: number = 0
+
+check "typed variables keep their type" ::
+  counter = "one" raises "variable 'counter' expects number, got string"
+end
+
 println("✓ All type system tests passed!")
@@ -47,4 +47,40 @@ check "Function that returns quotient and remainder" ::
   r is 2
 end
 
+# Array and map patterns
+
+check "Array patterns with rest and defaults" ::
+  var [first, second = 0, ...rest] = [1, 2, 3, 4]
+
+  first is 1
+  second is 2
+  rest is [3, 4]
+
+  var [only, missing = "none"] = ["one"]
+  missing is "none"
+end
+
+check "Map patterns with nested keys" ::
+  const {name, address: {city}, "home page": url = ""} = {"name": "Alice", "address": {"city": "Lisbon"}}
+
+  name is "Alice"
+  city is "Lisbon"
+  url is ""
+end
+
+fn span([start, finish], {step = 1}) ::
+  return (finish - start) / step
+end
+
+check "Patterns in loops and parameters" ::
+  var total = 0
+  for [key, value] in [[1, 2], [3, 4]] ::
+    total = total + key * value
+  end
+  total is 14
+
+  span([2, 10], {"step": 2}) is 4
+  span([0, 3], {}) is 3
+end
+
 println("✓ All value destructuring tests passed!")
@@ -32,6 +32,19 @@ fn createVector2D(x, y) ::
     return "Vec2(" + self["x"].to_string + ", " + self["y"].to_string + ")"
   end
 
+  # Operator methods give vectors +, * and == of their own
+  vec.__add = fn(self, other) ::
+    return self.add(other)
+  end
+
+  vec.__mul = fn(self, scalar) ::
+    return self.scale(scalar)
+  end
+
+  vec.__eq = fn(self, other) ::
+    return (self["x"] == other["x"]) && (self["y"] == other["y"])
+  end
+
   return vec
 end
 
@@ -46,4 +59,12 @@ check "Vector2D" ::
   v1.dot(v2) is 11
 end
 
+check "Vector2D operators" ::
+  (v1 + v2).to_string is "Vec2(4, 6)"
+  (v1 * 2).to_string is "Vec2(6, 8)"
+  (v1 + v2 == createVector2D(4, 6)) is true
+  (v1 != v2) is true
+  "#{v1}" is "Vec2(3, 4)"
+end
+
 println("✓ All Vector2D tests passed!")
\ No newline at end of file
@@ -176,6 +176,28 @@ func (lexer *Lexer) read_block_comment() string {
 	return lexer.input[start_position:lexer.position]
 }
 
+// operator_or_assign returns the compound assignment token when the operator is followed by =
+func (lexer *Lexer) operator_or_assign(operator, assign TokenType) Token {
+	if lexer.peek_char() == '=' {
+		char := lexer.char
+		lexer.read_char()
+		return new_token(assign, string(char)+string(lexer.char), lexer.line, lexer.column-1)
+	}
+	return new_token(operator, string(lexer.char), lexer.line, lexer.column)
+}
+
+// shift_operator reads << or >>, or their compound assignment <<= or >>=
+func (lexer *Lexer) shift_operator(operator, assign TokenType) Token {
+	start_column := lexer.column
+	char := lexer.char
+	lexer.read_char() // consume the second '<' or '>'
+	if lexer.peek_char() == '=' {
+		lexer.read_char()
+		return new_token(assign, string(char)+string(char)+"=", lexer.line, start_column)
+	}
+	return new_token(operator, string(char)+string(char), lexer.line, start_column)
+}
+
 // skip_whitespace skips whitespace characters (except newlines in some contexts)
 func (lexer *Lexer) skip_whitespace() {
 	for lexer.char == ' ' || lexer.char == '\t' || lexer.char == '\n' || lexer.char == '\r' {
@@ -203,7 +225,7 @@ func (lexer *Lexer) NextToken() Token {
 			tok = new_token(ASSIGN, string(lexer.char), lexer.line, lexer.column)
 		}
 	case '+':
-		tok = new_token(PLUS, string(lexer.char), lexer.line, lexer.column)
+		tok = lexer.operator_or_assign(PLUS, PLUS_ASSIGN)
 	case '-':
 		// TODO: I don't i'm going to use this, but i will keep it for now
 		if lexer.peek_char() == '>' {
@@ -211,16 +233,27 @@ func (lexer *Lexer) NextToken() Token {
 			lexer.read_char()
 			tok = new_token(TYPE_ARROW, string(char)+string(lexer.char), lexer.line, lexer.column-1)
 		} else {
-			tok = new_token(MINUS, string(lexer.char), lexer.line, lexer.column)
+			tok = lexer.operator_or_assign(MINUS, MINUS_ASSIGN)
 		}
 	case '*':
-		tok = new_token(MULTIPLY, string(lexer.char), lexer.line, lexer.column)
+		tok = lexer.operator_or_assign(MULTIPLY, MULTIPLY_ASSIGN)
 	case '/':
-		tok = new_token(DIVIDE, string(lexer.char), lexer.line, lexer.column)
+		if lexer.peek_char() == '/' {
+			start_column := lexer.column
+			lexer.read_char() // consume second '/'
+			if lexer.peek_char() == '=' {
+				lexer.read_char()
+				tok = new_token(FLOOR_DIVIDE_ASSIGN, "//=", lexer.line, start_column)
+			} else {
+				tok = new_token(FLOOR_DIVIDE, "//", lexer.line, start_column)
+			}
+		} else {
+			tok = lexer.operator_or_assign(DIVIDE, DIVIDE_ASSIGN)
+		}
 	case '%':
-		tok = new_token(MODULO, string(lexer.char), lexer.line, lexer.column)
+		tok = lexer.operator_or_assign(MODULO, MODULO_ASSIGN)
 	case '^':
-		tok = new_token(POWER, string(lexer.char), lexer.line, lexer.column)
+		tok = lexer.operator_or_assign(POWER, POWER_ASSIGN)
 	case '!':
 		if lexer.peek_char() == '=' {
 			char := lexer.char
@@ -230,7 +263,9 @@ func (lexer *Lexer) NextToken() Token {
 			tok = new_token(NOT, string(lexer.char), lexer.line, lexer.column)
 		}
 	case '<':
-		if lexer.peek_char() == '=' {
+		if lexer.peek_char() == '<' {
+			tok = lexer.shift_operator(SHIFT_LEFT, SHIFT_LEFT_ASSIGN)
+		} else if lexer.peek_char() == '=' {
 			char := lexer.char
 			lexer.read_char()
 			tok = new_token(LTE, string(char)+string(lexer.char), lexer.line, lexer.column-1)
@@ -238,7 +273,9 @@ func (lexer *Lexer) NextToken() Token {
 			tok = new_token(LT, string(lexer.char), lexer.line, lexer.column)
 		}
 	case '>':
-		if lexer.peek_char() == '=' {
+		if lexer.peek_char() == '>' {
+			tok = lexer.shift_operator(SHIFT_RIGHT, SHIFT_RIGHT_ASSIGN)
+		} else if lexer.peek_char() == '=' {
 			char := lexer.char
 			lexer.read_char()
 			tok = new_token(GTE, string(char)+string(lexer.char), lexer.line, lexer.column-1)
@@ -251,14 +288,35 @@ func (lexer *Lexer) NextToken() Token {
 			lexer.read_char()
 			tok = new_token(AND, string(char)+string(lexer.char), lexer.line, lexer.column-1)
 		} else {
-			tok = new_token(ILLEGAL, string(lexer.char), lexer.line, lexer.column)
+			tok = lexer.operator_or_assign(BIT_AND, BIT_AND_ASSIGN)
 		}
+	case '~':
+		tok = lexer.operator_or_assign(TILDE, TILDE_ASSIGN)
 	case '|':
 		if lexer.peek_char() == '|' {
 			char := lexer.char
 			lexer.read_char()
 			tok = new_token(OR, string(char)+string(lexer.char), lexer.line, lexer.column-1)
+		} else if lexer.peek_char() == '>' {
+			char := lexer.char
+			lexer.read_char()
+			tok = new_token(PIPELINE, string(char)+string(lexer.char), lexer.line, lexer.column-1)
 		} else {
+			tok = lexer.operator_or_assign(PIPE, BIT_OR_ASSIGN)
+		}
+	case '?':
+		// ? only appears in the nil-safe operators ?., ?[ and ??
+		switch lexer.peek_char() {
+		case '.':
+			lexer.read_char()
+			tok = new_token(OPTIONAL_DOT, "?.", lexer.line, lexer.column-1)
+		case '[':
+			lexer.read_char()
+			tok = new_token(OPTIONAL_INDEX, "?[", lexer.line, lexer.column-1)
+		case '?':
+			lexer.read_char()
+			tok = new_token(COALESCE, "??", lexer.line, lexer.column-1)
+		default:
 			tok = new_token(ILLEGAL, string(lexer.char), lexer.line, lexer.column)
 		}
 	case ',':
@@ -319,12 +377,14 @@ func (lexer *Lexer) NextToken() Token {
 	case 0:
 		tok = new_token(EOF, "", lexer.line, lexer.column)
 	default:
+		// Identifiers and numbers are positioned at their first character
+		start_line, start_column := lexer.line, lexer.column
 		if is_letter(lexer.char) {
 			literal := lexer.read_identifier()
-			tok = new_token(LookupIdent(literal), literal, lexer.line, lexer.column)
+			tok = new_token(LookupIdent(literal), literal, start_line, start_column)
 			return tok // early return to avoid read_char() call
 		} else if is_digit(lexer.char) {
-			tok = new_token(NUMBER, lexer.read_number(), lexer.line, lexer.column)
+			tok = new_token(NUMBER, lexer.read_number(), start_line, start_column)
 			return tok // early return to avoid read_char() call
 		} else {
 			tok = new_token(ILLEGAL, string(lexer.char), lexer.line, lexer.column)
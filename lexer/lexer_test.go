@@ -71,7 +71,7 @@ func TestKeywords(t *testing.T) {
 }
 
 func TestOperators(t *testing.T) {
-	input := `= + - * / % == != < > <= >= :: => -> && || !`
+	input := `= + - * / % == != < > <= >= :: => -> && || ! | += -= *= /= %= ^= ?. ?[ ?? |> // //= & ~ << >> &= |= ~= <<= >>=`
 
 	expectedOperators := []struct {
 		expectedType    TokenType
@@ -95,6 +95,28 @@ func TestOperators(t *testing.T) {
 		{AND, "&&"},
 		{OR, "||"},
 		{NOT, "!"},
+		{PIPE, "|"},
+		{PLUS_ASSIGN, "+="},
+		{MINUS_ASSIGN, "-="},
+		{MULTIPLY_ASSIGN, "*="},
+		{DIVIDE_ASSIGN, "/="},
+		{MODULO_ASSIGN, "%="},
+		{POWER_ASSIGN, "^="},
+		{OPTIONAL_DOT, "?."},
+		{OPTIONAL_INDEX, "?["},
+		{COALESCE, "??"},
+		{PIPELINE, "|>"},
+		{FLOOR_DIVIDE, "//"},
+		{FLOOR_DIVIDE_ASSIGN, "//="},
+		{BIT_AND, "&"},
+		{TILDE, "~"},
+		{SHIFT_LEFT, "<<"},
+		{SHIFT_RIGHT, ">>"},
+		{BIT_AND_ASSIGN, "&="},
+		{BIT_OR_ASSIGN, "|="},
+		{TILDE_ASSIGN, "~="},
+		{SHIFT_LEFT_ASSIGN, "<<="},
+		{SHIFT_RIGHT_ASSIGN, ">>="},
 	}
 
 	l := New(input)
@@ -248,12 +270,12 @@ func TestIdentifiers(t *testing.T) {
 }
 
 func TestAllKeywords(t *testing.T) {
-	input := `var const fn struct type if else case for in check where end is isA contains self true false return break module using as`
+	input := `var const fn struct type if else case for in check where end is isA contains self true false return break module using as try catch ensure throw while continue yield`
 
 	expectedTokens := []TokenType{
 		VAR, CONST, FN, STRUCT, TYPE, IF, ELSE, CASE, FOR, IN,
 		CHECK, WHERE, END, IS, ISA, CONTAINS, SELF, TRUE, FALSE, RETURN, BREAK,
-		MODULE, USING, AS,
+		MODULE, USING, AS, TRY, CATCH, ENSURE, THROW, WHILE, CONTINUE, YIELD,
 	}
 
 	l := New(input)
@@ -464,6 +486,38 @@ func TestLineTracking(t *testing.T) {
 	}
 }
 
+func TestIdentifierAndNumberPositions(t *testing.T) {
+	input := `var total = 42
+  return total`
+
+	expectedTokens := []struct {
+		expectedType   TokenType
+		expectedLine   int
+		expectedColumn int
+	}{
+		{VAR, 1, 1},
+		{IDENT, 1, 5},
+		{ASSIGN, 1, 11},
+		{NUMBER, 1, 13},
+		{RETURN, 2, 3},
+		{IDENT, 2, 10},
+	}
+
+	l := New(input)
+
+	for i, expected := range expectedTokens {
+		tok := l.NextToken()
+		if tok.Type != expected.expectedType {
+			t.Fatalf("token[%d] type wrong. expected=%q, got=%q",
+				i, expected.expectedType, tok.Type)
+		}
+		if tok.Line != expected.expectedLine || tok.Column != expected.expectedColumn {
+			t.Errorf("token[%d] position wrong. expected=%d:%d, got=%d:%d",
+				i, expected.expectedLine, expected.expectedColumn, tok.Line, tok.Column)
+		}
+	}
+}
+
 func TestMultilineString(t *testing.T) {
 	input := `"line1
 line2
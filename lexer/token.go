@@ -17,13 +17,34 @@ const (
 	BOOLEAN // true, false
 
 	// Operators
-	ASSIGN   // =
-	PLUS     // +
-	MINUS    // -
-	MULTIPLY // *
-	DIVIDE   // /
-	MODULO   // %
-	POWER    // ^
+	ASSIGN       // =
+	PLUS         // +
+	MINUS        // -
+	MULTIPLY     // *
+	DIVIDE       // /
+	FLOOR_DIVIDE // //
+	MODULO       // %
+	POWER        // ^
+
+	// Compound assignment
+	PLUS_ASSIGN         // +=
+	MINUS_ASSIGN        // -=
+	MULTIPLY_ASSIGN     // *=
+	DIVIDE_ASSIGN       // /=
+	FLOOR_DIVIDE_ASSIGN // //=
+	MODULO_ASSIGN       // %=
+	POWER_ASSIGN        // ^=
+	BIT_AND_ASSIGN      // &=
+	BIT_OR_ASSIGN       // |=
+	TILDE_ASSIGN        // ~=
+	SHIFT_LEFT_ASSIGN   // <<=
+	SHIFT_RIGHT_ASSIGN  // >>=
+
+	// Bitwise
+	BIT_AND     // &
+	TILDE       // ~ (xor between two numbers, not before one)
+	SHIFT_LEFT  // <<
+	SHIFT_RIGHT // >>
 
 	// Comparison
 	EQ     // ==
@@ -68,6 +89,7 @@ const (
 	ELSE     // else
 	CASE     // case
 	FOR      // for
+	WHILE    // while
 	IN       // in
 	CHECK    // check
 	WHERE    // where
@@ -87,6 +109,12 @@ const (
 	SELF     // self
 	RETURN   // return
 	BREAK    // break
+	CONTINUE // continue
+	TRY      // try
+	CATCH    // catch
+	ENSURE   // ensure
+	THROW    // throw
+	YIELD    // yield
 	TRUE     // true
 	FALSE    // false
 	NIL      // nil
@@ -105,6 +133,11 @@ const (
 	TYPE_ARROW      // ->
 	RANGE           // ..
 	RANGE_INCLUSIVE // ...
+	PIPE            // | (bitwise or, and separates alternative case patterns)
+	OPTIONAL_DOT    // ?.
+	OPTIONAL_INDEX  // ?[
+	COALESCE        // ??
+	PIPELINE        // |>
 
 	// Comments
 	COMMENT // # comment
@@ -143,10 +176,44 @@ func (t TokenType) String() string {
 		return "*"
 	case DIVIDE:
 		return "/"
+	case FLOOR_DIVIDE:
+		return "//"
 	case MODULO:
 		return "%"
 	case POWER:
 		return "^"
+	case PLUS_ASSIGN:
+		return "+="
+	case MINUS_ASSIGN:
+		return "-="
+	case MULTIPLY_ASSIGN:
+		return "*="
+	case DIVIDE_ASSIGN:
+		return "/="
+	case FLOOR_DIVIDE_ASSIGN:
+		return "//="
+	case MODULO_ASSIGN:
+		return "%="
+	case POWER_ASSIGN:
+		return "^="
+	case BIT_AND_ASSIGN:
+		return "&="
+	case BIT_OR_ASSIGN:
+		return "|="
+	case TILDE_ASSIGN:
+		return "~="
+	case SHIFT_LEFT_ASSIGN:
+		return "<<="
+	case SHIFT_RIGHT_ASSIGN:
+		return ">>="
+	case BIT_AND:
+		return "&"
+	case TILDE:
+		return "~"
+	case SHIFT_LEFT:
+		return "<<"
+	case SHIFT_RIGHT:
+		return ">>"
 	case EQ:
 		return "=="
 	case NOT_EQ:
@@ -211,6 +278,8 @@ func (t TokenType) String() string {
 		return "case"
 	case FOR:
 		return "for"
+	case WHILE:
+		return "while"
 	case IN:
 		return "in"
 	case CHECK:
@@ -249,6 +318,18 @@ func (t TokenType) String() string {
 		return "return"
 	case BREAK:
 		return "break"
+	case CONTINUE:
+		return "continue"
+	case TRY:
+		return "try"
+	case CATCH:
+		return "catch"
+	case ENSURE:
+		return "ensure"
+	case THROW:
+		return "throw"
+	case YIELD:
+		return "yield"
 	case TRUE:
 		return "true"
 	case FALSE:
@@ -273,6 +354,16 @@ func (t TokenType) String() string {
 		return ".."
 	case RANGE_INCLUSIVE:
 		return "..."
+	case PIPE:
+		return "|"
+	case OPTIONAL_DOT:
+		return "?."
+	case OPTIONAL_INDEX:
+		return "?["
+	case COALESCE:
+		return "??"
+	case PIPELINE:
+		return "|>"
 	case COMMENT:
 		return "COMMENT"
 	default:
@@ -300,6 +391,7 @@ var keywords = map[string]TokenType{
 	"else":     ELSE,
 	"case":     CASE,
 	"for":      FOR,
+	"while":    WHILE,
 	"in":       IN,
 	"check":    CHECK,
 	"where":    WHERE,
@@ -319,6 +411,12 @@ var keywords = map[string]TokenType{
 	"self":       SELF,
 	"return":   RETURN,
 	"break":    BREAK,
+	"continue": CONTINUE,
+	"try":      TRY,
+	"catch":    CATCH,
+	"ensure":   ENSURE,
+	"throw":    THROW,
+	"yield":    YIELD,
 	"true":     TRUE,
 	"false":    FALSE,
 	"nil":      NIL,
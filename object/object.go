@@ -2,6 +2,9 @@ package object
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,9 +22,12 @@ const (
 	TIME_OBJ    = "TIME"
 
 	// Collection types
-	ARRAY_OBJ = "ARRAY"
-	MAP_OBJ   = "MAP"
-	RANGE_OBJ = "RANGE"
+	ARRAY_OBJ    = "ARRAY"
+	MAP_OBJ      = "MAP"
+	RANGE_OBJ    = "RANGE"
+	ITERATOR_OBJ = "ITERATOR"
+	SET_OBJ      = "SET"
+	TUPLE_OBJ    = "TUPLE"
 
 	// Function types
 	FUNCTION_OBJ = "FUNCTION"
@@ -32,6 +38,7 @@ const (
 	// Control flow
 	RETURN_VALUE_OBJ = "RETURN_VALUE"
 	BREAK_OBJ        = "BREAK"
+	CONTINUE_OBJ     = "CONTINUE"
 	MULTI_VALUE_OBJ  = "MULTI_VALUE"
 
 	// Testing types
@@ -45,6 +52,11 @@ const (
 	NULL_OBJ       = "NULL"
 	ERROR_OBJ      = "ERROR"
 	TYPE_ALIAS_OBJ = "TYPE_ALIAS"
+	SUPER_OBJ      = "SUPER"
+
+	// User-defined record types
+	STRUCT_OBJ          = "STRUCT"
+	STRUCT_INSTANCE_OBJ = "STRUCT_INSTANCE"
 )
 
 // Object represents any value in the language
@@ -54,16 +66,199 @@ type Object interface {
 	String() string
 }
 
-// Number represents a numeric value
+// NumberKind tells how a Number holds its value
+type NumberKind int
+
+const (
+	FLOAT       NumberKind = iota // Value is the number
+	INTEGER                       // Int is the number, exactly
+	BIG_INTEGER                   // Big is an integer too large for 64 bits
+	DECIMAL                       // Decimal is an exact decimal fraction
+)
+
+// MaxSafeInteger is the largest whole float that every smaller whole number can be told apart from
+const MaxSafeInteger = 1 << 53
+
+// DecimalPlaces is how many places a decimal division that never ends is rounded to
+const DecimalPlaces = 28
+
+// Number represents a numeric value. Integers and decimals are exact, and Value always
+// holds the nearest float so code that only needs a float can read it directly.
 type Number struct {
 	Value       float64
+	Kind        NumberKind
+	Int         int64
+	Big         *big.Int
+	Decimal     *big.Rat
 	Properties  map[string]Object // Custom properties/methods
 	IsImmutable bool              // True if this number is immutable (const)
 }
 
+// NewInteger returns an exact integer
+func NewInteger(value int64) *Number {
+	return &Number{Value: float64(value), Kind: INTEGER, Int: value}
+}
+
+// NewBigInteger returns an exact integer, kept in 64 bits when it fits
+func NewBigInteger(value *big.Int) *Number {
+	if value.IsInt64() {
+		return NewInteger(value.Int64())
+	}
+	approx, _ := new(big.Float).SetInt(value).Float64()
+	return &Number{Value: approx, Kind: BIG_INTEGER, Big: value}
+}
+
+// NewDecimal returns an exact decimal, rounding fractions that never end to DecimalPlaces
+func NewDecimal(value *big.Rat) *Number {
+	if !terminates(value) {
+		value, _ = new(big.Rat).SetString(value.FloatString(DecimalPlaces))
+	}
+	approx, _ := value.Float64()
+	return &Number{Value: approx, Kind: DECIMAL, Decimal: value}
+}
+
+// IsInteger reports whether n is a whole number integer arithmetic can use exactly
+func (n *Number) IsInteger() bool {
+	switch n.Kind {
+	case INTEGER, BIG_INTEGER:
+		return true
+	case FLOAT:
+		return n.Value == math.Trunc(n.Value) && math.Abs(n.Value) <= MaxSafeInteger
+	}
+	return false
+}
+
+// Int64 returns the value of an integer that fits in 64 bits
+func (n *Number) Int64() (int64, bool) {
+	switch n.Kind {
+	case INTEGER:
+		return n.Int, true
+	case BIG_INTEGER:
+		if n.Big.IsInt64() {
+			return n.Big.Int64(), true
+		}
+	case FLOAT:
+		if n.IsInteger() {
+			return int64(n.Value), true
+		}
+	}
+	return 0, false
+}
+
+// BigInt returns the value of an integer as a big.Int
+func (n *Number) BigInt() *big.Int {
+	switch n.Kind {
+	case INTEGER:
+		return big.NewInt(n.Int)
+	case BIG_INTEGER:
+		return n.Big
+	}
+	return big.NewInt(int64(n.Value))
+}
+
+// Rat returns the exact value of an integer or decimal, or the shortest decimal that reads back as a float.
+// Infinities and NaN have no exact value.
+func (n *Number) Rat() (*big.Rat, bool) {
+	switch n.Kind {
+	case INTEGER:
+		return new(big.Rat).SetInt64(n.Int), true
+	case BIG_INTEGER:
+		return new(big.Rat).SetInt(n.Big), true
+	case DECIMAL:
+		return n.Decimal, true
+	}
+	if math.IsInf(n.Value, 0) || math.IsNaN(n.Value) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(n.Value, 'g', -1, 64))
+}
+
 func (n *Number) Type() ObjectType { return NUMBER_OBJ }
-func (n *Number) Inspect() string  { return fmt.Sprintf("%.10g", n.Value) }
-func (n *Number) String() string   { return n.Inspect() }
+func (n *Number) Inspect() string {
+	switch n.Kind {
+	case INTEGER:
+		return strconv.FormatInt(n.Int, 10)
+	case BIG_INTEGER:
+		return n.Big.String()
+	case DECIMAL:
+		return format_decimal(n.Decimal)
+	}
+	return FormatFloat(n.Value)
+}
+func (n *Number) String() string { return n.Inspect() }
+
+// Key writes a number so that numbers that compare equal share a key: whole numbers as their
+// digits whatever their kind, and fractions rounded to 12 significant digits, which is well
+// within the tolerance of a float comparison, so 0.1 + 0.2 and 0.3 agree
+func (n *Number) Key() string {
+	switch n.Kind {
+	case INTEGER, BIG_INTEGER:
+		return n.Inspect()
+	case DECIMAL:
+		if n.Decimal.IsInt() {
+			return n.Decimal.Num().String()
+		}
+		value, _ := n.Decimal.Float64()
+		return float_key(value)
+	}
+	return float_key(n.Value)
+}
+
+func float_key(value float64) string {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return FormatFloat(value)
+	}
+	if whole := math.Round(value); math.Abs(value-whole) < 1e-9 {
+		if whole == 0 {
+			return "0"
+		}
+		return strconv.FormatFloat(whole, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(value, 'g', 12, 64)
+}
+
+// FormatFloat writes a float with every digit it needs to read back the same, in plain notation
+// between 1e-7 and 1e21 and in exponent notation outside that range
+func FormatFloat(value float64) string {
+	abs := math.Abs(value)
+	if abs == 0 || (abs >= 1e-7 && abs < 1e21) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// terminates reports whether a fraction can be written with a finite number of decimal places
+func terminates(value *big.Rat) bool {
+	return decimal_places(value) >= 0
+}
+
+// decimal_places returns the places needed to write a fraction in full, or -1 if it never ends.
+// It ends when the denominator has no prime factors other than 2 and 5.
+func decimal_places(value *big.Rat) int {
+	denominator := new(big.Int).Set(value.Denom())
+	places := 0
+	for _, factor := range []int64{2, 5} {
+		divisor, remainder := big.NewInt(factor), new(big.Int)
+		count := 0
+		for {
+			quotient, _ := new(big.Int).QuoRem(denominator, divisor, remainder)
+			if remainder.Sign() != 0 {
+				break
+			}
+			denominator = quotient
+			count++
+		}
+		places = max(places, count)
+	}
+	if !denominator.IsInt64() || denominator.Int64() != 1 {
+		return -1
+	}
+	return places
+}
+
+func format_decimal(value *big.Rat) string {
+	return value.FloatString(max(decimal_places(value), 0))
+}
 
 // String represents a string value
 type String struct {
@@ -116,11 +311,23 @@ func (a *Array) String() string { return a.Inspect() }
 
 // Range represents a range of numbers
 type Range struct {
-	Start     int
-	End       int
+	Start     int64
+	End       int64
 	Inclusive bool
 }
 
+// Bounds returns the first and last number of the range, with ok false when it is empty
+func (r *Range) Bounds() (first, last int64, ok bool) {
+	last = r.End
+	if !r.Inclusive {
+		if r.End == math.MinInt64 {
+			return 0, 0, false
+		}
+		last--
+	}
+	return r.Start, last, r.Start <= last
+}
+
 func (r *Range) Type() ObjectType { return RANGE_OBJ }
 func (r *Range) Inspect() string {
 	if r.Inclusive {
@@ -136,23 +343,254 @@ type MapPair struct {
 	Value Object
 }
 
-// Map represents a map/hash/dictionary
+// HashKey identifies a value among the elements of a Set; equal values have equal keys
+type HashKey struct {
+	Type  ObjectType
+	Value string
+}
+
+// Hash returns the key of a value that can be a Set element: numbers, strings, booleans, null,
+// and tuples of those. Mutable values like arrays and maps cannot be hashed.
+func Hash(obj Object) (HashKey, bool) {
+	switch obj := obj.(type) {
+	case *Number:
+		// Equal numbers share a key whatever their kind, so 1, 1.0 and decimal("1") are one element
+		return HashKey{Type: NUMBER_OBJ, Value: obj.Key()}, true
+	case *String:
+		return HashKey{Type: STRING_OBJ, Value: obj.Value}, true
+	case *Boolean:
+		return HashKey{Type: BOOLEAN_OBJ, Value: obj.Inspect()}, true
+	case *Null:
+		return HashKey{Type: NULL_OBJ}, true
+	case *Tuple:
+		var out strings.Builder
+		for _, element := range obj.Elements {
+			key, ok := Hash(element)
+			if !ok {
+				return HashKey{}, false
+			}
+			fmt.Fprintf(&out, "%s:%q,", key.Type, key.Value)
+		}
+		return HashKey{Type: TUPLE_OBJ, Value: out.String()}, true
+	}
+	return HashKey{}, false
+}
+
+// Set is a collection of distinct hashable values, kept in the order they were added
+type Set struct {
+	keys        []HashKey
+	elements    map[HashKey]Object
+	IsImmutable bool // True if this set is immutable (const)
+}
+
+// NewSet returns an empty set
+func NewSet() *Set {
+	return &Set{elements: make(map[HashKey]Object)}
+}
+
+// Add puts value in the set, reporting false if it cannot be hashed
+func (s *Set) Add(value Object) bool {
+	key, ok := Hash(value)
+	if !ok {
+		return false
+	}
+	if _, found := s.elements[key]; !found {
+		s.keys = append(s.keys, key)
+		s.elements[key] = value
+	}
+	return true
+}
+
+// Contains reports whether value is in the set
+func (s *Set) Contains(value Object) bool {
+	key, ok := Hash(value)
+	if !ok {
+		return false
+	}
+	_, found := s.elements[key]
+	return found
+}
+
+// Remove takes value out of the set, reporting whether it was there
+func (s *Set) Remove(value Object) bool {
+	key, ok := Hash(value)
+	if !ok {
+		return false
+	}
+	if _, found := s.elements[key]; !found {
+		return false
+	}
+	delete(s.elements, key)
+	for i, k := range s.keys {
+		if k == key {
+			s.keys = append(s.keys[:i:i], s.keys[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Len returns the number of elements
+func (s *Set) Len() int { return len(s.keys) }
+
+// Values returns the elements in the order they were added
+func (s *Set) Values() []Object {
+	values := make([]Object, len(s.keys))
+	for i, key := range s.keys {
+		values[i] = s.elements[key]
+	}
+	return values
+}
+
+func (s *Set) Type() ObjectType { return SET_OBJ }
+func (s *Set) Inspect() string {
+	if s.Len() == 0 {
+		return "Set()"
+	}
+	var elements []string
+	for _, e := range s.Values() {
+		elements = append(elements, e.Inspect())
+	}
+	return fmt.Sprintf("{%s}", strings.Join(elements, ", "))
+}
+func (s *Set) String() string { return s.Inspect() }
+
+// Tuple is a fixed sequence of values. Tuples cannot be changed, so ones made of hashable values
+// can be Set elements and map keys.
+type Tuple struct {
+	Elements []Object
+}
+
+func (t *Tuple) Type() ObjectType { return TUPLE_OBJ }
+func (t *Tuple) Inspect() string {
+	var elements []string
+	for _, e := range t.Elements {
+		elements = append(elements, e.Inspect())
+	}
+	if len(elements) == 1 {
+		return fmt.Sprintf("(%s,)", elements[0])
+	}
+	return fmt.Sprintf("(%s)", strings.Join(elements, ", "))
+}
+func (t *Tuple) String() string { return t.Inspect() }
+
+// Map represents a map/hash/dictionary. Its pairs are only changed through Set, Delete and Clear,
+// so they keep the order they were first added in.
 type Map struct {
-	Pairs       map[string]MapPair
 	Properties  map[string]Object // Custom properties/methods
 	IsImmutable bool              // True if this map is immutable (const)
+	Proto       *Map              // Map that missing keys and methods are looked up in, if any
+	pairs       map[string]MapPair
+	order       []string // keys in the order Set first stored them
+}
+
+// NewMap returns an empty map
+func NewMap() *Map {
+	return &Map{pairs: make(map[string]MapPair)}
+}
+
+// Get returns the pair stored under key
+func (m *Map) Get(key string) (MapPair, bool) {
+	pair, ok := m.pairs[key]
+	return pair, ok
+}
+
+// Len returns the number of pairs in the map
+func (m *Map) Len() int {
+	return len(m.pairs)
+}
+
+// Set stores pair under key; a new key goes after the others, an existing one keeps its place
+func (m *Map) Set(key string, pair MapPair) {
+	if m.pairs == nil {
+		m.pairs = make(map[string]MapPair)
+	}
+	if _, exists := m.pairs[key]; !exists {
+		m.order = append(m.order, key)
+	}
+	m.pairs[key] = pair
+}
+
+// Delete removes key from the map, reporting whether it was there
+func (m *Map) Delete(key string) bool {
+	if _, exists := m.pairs[key]; !exists {
+		return false
+	}
+	delete(m.pairs, key)
+	for i, k := range m.order {
+		if k == key {
+			m.order = append(m.order[:i:i], m.order[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+// Clear removes every pair
+func (m *Map) Clear() {
+	m.pairs = make(map[string]MapPair)
+	m.order = nil
+}
+
+// Keys returns the keys of the map in insertion order
+func (m *Map) Keys() []string {
+	return append([]string(nil), m.order...)
+}
+
+// Entries returns the pairs of the map in the order of Keys
+func (m *Map) Entries() []MapPair {
+	keys := m.Keys()
+	entries := make([]MapPair, len(keys))
+	for i, key := range keys {
+		entries[i] = m.pairs[key]
+	}
+	return entries
 }
 
 func (m *Map) Type() ObjectType { return MAP_OBJ }
 func (m *Map) Inspect() string {
 	var pairs []string
-	for _, pair := range m.Pairs {
+	for _, pair := range m.Entries() {
 		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
 	}
 	return fmt.Sprintf("{%s}", strings.Join(pairs, ", "))
 }
 func (m *Map) String() string { return m.Inspect() }
 
+// Inherits reports whether proto is m or on the prototype chain of m
+func (m *Map) Inherits(proto *Map) bool {
+	for current := m; current != nil; current = current.Proto {
+		if current == proto {
+			return true
+		}
+	}
+	return false
+}
+
+// Super is what super refers to inside a method: lookups start at Proto, the prototype of the
+// map the method was found on, and the methods found run with Receiver as self
+type Super struct {
+	Receiver Object
+	Proto    *Map
+}
+
+func (s *Super) Type() ObjectType { return SUPER_OBJ }
+func (s *Super) Inspect() string  { return "super" }
+func (s *Super) String() string   { return s.Inspect() }
+
+// Iterator produces values one at a time, for generators and other lazy sources.
+// Next returns false once the values run out; a runtime error is returned as a value and ends the iteration.
+// Close releases whatever the iterator holds when it is abandoned early.
+type Iterator struct {
+	Name  string
+	Next  func() (Object, bool)
+	Close func()
+}
+
+func (it *Iterator) Type() ObjectType { return ITERATOR_OBJ }
+func (it *Iterator) Inspect() string  { return fmt.Sprintf("iterator %s", it.Name) }
+func (it *Iterator) String() string   { return it.Inspect() }
+
 // Null represents a null/nil value
 type Null struct{}
 
@@ -163,13 +601,70 @@ func (n *Null) String() string   { return "null" }
 // Error represents an error object
 type Error struct {
 	Message       string
-	IsUserCreated bool // True if created via error() builtin, false if runtime error
+	IsUserCreated bool         // True if created via error() builtin, false if runtime error
+	Kind          string       // Category callers can branch on, e.g. "io" or "parse"; empty for plain runtime errors
+	Cause         *Error       // Error this one wraps, nil when it is the original failure
+	Data          *Map         // Extra values attached by whoever created the error
+	File          string       // Source file where a runtime error originated
+	Line          int          // Line where a runtime error originated, 0 when unknown
+	Column        int          // Column where a runtime error originated
+	Stack         []StackFrame // Seda calls active when the error originated, outermost first
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
 func (e *Error) Inspect() string  { return fmt.Sprintf("ERROR: %s", e.Message) }
 func (e *Error) String() string   { return e.Message }
 
+// ErrorKind returns the error's kind, defaulting to "runtime" for errors raised by the interpreter
+func (e *Error) ErrorKind() string {
+	if e.Kind == "" {
+		return "runtime"
+	}
+	return e.Kind
+}
+
+// Is reports whether the error or any error it wraps has the given kind
+func (e *Error) Is(kind string) bool {
+	for err := e; err != nil; err = err.Cause {
+		if err.ErrorKind() == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPosition reports whether the error knows where it originated
+func (e *Error) HasPosition() bool { return e.Line > 0 }
+
+// Traceback formats the call stack captured when the error originated
+func (e *Error) Traceback() string {
+	if len(e.Stack) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	out.WriteString("Traceback (most recent call last):\n")
+	for _, frame := range e.Stack {
+		out.WriteString("  " + frame.String() + "\n")
+	}
+	return out.String()
+}
+
+// StackFrame is one Seda call in a traceback
+type StackFrame struct {
+	Function string // Function name, or <main> for top-level code
+	File     string
+	Line     int
+}
+
+func (f StackFrame) String() string {
+	file := f.File
+	if file == "" {
+		file = "<input>"
+	}
+	return fmt.Sprintf("File \"%s\", line %d, in %s", file, f.Line, f.Function)
+}
+
 // ReturnValue wraps other objects when returned from functions
 type ReturnValue struct {
 	Value Object
@@ -181,12 +676,22 @@ func (rv *ReturnValue) String() string   { return rv.Value.String() }
 
 // Break signals a break from a loop
 type Break struct {
+	Label string // Loop to break out of, empty for the innermost loop
 }
 
 func (b *Break) Type() ObjectType { return BREAK_OBJ }
 func (b *Break) Inspect() string  { return "break" }
 func (b *Break) String() string   { return "break" }
 
+// Continue signals a skip to the next iteration of a loop
+type Continue struct {
+	Label string // Loop to continue, empty for the innermost loop
+}
+
+func (c *Continue) Type() ObjectType { return CONTINUE_OBJ }
+func (c *Continue) Inspect() string  { return "continue" }
+func (c *Continue) String() string   { return "continue" }
+
 // MultiValue represents multiple return values
 type MultiValue struct {
 	Values []Object
@@ -238,26 +743,31 @@ func NewError(format string, a ...interface{}) *Error {
 // Environment represents a variable binding environment
 type Environment struct {
 	store            map[string]Object
-	constants        map[string]bool // Track which identifiers are constants
+	constants        map[string]bool                // Track which identifiers are constants
+	types            map[string]*ast.TypeAnnotation // Declared type annotations for typed variables
 	outer            *Environment
-	InWhereBlockTest bool   // Flag to prevent infinite recursion in where block tests
-	SourceDir        string // Directory of the source file being evaluated (for module resolution)
+	InWhereBlockTest bool         // Flag to prevent infinite recursion in where block tests
+	SourceDir        string       // Directory of the source file being evaluated (for module resolution)
+	SourceFile       string       // Source file being evaluated (for error positions and tracebacks)
+	yield            func(Object) bool // Set on the environment of a running generator body
 }
 
 // NewEnvironment creates a new environment
 func NewEnvironment() *Environment {
 	s := make(map[string]Object)
 	c := make(map[string]bool)
-	return &Environment{store: s, constants: c, outer: nil}
+	t := make(map[string]*ast.TypeAnnotation)
+	return &Environment{store: s, constants: c, types: t, outer: nil}
 }
 
 // NewEnclosedEnvironment creates a new environment with an outer scope
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
-	// Inherit source directory from parent environment
+	// Inherit source directory and file from parent environment
 	if outer != nil {
 		env.SourceDir = outer.SourceDir
+		env.SourceFile = outer.SourceFile
 	}
 	return env
 }
@@ -295,6 +805,26 @@ func (e *Environment) SetConstant(name string, val Object) Object {
 	return val
 }
 
+// SetType records the declared type of a variable in this scope (nil clears it)
+func (e *Environment) SetType(name string, annotation *ast.TypeAnnotation) {
+	if annotation == nil {
+		delete(e.types, name)
+		return
+	}
+	e.types[name] = annotation
+}
+
+// TypeOf returns the declared type of a variable from the scope that defines it
+func (e *Environment) TypeOf(name string) *ast.TypeAnnotation {
+	if _, ok := e.store[name]; ok {
+		return e.types[name]
+	}
+	if e.outer != nil {
+		return e.outer.TypeOf(name)
+	}
+	return nil
+}
+
 // IsConstant checks if a name is a constant in this environment or outer scopes
 func (e *Environment) IsConstant(name string) bool {
 	if e.constants[name] {
@@ -335,6 +865,22 @@ func (e *Environment) Update(name string, val Object) Object {
 	return val
 }
 
+// SetYield marks the environment as the body of a running generator
+func (e *Environment) SetYield(yield func(Object) bool) {
+	e.yield = yield
+}
+
+// Yield returns the function that hands values to the iterating code, or nil outside a generator.
+// It reports false once the generator has been closed and the body should stop.
+func (e *Environment) Yield() func(Object) bool {
+	for env := e; env != nil; env = env.outer {
+		if env.yield != nil {
+			return env.yield
+		}
+	}
+	return nil
+}
+
 // GetStore returns the internal store (for module extraction)
 func (e *Environment) GetStore() map[string]Object {
 	return e.store
@@ -342,10 +888,13 @@ func (e *Environment) GetStore() map[string]Object {
 
 // Function represents a user-defined function
 type Function struct {
+	Name       string // Empty for anonymous functions
 	Parameters []*ast.Parameter
+	ReturnType *ast.TypeAnnotation
 	Body       *ast.BlockStatement
 	Env        *Environment
 	WhereBlock *ast.WhereBlock
+	Generator  bool // Calls return an Iterator instead of running the body
 }
 
 func (f *Function) Type() ObjectType { return FUNCTION_OBJ }
@@ -379,16 +928,75 @@ func (m *Module) Type() ObjectType { return MODULE_OBJ }
 func (m *Module) Inspect() string  { return fmt.Sprintf("module %s", m.Name) }
 func (m *Module) String() string   { return m.Inspect() }
 
-// TypeAlias represents a type alias declaration
+// TypeAlias represents a type alias declaration, or a sum type when it has variants
 type TypeAlias struct {
 	Name           string
-	TypeAnnotation *ast.TypeAnnotation
+	TypeAnnotation *ast.TypeAnnotation // nil for sum types
+	Variants       []*Struct           // the closed set of cases of a sum type
 }
 
 func (t *TypeAlias) Type() ObjectType { return TYPE_ALIAS_OBJ }
 func (t *TypeAlias) Inspect() string  { return fmt.Sprintf("type %s", t.Name) }
 func (t *TypeAlias) String() string   { return t.Inspect() }
 
+// IsSumType reports whether the type was declared with variants
+func (t *TypeAlias) IsSumType() bool { return t.Variants != nil }
+
+// Struct represents a user-defined record type declared with `struct`
+type Struct struct {
+	Name    string
+	Fields  []*ast.StructField
+	Methods map[string]Object // Methods declared with fn Name.method()
+	Env     *Environment      // Declaring environment, used to resolve field types
+	SumType *TypeAlias        // Set when the struct is a variant of a sum type
+}
+
+func (s *Struct) Type() ObjectType { return STRUCT_OBJ }
+func (s *Struct) Inspect() string  { return fmt.Sprintf("struct %s", s.Name) }
+func (s *Struct) String() string   { return s.Inspect() }
+
+// IsUnitVariant reports whether the struct is a sum type variant without fields, like None
+func (s *Struct) IsUnitVariant() bool {
+	return s.SumType != nil && s.Fields == nil
+}
+
+// HasField checks if the struct declares a field with the given name
+func (s *Struct) HasField(name string) bool {
+	for _, field := range s.Fields {
+		if field.Name.Value == name {
+			return true
+		}
+	}
+	return false
+}
+
+// StructInstance represents a value constructed from a Struct
+type StructInstance struct {
+	Struct      *Struct
+	Fields      map[string]Object
+	IsImmutable bool // True if this instance is immutable (const)
+}
+
+func (si *StructInstance) Type() ObjectType { return STRUCT_INSTANCE_OBJ }
+func (si *StructInstance) Inspect() string {
+	// Variants without fields are plain values
+	if si.Struct.IsUnitVariant() {
+		return si.Struct.Name
+	}
+
+	// Print fields in declaration order so output is stable
+	var fields []string
+	for _, field := range si.Struct.Fields {
+		value, ok := si.Fields[field.Name.Value]
+		if !ok {
+			value = NULL
+		}
+		fields = append(fields, fmt.Sprintf("%s: %s", field.Name.Value, value.Inspect()))
+	}
+	return fmt.Sprintf("%s(%s)", si.Struct.Name, strings.Join(fields, ", "))
+}
+func (si *StructInstance) String() string { return si.Inspect() }
+
 // AssertionResult represents the result of a single assertion
 type AssertionResult struct {
 	Passed   bool
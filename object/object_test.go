@@ -1,6 +1,8 @@
 package object
 
 import (
+	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/vpaulo/seda/ast"
@@ -36,6 +38,40 @@ func TestNumberObject(t *testing.T) {
 	}
 }
 
+func TestExactNumbers(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	third := NewDecimal(big.NewRat(1, 3))
+
+	tests := []struct {
+		num      *Number
+		expected string
+	}{
+		{NewInteger(12345678901), "12345678901"},
+		{NewInteger(-9007199254740993), "-9007199254740993"},
+		{NewBigInteger(huge), "123456789012345678901234567890"},
+		{NewBigInteger(big.NewInt(7)), "7"},
+		{NewDecimal(big.NewRat(1999, 100)), "19.99"},
+		{NewDecimal(big.NewRat(5, 1)), "5"},
+		{third, "0.3333333333333333333333333333"},
+		{&Number{Value: 12345678901}, "12345678901"},
+		{&Number{Value: 1.0 / 3}, "0.3333333333333333"},
+		{&Number{Value: 1e21}, "1e+21"},
+	}
+
+	for _, tt := range tests {
+		if tt.num.Inspect() != tt.expected {
+			t.Errorf("num.Inspect() = %q, want %q", tt.num.Inspect(), tt.expected)
+		}
+	}
+
+	if NewBigInteger(big.NewInt(7)).Kind != INTEGER {
+		t.Error("big integers that fit in 64 bits should become plain integers")
+	}
+	if !(&Number{Value: 4}).IsInteger() || (&Number{Value: 4.5}).IsInteger() || third.IsInteger() {
+		t.Error("IsInteger() is wrong")
+	}
+}
+
 func TestNumberWithProperties(t *testing.T) {
 	num := &Number{Value: 42, Properties: make(map[string]Object)}
 	num.Properties["custom"] = &String{Value: "test"}
@@ -185,8 +221,8 @@ func TestArrayObject(t *testing.T) {
 func TestRangeObject(t *testing.T) {
 	tests := []struct {
 		name      string
-		start     int
-		end       int
+		start     int64
+		end       int64
 		inclusive bool
 		expected  string
 	}{
@@ -238,7 +274,10 @@ func TestMapObject(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := &Map{Pairs: tt.pairs}
+			m := NewMap()
+			for key, pair := range tt.pairs {
+				m.Set(key, pair)
+			}
 
 			if m.Type() != MAP_OBJ {
 				t.Errorf("m.Type() = %q, want %q", m.Type(), MAP_OBJ)
@@ -251,6 +290,101 @@ func TestMapObject(t *testing.T) {
 	}
 }
 
+func TestMapOrder(t *testing.T) {
+	m := NewMap()
+	for _, key := range []string{"z", "a", "m"} {
+		m.Set(key, MapPair{Key: &String{Value: key}, Value: NULL})
+	}
+	m.Set("z", MapPair{Key: &String{Value: "z"}, Value: TRUE})
+	m.Delete("a")
+	m.Set("a", MapPair{Key: &String{Value: "a"}, Value: NULL})
+
+	if got := strings.Join(m.Keys(), ","); got != "z,m,a" {
+		t.Errorf("m.Keys() = %q, want %q", got, "z,m,a")
+	}
+	if m.Inspect() != `{"z": true, "m": null, "a": null}` {
+		t.Errorf("m.Inspect() = %q", m.Inspect())
+	}
+	if pair, ok := m.Get("z"); !ok || pair.Value != TRUE || m.Len() != 3 {
+		t.Errorf("m.Get(\"z\") = %v, %v with %d pairs", pair.Value, ok, m.Len())
+	}
+
+	m.Clear()
+	if len(m.Keys()) != 0 || m.Delete("z") {
+		t.Errorf("a cleared map should be empty")
+	}
+}
+
+func TestMapInherits(t *testing.T) {
+	animal := NewMap()
+	dog := &Map{Proto: animal}
+	rex := &Map{Proto: dog}
+
+	if !rex.Inherits(rex) || !rex.Inherits(dog) || !rex.Inherits(animal) {
+		t.Errorf("rex should inherit from itself, dog and animal")
+	}
+	if animal.Inherits(dog) {
+		t.Errorf("animal should not inherit from dog")
+	}
+}
+
+// Test Set and Tuple objects
+func TestSetObject(t *testing.T) {
+	set := NewSet()
+	set.Add(&Number{Value: 1})
+	set.Add(NewInteger(1))
+	set.Add(&String{Value: "1"})
+	set.Add(&Tuple{Elements: []Object{NewInteger(1), &String{Value: "a"}}})
+
+	if set.Type() != SET_OBJ {
+		t.Errorf("set.Type() = %q, want %q", set.Type(), SET_OBJ)
+	}
+	if set.Len() != 3 {
+		t.Fatalf("set.Len() = %d, want 3", set.Len())
+	}
+	if set.Inspect() != `{1, "1", (1, "a")}` {
+		t.Errorf("set.Inspect() = %q", set.Inspect())
+	}
+	if !set.Contains(&Tuple{Elements: []Object{&Number{Value: 1}, &String{Value: "a"}}}) {
+		t.Errorf("set should contain an equal tuple")
+	}
+	if set.Add(&Array{}) {
+		t.Errorf("arrays should not be hashable")
+	}
+	if !set.Remove(&String{Value: "1"}) || set.Remove(&String{Value: "1"}) {
+		t.Errorf("Remove should report whether the value was there")
+	}
+	if NewSet().Inspect() != "Set()" {
+		t.Errorf("empty set Inspect() = %q", NewSet().Inspect())
+	}
+}
+
+func TestTupleObject(t *testing.T) {
+	tests := []struct {
+		elements []Object
+		expected string
+	}{
+		{[]Object{}, "()"},
+		{[]Object{NewInteger(1)}, "(1,)"},
+		{[]Object{NewInteger(1), &String{Value: "a"}}, `(1, "a")`},
+	}
+
+	for _, tt := range tests {
+		tuple := &Tuple{Elements: tt.elements}
+		if tuple.Type() != TUPLE_OBJ {
+			t.Errorf("tuple.Type() = %q, want %q", tuple.Type(), TUPLE_OBJ)
+		}
+		if tuple.Inspect() != tt.expected {
+			t.Errorf("tuple.Inspect() = %q, want %q", tuple.Inspect(), tt.expected)
+		}
+	}
+
+	// Tuples hash by their elements, unless one of them cannot be hashed
+	if _, ok := Hash(&Tuple{Elements: []Object{&Array{}}}); ok {
+		t.Errorf("a tuple holding an array should not be hashable")
+	}
+}
+
 // Test Null object
 func TestNullObject(t *testing.T) {
 	null := &Null{}
@@ -490,6 +624,46 @@ func TestTypeAliasObject(t *testing.T) {
 	}
 }
 
+// Test Struct and StructInstance objects
+func TestStructObject(t *testing.T) {
+	point := &Struct{
+		Name: "Point",
+		Fields: []*ast.StructField{
+			{Name: &ast.Identifier{Value: "x"}, Type: &ast.TypeAnnotation{Name: "number"}},
+			{Name: &ast.Identifier{Value: "y"}, Type: &ast.TypeAnnotation{Name: "number"}},
+		},
+		Methods: map[string]Object{},
+	}
+
+	if point.Type() != STRUCT_OBJ {
+		t.Errorf("point.Type() = %q, want %q", point.Type(), STRUCT_OBJ)
+	}
+
+	if point.Inspect() != "struct Point" {
+		t.Errorf("point.Inspect() = %q, want %q", point.Inspect(), "struct Point")
+	}
+
+	if !point.HasField("x") || point.HasField("z") {
+		t.Error("point.HasField() reported wrong fields")
+	}
+
+	instance := &StructInstance{
+		Struct: point,
+		Fields: map[string]Object{
+			"y": &Number{Value: 2},
+			"x": &Number{Value: 1},
+		},
+	}
+
+	if instance.Type() != STRUCT_INSTANCE_OBJ {
+		t.Errorf("instance.Type() = %q, want %q", instance.Type(), STRUCT_INSTANCE_OBJ)
+	}
+
+	if instance.Inspect() != "Point(x: 1, y: 2)" {
+		t.Errorf("instance.Inspect() = %q, want %q", instance.Inspect(), "Point(x: 1, y: 2)")
+	}
+}
+
 // Test TestResult object
 func TestTestResultObject(t *testing.T) {
 	tests := []struct {
@@ -702,3 +876,36 @@ func TestEnvironmentGetStore(t *testing.T) {
 		t.Error("expected store to contain 'y'")
 	}
 }
+
+func TestErrorTraceback(t *testing.T) {
+	err := &Error{Message: "division by zero", File: "lib/calc.s", Line: 3, Column: 10, Stack: []StackFrame{
+		{Function: "<main>", File: "main.s", Line: 8},
+		{Function: "ratio", File: "lib/calc.s", Line: 3},
+	}}
+
+	expected := "Traceback (most recent call last):\n" +
+		"  File \"main.s\", line 8, in <main>\n" +
+		"  File \"lib/calc.s\", line 3, in ratio\n"
+	if err.Traceback() != expected {
+		t.Errorf("Traceback() = %q, want %q", err.Traceback(), expected)
+	}
+
+	if (&Error{Message: "plain"}).Traceback() != "" {
+		t.Error("expected no traceback for an error without a stack")
+	}
+}
+
+func TestErrorIsWalksCauses(t *testing.T) {
+	root := &Error{Message: "no such file", Kind: "io"}
+	wrapped := &Error{Message: "loading config", Kind: "config", Cause: root}
+
+	if !wrapped.Is("config") || !wrapped.Is("io") {
+		t.Error("expected Is to match the error and its causes")
+	}
+	if wrapped.Is("parse") {
+		t.Error("expected Is to reject kinds not in the chain")
+	}
+	if (&Error{Message: "division by zero"}).ErrorKind() != "runtime" {
+		t.Error("expected errors without a kind to report runtime")
+	}
+}
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"unicode"
 
 	"github.com/vpaulo/seda/ast"
 	"github.com/vpaulo/seda/lexer"
@@ -14,7 +15,13 @@ const (
 	ASSIGNMENT  // =
 	EQUALS      // ==
 	LESSGREATER // > or <
+	BIT_OR      // |
+	BIT_XOR     // ~
+	BIT_AND     // &
+	COALESCE    // ??
+	PIPE_PREC   // |>
 	RANGE_PREC  // .. or ...
+	SHIFT       // << or >>
 	SUM         // +
 	PRODUCT     // *
 	POWER       // ^
@@ -26,26 +33,48 @@ const (
 
 // precedences maps token types to their precedence
 var precedences = map[lexer.TokenType]int{
-	lexer.ASSIGN:          ASSIGNMENT,
-	lexer.RANGE:           RANGE_PREC,
-	lexer.RANGE_INCLUSIVE: RANGE_PREC,
-	lexer.EQ:              EQUALS,
-	lexer.NOT_EQ:          EQUALS,
-	lexer.LT:              LESSGREATER,
-	lexer.GT:              LESSGREATER,
-	lexer.LTE:             LESSGREATER,
-	lexer.GTE:             LESSGREATER,
-	lexer.PLUS:            SUM,
-	lexer.MINUS:           SUM,
-	lexer.DIVIDE:          PRODUCT,
-	lexer.MULTIPLY:        PRODUCT,
-	lexer.MODULO:          PRODUCT,
-	lexer.POWER:           POWER,
-	lexer.LPAREN:          CALL,
-	lexer.LBRACKET:        INDEX,
-	lexer.DOT:             DOT,
-	lexer.AND:             EQUALS,
-	lexer.OR:              EQUALS,
+	lexer.ASSIGN:              ASSIGNMENT,
+	lexer.PLUS_ASSIGN:         ASSIGNMENT,
+	lexer.MINUS_ASSIGN:        ASSIGNMENT,
+	lexer.MULTIPLY_ASSIGN:     ASSIGNMENT,
+	lexer.DIVIDE_ASSIGN:       ASSIGNMENT,
+	lexer.FLOOR_DIVIDE_ASSIGN: ASSIGNMENT,
+	lexer.MODULO_ASSIGN:       ASSIGNMENT,
+	lexer.POWER_ASSIGN:        ASSIGNMENT,
+	lexer.BIT_AND_ASSIGN:      ASSIGNMENT,
+	lexer.BIT_OR_ASSIGN:       ASSIGNMENT,
+	lexer.TILDE_ASSIGN:        ASSIGNMENT,
+	lexer.SHIFT_LEFT_ASSIGN:   ASSIGNMENT,
+	lexer.SHIFT_RIGHT_ASSIGN:  ASSIGNMENT,
+	lexer.RANGE:               RANGE_PREC,
+	lexer.RANGE_INCLUSIVE:     RANGE_PREC,
+	lexer.EQ:                  EQUALS,
+	lexer.NOT_EQ:              EQUALS,
+	lexer.LT:                  LESSGREATER,
+	lexer.GT:                  LESSGREATER,
+	lexer.LTE:                 LESSGREATER,
+	lexer.GTE:                 LESSGREATER,
+	lexer.PIPE:                BIT_OR,
+	lexer.TILDE:               BIT_XOR,
+	lexer.BIT_AND:             BIT_AND,
+	lexer.SHIFT_LEFT:          SHIFT,
+	lexer.SHIFT_RIGHT:         SHIFT,
+	lexer.PLUS:                SUM,
+	lexer.MINUS:               SUM,
+	lexer.DIVIDE:              PRODUCT,
+	lexer.FLOOR_DIVIDE:        PRODUCT,
+	lexer.MULTIPLY:            PRODUCT,
+	lexer.MODULO:              PRODUCT,
+	lexer.POWER:               POWER,
+	lexer.LPAREN:              CALL,
+	lexer.LBRACKET:            INDEX,
+	lexer.DOT:                 DOT,
+	lexer.OPTIONAL_DOT:        DOT,
+	lexer.OPTIONAL_INDEX:      INDEX,
+	lexer.COALESCE:            COALESCE,
+	lexer.PIPELINE:            PIPE_PREC,
+	lexer.AND:                 EQUALS,
+	lexer.OR:                  EQUALS,
 }
 
 type (
@@ -60,6 +89,15 @@ type Parser struct {
 	current_token lexer.Token
 	peek_token    lexer.Token
 
+	// in_case_pattern is positive while parsing a case pattern or guard, where => ends the branch header
+	// instead of starting an arrow function
+	in_case_pattern int
+
+	// function_depth counts the function bodies being parsed, and yields records whether the innermost one
+	// contains a yield, which makes it a generator
+	function_depth int
+	yields         bool
+
 	errors []string
 
 	prefix_parse_fns map[lexer.TokenType]prefix_parse_fn
@@ -83,6 +121,7 @@ func New(l *lexer.Lexer) *Parser {
 	parser.register_prefix(lexer.NIL, parser.parse_nil_literal)
 	parser.register_prefix(lexer.MINUS, parser.parse_prefix_expression)
 	parser.register_prefix(lexer.NOT, parser.parse_prefix_expression)
+	parser.register_prefix(lexer.TILDE, parser.parse_prefix_expression)
 	parser.register_prefix(lexer.LPAREN, parser.parse_grouped_expression)
 	parser.register_prefix(lexer.LBRACKET, parser.parse_array_literal)
 	parser.register_prefix(lexer.LBRACE, parser.parse_map_literal)
@@ -95,6 +134,12 @@ func New(l *lexer.Lexer) *Parser {
 	parser.register_infix(lexer.PLUS, parser.parse_infix_expression)
 	parser.register_infix(lexer.MINUS, parser.parse_infix_expression)
 	parser.register_infix(lexer.DIVIDE, parser.parse_infix_expression)
+	parser.register_infix(lexer.FLOOR_DIVIDE, parser.parse_infix_expression)
+	parser.register_infix(lexer.PIPE, parser.parse_infix_expression)
+	parser.register_infix(lexer.TILDE, parser.parse_infix_expression)
+	parser.register_infix(lexer.BIT_AND, parser.parse_infix_expression)
+	parser.register_infix(lexer.SHIFT_LEFT, parser.parse_infix_expression)
+	parser.register_infix(lexer.SHIFT_RIGHT, parser.parse_infix_expression)
 	parser.register_infix(lexer.MULTIPLY, parser.parse_infix_expression)
 	parser.register_infix(lexer.MODULO, parser.parse_infix_expression)
 	parser.register_infix(lexer.POWER, parser.parse_infix_expression)
@@ -109,7 +154,23 @@ func New(l *lexer.Lexer) *Parser {
 	parser.register_infix(lexer.LPAREN, parser.parse_call_expression)
 	parser.register_infix(lexer.LBRACKET, parser.parse_index_expression)
 	parser.register_infix(lexer.DOT, parser.parse_dot_expression)
+	parser.register_infix(lexer.OPTIONAL_DOT, parser.parse_optional_expression)
+	parser.register_infix(lexer.OPTIONAL_INDEX, parser.parse_index_expression)
+	parser.register_infix(lexer.COALESCE, parser.parse_infix_expression)
+	parser.register_infix(lexer.PIPELINE, parser.parse_pipeline_expression)
 	parser.register_infix(lexer.ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.PLUS_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.MINUS_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.MULTIPLY_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.DIVIDE_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.FLOOR_DIVIDE_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.BIT_AND_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.BIT_OR_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.TILDE_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.SHIFT_LEFT_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.SHIFT_RIGHT_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.MODULO_ASSIGN, parser.parse_assignment_expression)
+	parser.register_infix(lexer.POWER_ASSIGN, parser.parse_assignment_expression)
 	parser.register_infix(lexer.RANGE, parser.parse_range_expression)
 	parser.register_infix(lexer.RANGE_INCLUSIVE, parser.parse_range_expression)
 
@@ -208,18 +269,34 @@ func (parser *Parser) parse_statement() ast.Statement {
 		return parser.parse_case_statement()
 	case lexer.FOR:
 		return parser.parse_for_statement()
+	case lexer.WHILE:
+		return parser.parse_while_statement()
 	case lexer.RETURN:
 		return parser.parse_return_statement()
 	case lexer.BREAK:
 		return parser.parse_break_statement()
+	case lexer.CONTINUE:
+		return parser.parse_continue_statement()
+	case lexer.TRY:
+		return parser.parse_try_statement()
+	case lexer.THROW:
+		return parser.parse_throw_statement()
+	case lexer.YIELD:
+		return parser.parse_yield_statement()
 	case lexer.CHECK:
 		return parser.parse_check_statement()
 	case lexer.COMMENT:
 		// Skip comments
 		return nil
-	case lexer.WHERE, lexer.ELSE, lexer.END:
+	case lexer.WHERE, lexer.ELSE, lexer.CATCH, lexer.ENSURE, lexer.END:
 		// These are not statements but block terminators
 		return nil
+	case lexer.IDENT:
+		// A name followed by a colon labels the loop that follows
+		if parser.is_loop_label() {
+			return parser.parse_labeled_loop()
+		}
+		return parser.parse_expression_statement()
 	default:
 		return parser.parse_expression_statement()
 	}
@@ -227,22 +304,29 @@ func (parser *Parser) parse_statement() ast.Statement {
 
 // parse_var_statement parses variable declarations
 func (parser *Parser) parse_var_statement(is_constant bool) *ast.VarStatement {
-	stmt := &ast.VarStatement{IsConstant: is_constant, Names: []*ast.Identifier{}}
+	stmt := &ast.VarStatement{Token: parser.current_token, IsConstant: is_constant, Names: []*ast.Identifier{}}
 
-	if !parser.expect_peek(lexer.IDENT) {
+	// var [a, b] = ... and var {name, age} = ... destructure the value
+	if parser.peek_token.Type == lexer.LBRACKET || parser.peek_token.Type == lexer.LBRACE {
+		parser.next_token()
+		stmt.Pattern = parser.parse_binding_pattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	} else if !parser.expect_peek(lexer.IDENT) {
 		return nil
+	} else {
+		// Parse first variable name
+		stmt.Names = append(stmt.Names, &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal})
 	}
 
-	// Parse first variable name
-	stmt.Names = append(stmt.Names, &ast.Identifier{Value: parser.current_token.Literal})
-
 	// Parse additional comma-separated variable names
-	for parser.peek_token.Type == lexer.COMMA {
+	for stmt.Pattern == nil && parser.peek_token.Type == lexer.COMMA {
 		parser.next_token() // skip comma
 		if !parser.expect_peek(lexer.IDENT) {
 			return nil
 		}
-		stmt.Names = append(stmt.Names, &ast.Identifier{Value: parser.current_token.Literal})
+		stmt.Names = append(stmt.Names, &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal})
 	}
 
 	// Optional type annotation
@@ -264,7 +348,7 @@ func (parser *Parser) parse_var_statement(is_constant bool) *ast.VarStatement {
 
 // parse_return_statement parses return statements
 func (parser *Parser) parse_return_statement() *ast.ReturnStatement {
-	stmt := &ast.ReturnStatement{Values: []ast.Expression{}}
+	stmt := &ast.ReturnStatement{Token: parser.current_token, Values: []ast.Expression{}}
 
 	// Check if there's a return value
 	if parser.peek_token.Type != lexer.SEMICOLON && parser.peek_token.Type != lexer.END && parser.peek_token.Type != lexer.EOF {
@@ -283,14 +367,28 @@ func (parser *Parser) parse_return_statement() *ast.ReturnStatement {
 	return stmt
 }
 
-// parse_break_statement parses break statements
+// parse_break_statement parses break statements with an optional loop label
 func (parser *Parser) parse_break_statement() *ast.BreakStatement {
-	return &ast.BreakStatement{}
+	return &ast.BreakStatement{Token: parser.current_token, Label: parser.parse_loop_label()}
+}
+
+// parse_continue_statement parses continue statements with an optional loop label
+func (parser *Parser) parse_continue_statement() *ast.ContinueStatement {
+	return &ast.ContinueStatement{Token: parser.current_token, Label: parser.parse_loop_label()}
+}
+
+// parse_loop_label reads the label after break or continue; it must be on the same line
+func (parser *Parser) parse_loop_label() *ast.Identifier {
+	if parser.peek_token.Type != lexer.IDENT || parser.peek_token.Line != parser.current_token.Line {
+		return nil
+	}
+	parser.next_token()
+	return &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 }
 
 // parse_fn_statement parses function declarations
 func (parser *Parser) parse_fn_statement() *ast.FnStatement {
-	stmt := &ast.FnStatement{}
+	stmt := &ast.FnStatement{Token: parser.current_token}
 
 	// Check if next token is LPAREN - if so, this is an anonymous function expression, not a statement
 	if parser.peek_token.Type == lexer.LPAREN {
@@ -311,7 +409,7 @@ func (parser *Parser) parse_fn_statement() *ast.FnStatement {
 		}
 	}
 
-	stmt.Name = &ast.Identifier{Value: parser.current_token.Literal}
+	stmt.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 
 	if !parser.expect_peek(lexer.LPAREN) {
 		return nil
@@ -330,7 +428,7 @@ func (parser *Parser) parse_fn_statement() *ast.FnStatement {
 		return nil
 	}
 
-	stmt.Body = parser.parse_block_statement()
+	stmt.Body, stmt.Generator = parser.parse_function_body()
 
 	// Check for where block
 	if parser.current_token.Type == lexer.WHERE {
@@ -345,14 +443,14 @@ func (parser *Parser) parse_fn_statement() *ast.FnStatement {
 
 // parse_component_statement parses UI component declarations
 func (parser *Parser) parse_component_statement() *ast.ComponentStatement {
-	stmt := &ast.ComponentStatement{}
+	stmt := &ast.ComponentStatement{Token: parser.current_token}
 
 	// Expect component name
 	if !parser.expect_peek(lexer.IDENT) {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Value: parser.current_token.Literal}
+	stmt.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 
 	// Expect parameter list
 	if !parser.expect_peek(lexer.LPAREN) {
@@ -379,6 +477,7 @@ func (parser *Parser) parse_component_body() *ast.ComponentBody {
 	body.Statements = []ast.Statement{}
 
 	parser.next_token()
+	body.Token = parser.current_token
 
 	// Parse statements (var, const, assignments) until we hit a UI element or END
 	for parser.current_token.Type != lexer.END && parser.current_token.Type != lexer.EOF {
@@ -406,13 +505,13 @@ func (parser *Parser) parse_component_body() *ast.ComponentBody {
 
 // parse_struct_statement parses struct declarations
 func (parser *Parser) parse_struct_statement() *ast.StructStatement {
-	stmt := &ast.StructStatement{}
+	stmt := &ast.StructStatement{Token: parser.current_token}
 
 	if !parser.expect_peek(lexer.IDENT) {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Value: parser.current_token.Literal}
+	stmt.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 
 	if !parser.expect_peek(lexer.DOUBLE_COLON) {
 		return nil
@@ -423,8 +522,8 @@ func (parser *Parser) parse_struct_statement() *ast.StructStatement {
 	parser.next_token()
 	for parser.current_token.Type != lexer.END && parser.current_token.Type != lexer.EOF {
 		if parser.current_token.Type == lexer.IDENT {
-			field := &ast.StructField{}
-			field.Name = &ast.Identifier{Value: parser.current_token.Literal}
+			field := &ast.StructField{Token: parser.current_token}
+			field.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 
 			if !parser.expect_peek(lexer.COLON) {
 				return nil
@@ -447,33 +546,115 @@ func (parser *Parser) parse_struct_statement() *ast.StructStatement {
 
 // parse_type_statement parses type alias declarations
 func (parser *Parser) parse_type_statement() *ast.TypeStatement {
-	stmt := &ast.TypeStatement{}
+	stmt := &ast.TypeStatement{Token: parser.current_token}
 
 	if !parser.expect_peek(lexer.IDENT) {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Value: parser.current_token.Literal}
+	stmt.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 
 	if !parser.expect_peek(lexer.ASSIGN) {
 		return nil
 	}
 
 	parser.next_token()
+
+	// Variants with fields or separated by | declare a sum type instead of an alias
+	if parser.current_token.Type == lexer.IDENT &&
+		(parser.peek_token.Type == lexer.LPAREN || parser.peek_token.Type == lexer.PIPE) {
+		stmt.Variants = parser.parse_variants()
+		if stmt.Variants == nil {
+			return nil
+		}
+		return stmt
+	}
+
 	stmt.Type = parser.parse_type_annotation()
 
 	return stmt
 }
 
+// parse_variants parses the cases of a sum type: Circle(radius) | Rect(w: number, h: number) | Empty
+func (parser *Parser) parse_variants() []*ast.Variant {
+	variants := []*ast.Variant{}
+	seen := make(map[string]bool)
+
+	for {
+		if parser.current_token.Type != lexer.IDENT {
+			msg := fmt.Sprintf("line %d:%d: expected a variant name, got %s",
+				parser.current_token.Line, parser.current_token.Column, parser.current_token.Type)
+			parser.errors = append(parser.errors, msg)
+			return nil
+		}
+
+		variant := &ast.Variant{Token: parser.current_token}
+		variant.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+		if !unicode.IsUpper([]rune(variant.Name.Value)[0]) {
+			msg := fmt.Sprintf("line %d:%d: variant name '%s' must start with an uppercase letter",
+				variant.Token.Line, variant.Token.Column, variant.Name.Value)
+			parser.errors = append(parser.errors, msg)
+		}
+		if seen[variant.Name.Value] {
+			msg := fmt.Sprintf("line %d:%d: duplicate variant '%s'",
+				variant.Token.Line, variant.Token.Column, variant.Name.Value)
+			parser.errors = append(parser.errors, msg)
+		}
+		seen[variant.Name.Value] = true
+
+		if parser.peek_token.Type == lexer.LPAREN {
+			parser.next_token()
+			variant.Fields = parser.parse_variant_fields()
+			if variant.Fields == nil {
+				return nil
+			}
+		}
+		variants = append(variants, variant)
+
+		if parser.peek_token.Type != lexer.PIPE {
+			return variants
+		}
+		parser.next_token()
+		parser.next_token()
+	}
+}
+
+// parse_variant_fields parses (name, name: type, ...); field types are optional
+func (parser *Parser) parse_variant_fields() []*ast.StructField {
+	fields := []*ast.StructField{}
+
+	for parser.peek_token.Type != lexer.RPAREN {
+		if !parser.expect_peek(lexer.IDENT) {
+			return nil
+		}
+
+		field := &ast.StructField{Token: parser.current_token}
+		field.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+		if parser.peek_token.Type == lexer.COLON {
+			parser.next_token()
+			parser.next_token()
+			field.Type = parser.parse_type_annotation()
+		}
+		fields = append(fields, field)
+
+		if parser.peek_token.Type != lexer.RPAREN && !parser.expect_peek(lexer.COMMA) {
+			return nil
+		}
+	}
+
+	parser.next_token()
+	return fields
+}
+
 // parse_module_statement parses module statements
 func (parser *Parser) parse_module_statement() *ast.ModuleStatement {
-	stmt := &ast.ModuleStatement{}
+	stmt := &ast.ModuleStatement{Token: parser.current_token}
 
 	if !parser.expect_peek(lexer.IDENT) {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{Value: parser.current_token.Literal}
+	stmt.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 
 	if !parser.expect_peek(lexer.DOUBLE_COLON) {
 		return nil
@@ -486,13 +667,13 @@ func (parser *Parser) parse_module_statement() *ast.ModuleStatement {
 
 // parse_using_statement parses using statements for external module imports
 func (parser *Parser) parse_using_statement() *ast.UsingStatement {
-	stmt := &ast.UsingStatement{}
+	stmt := &ast.UsingStatement{Token: parser.current_token}
 
 	if !parser.expect_peek(lexer.STRING) {
 		return nil
 	}
 
-	stmt.Path = &ast.StringLiteral{Value: parser.current_token.Literal}
+	stmt.Path = &ast.StringLiteral{Token: parser.current_token, Value: parser.current_token.Literal}
 
 	// Check for optional "as" alias
 	if parser.peek_token.Type == lexer.AS {
@@ -500,7 +681,7 @@ func (parser *Parser) parse_using_statement() *ast.UsingStatement {
 		if !parser.expect_peek(lexer.IDENT) {
 			return nil
 		}
-		stmt.Alias = &ast.Identifier{Value: parser.current_token.Literal}
+		stmt.Alias = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 	}
 
 	return stmt
@@ -508,7 +689,7 @@ func (parser *Parser) parse_using_statement() *ast.UsingStatement {
 
 // parse_if_statement parses if statements
 func (parser *Parser) parse_if_statement() *ast.IfStatement {
-	stmt := &ast.IfStatement{}
+	stmt := &ast.IfStatement{Token: parser.current_token}
 
 	parser.next_token()
 	stmt.Condition = parser.parse_expression(LOWEST)
@@ -523,9 +704,9 @@ func (parser *Parser) parse_if_statement() *ast.IfStatement {
 	for parser.current_token.Type == lexer.ELSE {
 		if parser.peek_token.Type == lexer.IF {
 			// else if
+			else_if := &ast.ElseIfClause{Token: parser.current_token}
 			parser.next_token() // move to IF
 			parser.next_token() // move to condition
-			else_if := &ast.ElseIfClause{}
 			else_if.Condition = parser.parse_expression(LOWEST)
 			if !parser.expect_peek(lexer.DOUBLE_COLON) {
 				return nil
@@ -547,9 +728,85 @@ func (parser *Parser) parse_if_statement() *ast.IfStatement {
 	return stmt
 }
 
+// parse_try_statement parses try blocks with optional catch and ensure clauses
+func (parser *Parser) parse_try_statement() *ast.TryStatement {
+	stmt := &ast.TryStatement{Token: parser.current_token}
+
+	if !parser.expect_peek(lexer.DOUBLE_COLON) {
+		return nil
+	}
+
+	stmt.Body = parser.parse_block_statement()
+
+	if parser.current_token.Type == lexer.CATCH {
+		if parser.peek_token.Type == lexer.IDENT {
+			parser.next_token()
+			stmt.ErrorName = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+		}
+		if !parser.expect_peek(lexer.DOUBLE_COLON) {
+			return nil
+		}
+		stmt.CatchBlock = parser.parse_block_statement()
+	}
+
+	if parser.current_token.Type == lexer.ENSURE {
+		if !parser.expect_peek(lexer.DOUBLE_COLON) {
+			return nil
+		}
+		stmt.EnsureBlock = parser.parse_block_statement()
+	}
+
+	if stmt.CatchBlock == nil && stmt.EnsureBlock == nil {
+		msg := fmt.Sprintf("line %d:%d: try needs a catch or ensure clause",
+			stmt.Token.Line, stmt.Token.Column)
+		parser.errors = append(parser.errors, msg)
+		return nil
+	}
+
+	if parser.current_token.Type != lexer.END {
+		msg := fmt.Sprintf("line %d:%d: expected 'end' to close try, got %s",
+			parser.current_token.Line, parser.current_token.Column, parser.current_token.Type)
+		parser.errors = append(parser.errors, msg)
+		return nil
+	}
+
+	// parse_block_statement leaves us at END token - no need to expectPeek
+
+	return stmt
+}
+
+// parse_throw_statement parses throw statements
+func (parser *Parser) parse_throw_statement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: parser.current_token}
+
+	parser.next_token()
+	stmt.Value = parser.parse_expression(LOWEST)
+
+	return stmt
+}
+
+// parse_yield_statement parses yield statements, which turn the enclosing function into a generator
+func (parser *Parser) parse_yield_statement() *ast.YieldStatement {
+	stmt := &ast.YieldStatement{Token: parser.current_token}
+
+	if parser.function_depth == 0 {
+		msg := fmt.Sprintf("line %d:%d: yield outside of a function",
+			parser.current_token.Line, parser.current_token.Column)
+		parser.errors = append(parser.errors, msg)
+	}
+	parser.yields = true
+
+	if parser.peek_token.Type != lexer.SEMICOLON && parser.peek_token.Type != lexer.END && parser.peek_token.Type != lexer.EOF {
+		parser.next_token()
+		stmt.Value = parser.parse_expression(LOWEST)
+	}
+
+	return stmt
+}
+
 // parse_case_statement parses case statements
 func (parser *Parser) parse_case_statement() *ast.CaseStatement {
-	stmt := &ast.CaseStatement{}
+	stmt := &ast.CaseStatement{Token: parser.current_token}
 
 	parser.next_token()
 	stmt.Expression = parser.parse_expression(LOWEST)
@@ -562,16 +819,10 @@ func (parser *Parser) parse_case_statement() *ast.CaseStatement {
 
 	parser.next_token()
 	for parser.current_token.Type != lexer.END && parser.current_token.Type != lexer.EOF {
-		branch := &ast.CaseBranch{}
-		branch.Pattern = parser.parse_expression(LOWEST)
-
-		if !parser.expect_peek(lexer.ARROW) {
+		branch := parser.parse_case_branch()
+		if branch == nil {
 			return nil
 		}
-
-		parser.next_token()
-		branch.Result = parser.parse_expression(LOWEST)
-
 		stmt.Branches = append(stmt.Branches, branch)
 		parser.next_token()
 	}
@@ -581,7 +832,7 @@ func (parser *Parser) parse_case_statement() *ast.CaseStatement {
 
 // parse_case_expression parses case expressions (returns a value)
 func (parser *Parser) parse_case_expression() ast.Expression {
-	expr := &ast.CaseExpression{}
+	expr := &ast.CaseExpression{Token: parser.current_token}
 
 	parser.next_token()
 	expr.Expression = parser.parse_expression(LOWEST)
@@ -594,41 +845,319 @@ func (parser *Parser) parse_case_expression() ast.Expression {
 
 	parser.next_token()
 	for parser.current_token.Type != lexer.END && parser.current_token.Type != lexer.EOF {
-		branch := &ast.CaseBranch{}
-		branch.Pattern = parser.parse_expression(LOWEST)
-
-		if !parser.expect_peek(lexer.ARROW) {
+		branch := parser.parse_case_branch()
+		if branch == nil {
 			return nil
 		}
+		expr.Branches = append(expr.Branches, branch)
+		parser.next_token()
+	}
+
+	return expr
+}
 
+// parse_case_branch parses "pattern [if guard] => result"
+func (parser *Parser) parse_case_branch() *ast.CaseBranch {
+	branch := &ast.CaseBranch{Token: parser.current_token}
+	parser.in_case_pattern++
+	branch.Pattern = parser.parse_pattern()
+
+	if parser.peek_token.Type == lexer.IF {
+		parser.next_token()
 		parser.next_token()
-		branch.Result = parser.parse_expression(LOWEST)
+		branch.Guard = parser.parse_expression(LOWEST)
+	}
+	parser.in_case_pattern--
 
-		expr.Branches = append(expr.Branches, branch)
+	if !parser.expect_peek(lexer.ARROW) {
+		return nil
+	}
+
+	parser.next_token()
+	branch.Result = parser.parse_expression(LOWEST)
+
+	return branch
+}
+
+// parse_pattern parses a case pattern, including alternatives separated by |
+func (parser *Parser) parse_pattern() ast.Expression {
+	token := parser.current_token
+	pattern := parser.parse_single_pattern()
+	if parser.peek_token.Type != lexer.PIPE {
+		return pattern
+	}
+
+	alternatives := &ast.AlternativePattern{Token: token, Alternatives: []ast.Expression{pattern}}
+	for parser.peek_token.Type == lexer.PIPE {
 		parser.next_token()
+		parser.next_token()
+		alternatives.Alternatives = append(alternatives.Alternatives, parser.parse_single_pattern())
 	}
+	return alternatives
+}
 
-	return expr
+// parse_single_pattern parses one pattern; anything that is not structural is a value to compare with
+func (parser *Parser) parse_single_pattern() ast.Expression {
+	switch parser.current_token.Type {
+	case lexer.LBRACKET:
+		return parser.parse_array_pattern()
+	case lexer.LBRACE:
+		return parser.parse_map_pattern()
+	case lexer.IDENT:
+		name := &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+		switch {
+		case parser.peek_token.Type == lexer.COLON:
+			parser.next_token() // move to colon
+			parser.next_token() // move to type
+			return &ast.TypePattern{Token: name.Token, Name: name, Type: parser.parse_type_annotation()}
+		case parser.peek_token.Type == lexer.LPAREN && unicode.IsUpper([]rune(name.Value)[0]):
+			return parser.parse_struct_pattern(name)
+		}
+	}
+	// Stop before | so it separates alternatives instead of being a bitwise or
+	return parser.parse_expression(BIT_OR)
 }
 
-// parse_for_statement parses for loops
-func (parser *Parser) parse_for_statement() *ast.ForStatement {
-	stmt := &ast.ForStatement{}
+// parse_array_pattern parses [a, b, ...rest]
+func (parser *Parser) parse_array_pattern() ast.Expression {
+	pattern := &ast.ArrayPattern{Token: parser.current_token, Elements: []ast.Expression{}}
 
-	if !parser.expect_peek(lexer.IDENT) {
+	if parser.peek_token.Type == lexer.RBRACKET {
+		parser.next_token()
+		return pattern
+	}
+
+	has_rest := false
+	for {
+		parser.next_token()
+		if parser.current_token.Type == lexer.RANGE_INCLUSIVE {
+			rest := &ast.RestPattern{Token: parser.current_token}
+			if !parser.expect_peek(lexer.IDENT) {
+				return nil
+			}
+			if has_rest {
+				msg := fmt.Sprintf("line %d:%d: an array pattern can only have one rest element",
+					rest.Token.Line, rest.Token.Column)
+				parser.errors = append(parser.errors, msg)
+			}
+			has_rest = true
+			rest.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+			pattern.Elements = append(pattern.Elements, rest)
+		} else {
+			pattern.Elements = append(pattern.Elements, parser.parse_pattern())
+		}
+
+		if parser.peek_token.Type != lexer.COMMA {
+			break
+		}
+		parser.next_token()
+	}
+
+	if !parser.expect_peek(lexer.RBRACKET) {
 		return nil
 	}
+	return pattern
+}
 
-	// Check if this is "for index, value" or just "for value"
-	if parser.peek_token.Type == lexer.COMMA {
-		stmt.Index = &ast.Identifier{Value: parser.current_token.Literal}
+// parse_map_pattern parses {"key": pattern, ...}
+func (parser *Parser) parse_map_pattern() ast.Expression {
+	pattern := &ast.MapPattern{Token: parser.current_token}
+
+	for parser.peek_token.Type != lexer.RBRACE {
 		parser.next_token()
-		if !parser.expect_peek(lexer.IDENT) {
+		pattern.Keys = append(pattern.Keys, parser.parse_expression(LOWEST))
+
+		if !parser.expect_peek(lexer.COLON) {
+			return nil
+		}
+
+		parser.next_token()
+		pattern.Values = append(pattern.Values, parser.parse_pattern())
+
+		if parser.peek_token.Type != lexer.RBRACE && !parser.expect_peek(lexer.COMMA) {
+			return nil
+		}
+	}
+
+	if !parser.expect_peek(lexer.RBRACE) {
+		return nil
+	}
+	return pattern
+}
+
+// parse_struct_pattern parses Name(pattern, field: pattern, ...)
+func (parser *Parser) parse_struct_pattern(name *ast.Identifier) ast.Expression {
+	pattern := &ast.StructPattern{Token: name.Token, Name: name, Arguments: []ast.Expression{}}
+	parser.next_token() // move to (
+
+	for parser.peek_token.Type != lexer.RPAREN {
+		parser.next_token()
+		if parser.current_token.Type == lexer.IDENT && parser.peek_token.Type == lexer.COLON {
+			field := &ast.NamedArgument{Token: parser.current_token}
+			field.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+			parser.next_token() // move to colon
+			parser.next_token() // move to pattern
+			field.Value = parser.parse_pattern()
+			pattern.Arguments = append(pattern.Arguments, field)
+		} else {
+			pattern.Arguments = append(pattern.Arguments, parser.parse_pattern())
+		}
+
+		if parser.peek_token.Type != lexer.RPAREN && !parser.expect_peek(lexer.COMMA) {
 			return nil
 		}
 	}
 
-	stmt.Variable = &ast.Identifier{Value: parser.current_token.Literal}
+	if !parser.expect_peek(lexer.RPAREN) {
+		return nil
+	}
+	return pattern
+}
+
+// parse_binding_pattern parses what a destructuring var, for or parameter binds:
+// a name, [a, b, ...rest] or {key, key: pattern, "key": pattern}
+func (parser *Parser) parse_binding_pattern() ast.Expression {
+	switch parser.current_token.Type {
+	case lexer.IDENT:
+		return &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+	case lexer.LBRACKET:
+		return parser.parse_array_binding()
+	case lexer.LBRACE:
+		return parser.parse_map_binding()
+	}
+
+	msg := fmt.Sprintf("line %d:%d: expected a name or destructuring pattern, got %s",
+		parser.current_token.Line, parser.current_token.Column, parser.current_token.Type)
+	parser.errors = append(parser.errors, msg)
+	return nil
+}
+
+// parse_binding_element parses a binding pattern nested in another, which may have a default
+func (parser *Parser) parse_binding_element() ast.Expression {
+	target := parser.parse_binding_pattern()
+	if target == nil || parser.peek_token.Type != lexer.ASSIGN {
+		return target
+	}
+
+	parser.next_token()
+	pattern := &ast.DefaultPattern{Token: parser.current_token, Target: target}
+	parser.next_token()
+	pattern.Default = parser.parse_expression(LOWEST)
+	return pattern
+}
+
+// parse_array_binding parses [a, [b, c], d = 1, ...rest]
+func (parser *Parser) parse_array_binding() ast.Expression {
+	pattern := &ast.ArrayPattern{Token: parser.current_token, Elements: []ast.Expression{}}
+
+	has_rest := false
+	for parser.peek_token.Type != lexer.RBRACKET {
+		parser.next_token()
+		if parser.current_token.Type == lexer.RANGE_INCLUSIVE {
+			rest := &ast.RestPattern{Token: parser.current_token}
+			if !parser.expect_peek(lexer.IDENT) {
+				return nil
+			}
+			if has_rest {
+				msg := fmt.Sprintf("line %d:%d: an array pattern can only have one rest element",
+					rest.Token.Line, rest.Token.Column)
+				parser.errors = append(parser.errors, msg)
+			}
+			has_rest = true
+			rest.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+			pattern.Elements = append(pattern.Elements, rest)
+		} else {
+			element := parser.parse_binding_element()
+			if element == nil {
+				return nil
+			}
+			pattern.Elements = append(pattern.Elements, element)
+		}
+
+		if parser.peek_token.Type != lexer.RBRACKET && !parser.expect_peek(lexer.COMMA) {
+			return nil
+		}
+	}
+
+	parser.next_token()
+	return pattern
+}
+
+// parse_map_binding parses {name, age = 0, address: {city}, "full name": full}.
+// A bare name reads the key of the same name.
+func (parser *Parser) parse_map_binding() ast.Expression {
+	pattern := &ast.MapPattern{Token: parser.current_token}
+
+	for parser.peek_token.Type != lexer.RBRACE {
+		parser.next_token()
+
+		var key ast.Expression
+		switch parser.current_token.Type {
+		case lexer.IDENT:
+			key = &ast.StringLiteral{Token: parser.current_token, Value: parser.current_token.Literal}
+		case lexer.STRING:
+			key = &ast.StringLiteral{Token: parser.current_token, Value: parser.current_token.Literal}
+			if parser.peek_token.Type != lexer.COLON {
+				parser.peek_error(lexer.COLON)
+				return nil
+			}
+		default:
+			msg := fmt.Sprintf("line %d:%d: expected a key in map pattern, got %s",
+				parser.current_token.Line, parser.current_token.Column, parser.current_token.Type)
+			parser.errors = append(parser.errors, msg)
+			return nil
+		}
+
+		var value ast.Expression
+		if parser.peek_token.Type == lexer.COLON {
+			parser.next_token()
+			parser.next_token()
+			value = parser.parse_binding_element()
+		} else {
+			value = parser.parse_binding_element()
+		}
+		if value == nil {
+			return nil
+		}
+		pattern.Keys = append(pattern.Keys, key)
+		pattern.Values = append(pattern.Values, value)
+
+		if parser.peek_token.Type != lexer.RBRACE && !parser.expect_peek(lexer.COMMA) {
+			return nil
+		}
+	}
+
+	parser.next_token()
+	return pattern
+}
+
+// parse_for_statement parses for loops
+func (parser *Parser) parse_for_statement() *ast.ForStatement {
+	stmt := &ast.ForStatement{Token: parser.current_token}
+	parser.next_token()
+
+	// Check if this is "for index, value" or just "for value"
+	if parser.current_token.Type == lexer.IDENT && parser.peek_token.Type == lexer.COMMA {
+		stmt.Index = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+		parser.next_token()
+		parser.next_token()
+	}
+
+	switch parser.current_token.Type {
+	case lexer.IDENT:
+		stmt.Variable = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+	case lexer.LBRACKET, lexer.LBRACE:
+		// for [key, value] in pairs destructures each element
+		stmt.Pattern = parser.parse_binding_pattern()
+		if stmt.Pattern == nil {
+			return nil
+		}
+	default:
+		msg := fmt.Sprintf("line %d:%d: expected a loop variable or destructuring pattern, got %s",
+			parser.current_token.Line, parser.current_token.Column, parser.current_token.Type)
+		parser.errors = append(parser.errors, msg)
+		return nil
+	}
 
 	if !parser.expect_peek(lexer.IN) {
 		return nil
@@ -648,9 +1177,56 @@ func (parser *Parser) parse_for_statement() *ast.ForStatement {
 	return stmt
 }
 
+// parse_while_statement parses while loops
+func (parser *Parser) parse_while_statement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: parser.current_token}
+
+	parser.next_token()
+	stmt.Condition = parser.parse_expression(LOWEST)
+
+	if !parser.expect_peek(lexer.DOUBLE_COLON) {
+		return nil
+	}
+
+	stmt.Body = parser.parse_block_statement()
+
+	// parse_block_statement leaves us at END token - no need to expectPeek
+
+	return stmt
+}
+
+// parse_labeled_loop parses "label: for ..." and "label: while ..."
+func (parser *Parser) parse_labeled_loop() ast.Statement {
+	label := &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+	parser.next_token() // move to colon
+	parser.next_token() // move to loop keyword
+
+	switch parser.current_token.Type {
+	case lexer.FOR:
+		stmt := parser.parse_for_statement()
+		if stmt == nil {
+			return nil
+		}
+		stmt.Label = label
+		return stmt
+	case lexer.WHILE:
+		stmt := parser.parse_while_statement()
+		if stmt == nil {
+			return nil
+		}
+		stmt.Label = label
+		return stmt
+	}
+
+	msg := fmt.Sprintf("line %d:%d: label '%s' must be followed by a for or while loop",
+		label.Token.Line, label.Token.Column, label.Value)
+	parser.errors = append(parser.errors, msg)
+	return nil
+}
+
 // parse_check_statement parses check blocks
 func (parser *Parser) parse_check_statement() *ast.CheckStatement {
-	stmt := &ast.CheckStatement{}
+	stmt := &ast.CheckStatement{Token: parser.current_token}
 
 	// Check for optional label
 	if parser.peek_token.Type == lexer.STRING {
@@ -662,14 +1238,14 @@ func (parser *Parser) parse_check_statement() *ast.CheckStatement {
 		return nil
 	}
 
-    stmt.Statements = []ast.Statement{}
+	stmt.Statements = []ast.Statement{}
 	stmt.Assertions = []*ast.Assertion{}
 
 	parser.next_token()
 	for parser.current_token.Type != lexer.END && parser.current_token.Type != lexer.EOF {
 		// Try to parse as a statement first (var, const, for, if, etc.)
 		// Check if the current token can start a statement
-		if parser.is_statement_token(parser.current_token.Type) {
+		if parser.is_statement_token(parser.current_token.Type) || parser.is_loop_label() {
 			statement := parser.parse_statement()
 			if statement != nil {
 				stmt.Statements = append(stmt.Statements, statement)
@@ -689,20 +1265,35 @@ func (parser *Parser) parse_check_statement() *ast.CheckStatement {
 
 // parse_expression_statement parses expression statements
 func (parser *Parser) parse_expression_statement() *ast.ExpressionStatement {
-	stmt := &ast.ExpressionStatement{}
+	stmt := &ast.ExpressionStatement{Token: parser.current_token}
 	stmt.Expression = parser.parse_expression(LOWEST)
 	return stmt
 }
 
+// parse_function_body parses the body of a function and reports whether it yields
+func (parser *Parser) parse_function_body() (*ast.BlockStatement, bool) {
+	outer := parser.yields
+	parser.function_depth++
+	parser.yields = false
+
+	body := parser.parse_block_statement()
+	generator := parser.yields
+
+	parser.function_depth--
+	parser.yields = outer
+	return body, generator
+}
+
 // parse_bock_statement parses a block of statements
 func (parser *Parser) parse_block_statement() *ast.BlockStatement {
-	block := &ast.BlockStatement{}
+	block := &ast.BlockStatement{Token: parser.current_token}
 	block.Statements = []ast.Statement{}
 
 	parser.next_token()
 
 	for parser.current_token.Type != lexer.END && parser.current_token.Type != lexer.EOF &&
-		parser.current_token.Type != lexer.WHERE && parser.current_token.Type != lexer.ELSE {
+		parser.current_token.Type != lexer.WHERE && parser.current_token.Type != lexer.ELSE &&
+		parser.current_token.Type != lexer.CATCH && parser.current_token.Type != lexer.ENSURE {
 		if parser.current_token.Type == lexer.COMMENT {
 			// Skip comments
 			parser.next_token()
@@ -735,39 +1326,67 @@ func (parser *Parser) parse_function_parameters() []*ast.Parameter {
 	}
 
 	parser.next_token()
+	params = append(params, parser.parse_parameter())
 
-	param := &ast.Parameter{}
-	param.Name = &ast.Identifier{Value: parser.current_token.Literal}
-
-	if parser.peek_token.Type == lexer.COLON {
+	for parser.peek_token.Type == lexer.COMMA {
 		parser.next_token()
 		parser.next_token()
-		param.Type = parser.parse_type_annotation()
+		params = append(params, parser.parse_parameter())
 	}
 
-	params = append(params, param)
+	if !parser.expect_peek(lexer.RPAREN) {
+		return nil
+	}
 
-	for parser.peek_token.Type == lexer.COMMA {
-		parser.next_token()
-		parser.next_token()
+	// A rest parameter takes whatever is left, so nothing can follow it
+	for _, param := range params[:len(params)-1] {
+		if param.Rest {
+			msg := fmt.Sprintf("line %d:%d: rest parameter '...%s' must be the last parameter",
+				param.Token.Line, param.Token.Column, param.Name.Value)
+			parser.errors = append(parser.errors, msg)
+		}
+	}
 
-		param := &ast.Parameter{}
-		param.Name = &ast.Identifier{Value: parser.current_token.Literal}
+	return params
+}
 
-		if parser.peek_token.Type == lexer.COLON {
-			parser.next_token()
-			parser.next_token()
-			param.Type = parser.parse_type_annotation()
+// parse_parameter parses name, name: type, name = default or ...name
+func (parser *Parser) parse_parameter() *ast.Parameter {
+	param := &ast.Parameter{Token: parser.current_token}
+	switch parser.current_token.Type {
+	case lexer.RANGE_INCLUSIVE:
+		param.Rest = true
+		parser.next_token()
+	case lexer.LBRACKET, lexer.LBRACE:
+		// Destructured parameters are named after their pattern in error messages
+		param.Pattern = parser.parse_binding_pattern()
+		if param.Pattern == nil {
+			return nil
 		}
+		param.Name = &ast.Identifier{Token: param.Token, Value: param.Pattern.String()}
+	}
+	if param.Name == nil {
+		param.Name = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+	}
 
-		params = append(params, param)
+	if parser.peek_token.Type == lexer.COLON {
+		parser.next_token()
+		parser.next_token()
+		param.Type = parser.parse_type_annotation()
 	}
 
-	if !parser.expect_peek(lexer.RPAREN) {
-		return nil
+	if parser.peek_token.Type == lexer.ASSIGN {
+		parser.next_token()
+		if param.Rest {
+			msg := fmt.Sprintf("line %d:%d: rest parameter '...%s' cannot have a default value",
+				parser.current_token.Line, parser.current_token.Column, param.Name.Value)
+			parser.errors = append(parser.errors, msg)
+		}
+		parser.next_token()
+		param.Default = parser.parse_expression(LOWEST)
 	}
 
-	return params
+	return param
 }
 
 // parse_type_annotation parses type annotations
@@ -785,7 +1404,7 @@ func (parser *Parser) parse_type_annotation() *ast.TypeAnnotation {
 	default:
 		type_name = parser.current_token.Literal
 	}
-	ta := &ast.TypeAnnotation{Name: type_name}
+	ta := &ast.TypeAnnotation{Token: parser.current_token, Name: type_name}
 
 	// Handle generic types like Array[String]
 	if parser.peek_token.Type == lexer.LBRACKET {
@@ -811,7 +1430,7 @@ func (parser *Parser) parse_type_annotation() *ast.TypeAnnotation {
 
 // parse_where_block parses where test blocks
 func (parser *Parser) parse_where_block() *ast.WhereBlock {
-	wb := &ast.WhereBlock{}
+	wb := &ast.WhereBlock{Token: parser.current_token}
 
 	if !parser.expect_peek(lexer.DOUBLE_COLON) {
 		return nil
@@ -830,7 +1449,7 @@ func (parser *Parser) parse_where_block() *ast.WhereBlock {
 
 		// Try to parse as a statement first (var, const, for, if, etc.)
 		// Check if the current token can start a statement
-		if parser.is_statement_token(parser.current_token.Type) {
+		if parser.is_statement_token(parser.current_token.Type) || parser.is_loop_label() {
 			statement := parser.parse_statement()
 			if statement != nil {
 				wb.Statements = append(wb.Statements, statement)
@@ -860,6 +1479,7 @@ func (parser *Parser) parse_assertion() *ast.Assertion {
 	}
 
 	parser.next_token()
+	assertion.Token = parser.current_token
 	assertion.Operator = parser.current_token.Literal
 
 	// Check if this is a unary assertion (no right operand)
@@ -915,7 +1535,16 @@ func (parser *Parser) is_statement_token(token_type lexer.TokenType) bool {
 		token_type == lexer.FOR ||
 		token_type == lexer.IF ||
 		token_type == lexer.RETURN ||
-		token_type == lexer.BREAK
+		token_type == lexer.WHILE ||
+		token_type == lexer.BREAK ||
+		token_type == lexer.CONTINUE ||
+		token_type == lexer.TRY ||
+		token_type == lexer.THROW
+}
+
+// is_loop_label checks if the current token labels a loop
+func (parser *Parser) is_loop_label() bool {
+	return parser.current_token.Type == lexer.IDENT && parser.peek_token.Type == lexer.COLON
 }
 
 // parse_expression parses expressions using Pratt parsing
@@ -933,6 +1562,12 @@ func (parser *Parser) parse_expression(precedence int) ast.Expression {
 			return left_exp
 		}
 
+		// A ( or [ on a new line starts the next statement or case pattern instead of calling or indexing
+		if (parser.peek_token.Type == lexer.LPAREN || parser.peek_token.Type == lexer.LBRACKET) &&
+			parser.peek_token.Line > parser.current_token.Line {
+			return left_exp
+		}
+
 		parser.next_token()
 		left_exp = infix(left_exp)
 	}
@@ -942,11 +1577,15 @@ func (parser *Parser) parse_expression(precedence int) ast.Expression {
 
 // Prefix parsing functions
 func (parser *Parser) parse_identifier() ast.Expression {
-	return &ast.Identifier{Value: parser.current_token.Literal}
+	ident := &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
+	if parser.peek_token.Type == lexer.ARROW && parser.in_case_pattern == 0 {
+		return parser.parse_arrow_function([]*ast.Parameter{{Token: ident.Token, Name: ident}})
+	}
+	return ident
 }
 
 func (parser *Parser) parse_number_literal() ast.Expression {
-	return &ast.NumberLiteral{Value: parser.current_token.Literal}
+	return &ast.NumberLiteral{Token: parser.current_token, Value: parser.current_token.Literal}
 }
 
 func (parser *Parser) parse_string_literal() ast.Expression {
@@ -954,7 +1593,7 @@ func (parser *Parser) parse_string_literal() ast.Expression {
 
 	// Check if string contains interpolation patterns #{...}
 	if !contains_interpolation(str_value) {
-		return &ast.StringLiteral{Value: str_value}
+		return &ast.StringLiteral{Token: parser.current_token, Value: str_value}
 	}
 
 	// Parse interpolated string
@@ -1004,7 +1643,7 @@ func (parser *Parser) parse_interpolated_string(str_value string) ast.Expression
 
 			if brace_depth != 0 {
 				// Unclosed interpolation - treat as regular string
-				return &ast.StringLiteral{Value: str_value}
+				return &ast.StringLiteral{Token: parser.current_token, Value: str_value}
 			}
 
 			// Parse the expression inside #{}
@@ -1036,23 +1675,24 @@ func (parser *Parser) parse_interpolated_string(str_value string) ast.Expression
 		}
 	}
 
-	return &ast.InterpolatedString{Parts: parts}
+	return &ast.InterpolatedString{Token: parser.current_token, Parts: parts}
 }
 
 func (parser *Parser) parse_boolean_literal() ast.Expression {
-	return &ast.BooleanLiteral{Value: parser.current_token.Type == lexer.TRUE}
+	return &ast.BooleanLiteral{Token: parser.current_token, Value: parser.current_token.Type == lexer.TRUE}
 }
 
 func (parser *Parser) parse_nil_literal() ast.Expression {
-	return &ast.NilLiteral{}
+	return &ast.NilLiteral{Token: parser.current_token}
 }
 
 func (parser *Parser) parse_self_expression() ast.Expression {
-	return &ast.Identifier{Value: "self"}
+	return &ast.Identifier{Token: parser.current_token, Value: "self"}
 }
 
 func (parser *Parser) parse_prefix_expression() ast.Expression {
 	expression := &ast.PrefixExpression{
+		Token:    parser.current_token,
 		Operator: parser.current_token.Literal,
 	}
 
@@ -1063,6 +1703,24 @@ func (parser *Parser) parse_prefix_expression() ast.Expression {
 }
 
 func (parser *Parser) parse_grouped_expression() ast.Expression {
+	if parser.in_case_pattern == 0 && parser.is_arrow_function() {
+		params := parser.parse_function_parameters()
+		var return_type *ast.TypeAnnotation
+		if parser.peek_token.Type == lexer.COLON {
+			parser.next_token()
+			parser.next_token()
+			return_type = parser.parse_type_annotation()
+		}
+		lit := parser.parse_arrow_function(params).(*ast.FunctionLiteral)
+		lit.ReturnType = return_type
+		return lit
+	}
+
+	// Inside parentheses => can only start an arrow function
+	saved := parser.in_case_pattern
+	parser.in_case_pattern = 0
+	defer func() { parser.in_case_pattern = saved }()
+
 	parser.next_token()
 	exp := parser.parse_expression(LOWEST)
 	if !parser.expect_peek(lexer.RPAREN) {
@@ -1071,14 +1729,82 @@ func (parser *Parser) parse_grouped_expression() ast.Expression {
 	return exp
 }
 
+// is_arrow_function reports whether the ( at the current token opens the parameters of an arrow function.
+// It scans a copy of the lexer to the matching ) and checks that => follows.
+func (parser *Parser) is_arrow_function() bool {
+	scanner := *parser.lexer
+	depth := 1
+	for tok := parser.peek_token; tok.Type != lexer.EOF; tok = scanner.NextToken() {
+		switch tok.Type {
+		case lexer.LPAREN, lexer.LBRACKET, lexer.LBRACE:
+			depth++
+		case lexer.RPAREN, lexer.RBRACKET, lexer.RBRACE:
+			depth--
+		}
+		if depth == 0 {
+			return tok.Type == lexer.RPAREN && follows_arrow(&scanner)
+		}
+	}
+	return false
+}
+
+// follows_arrow reports whether the scanner is at =>, possibly after a return type annotation
+func follows_arrow(scanner *lexer.Lexer) bool {
+	tok := next_significant(scanner)
+	if tok.Type != lexer.COLON {
+		return tok.Type == lexer.ARROW
+	}
+
+	// Return types are names, optionally with [element] types
+	depth := 0
+	for tok = next_significant(scanner); ; tok = next_significant(scanner) {
+		switch tok.Type {
+		case lexer.IDENT, lexer.NUMBER_TYPE, lexer.STRING_TYPE, lexer.BOOLEAN_TYPE:
+		case lexer.LBRACKET:
+			depth++
+		case lexer.RBRACKET:
+			depth--
+		case lexer.COMMA:
+			if depth == 0 {
+				return false
+			}
+		default:
+			return tok.Type == lexer.ARROW && depth == 0
+		}
+	}
+}
+
+// next_significant returns the next token that is not a comment
+func next_significant(scanner *lexer.Lexer) lexer.Token {
+	tok := scanner.NextToken()
+	for tok.Type == lexer.COMMENT {
+		tok = scanner.NextToken()
+	}
+	return tok
+}
+
+// parse_arrow_function parses the => and expression body of x => body or (a, b) => body.
+// The body is returned implicitly, so the result is an ordinary function literal.
+func (parser *Parser) parse_arrow_function(params []*ast.Parameter) ast.Expression {
+	parser.next_token() // move to =>
+	lit := &ast.FunctionLiteral{Token: parser.current_token, Parameters: params, Arrow: true}
+	body := &ast.ReturnStatement{Token: parser.current_token}
+
+	parser.next_token()
+	body.Values = []ast.Expression{parser.parse_expression(LOWEST)}
+	lit.Body = &ast.BlockStatement{Token: lit.Token, Statements: []ast.Statement{body}}
+
+	return lit
+}
+
 func (parser *Parser) parse_array_literal() ast.Expression {
-	array := &ast.ArrayLiteral{}
+	array := &ast.ArrayLiteral{Token: parser.current_token}
 	array.Elements = parser.parse_expression_list(lexer.RBRACKET)
 	return array
 }
 
 func (parser *Parser) parse_map_literal() ast.Expression {
-	map_lit := &ast.MapLiteral{}
+	map_lit := &ast.MapLiteral{Token: parser.current_token}
 	map_lit.Pairs = []ast.MapPair{}
 
 	if parser.peek_token.Type == lexer.RBRACE {
@@ -1089,6 +1815,10 @@ func (parser *Parser) parse_map_literal() ast.Expression {
 	parser.next_token()
 
 	key := parser.parse_expression(LOWEST)
+	// Without a colon after the first element this is a set
+	if parser.peek_token.Type == lexer.COMMA || parser.peek_token.Type == lexer.RBRACE {
+		return parser.parse_set_literal(map_lit.Token, key)
+	}
 	if !parser.expect_peek(lexer.COLON) {
 		return nil
 	}
@@ -1118,9 +1848,27 @@ func (parser *Parser) parse_map_literal() ast.Expression {
 	return map_lit
 }
 
+// parse_set_literal parses the rest of {a, b, c} once its first element has been read
+func (parser *Parser) parse_set_literal(token lexer.Token, first ast.Expression) ast.Expression {
+	set_lit := &ast.SetLiteral{Token: token, Elements: []ast.Expression{first}}
+
+	for parser.peek_token.Type == lexer.COMMA {
+		parser.next_token()
+		parser.next_token()
+		set_lit.Elements = append(set_lit.Elements, parser.parse_expression(LOWEST))
+	}
+
+	if !parser.expect_peek(lexer.RBRACE) {
+		return nil
+	}
+
+	return set_lit
+}
+
 // Infix parsing functions
 func (parser *Parser) parse_infix_expression(left ast.Expression) ast.Expression {
 	expression := &ast.InfixExpression{
+		Token:    parser.current_token,
 		Left:     left,
 		Operator: parser.current_token.Literal,
 	}
@@ -1133,13 +1881,56 @@ func (parser *Parser) parse_infix_expression(left ast.Expression) ast.Expression
 }
 
 func (parser *Parser) parse_call_expression(fn ast.Expression) ast.Expression {
-	exp := &ast.CallExpression{Function: fn}
-	exp.Arguments = parser.parse_expression_list(lexer.RPAREN)
+	exp := &ast.CallExpression{Token: parser.current_token, Function: fn}
+	exp.Arguments = parser.parse_call_arguments()
 	return exp
 }
 
+// parse_call_arguments parses call arguments, allowing named arguments (name: value)
+func (parser *Parser) parse_call_arguments() []ast.Expression {
+	args := []ast.Expression{}
+
+	// Arguments may be arrow functions even inside a case pattern or guard
+	saved := parser.in_case_pattern
+	parser.in_case_pattern = 0
+	defer func() { parser.in_case_pattern = saved }()
+
+	if parser.peek_token.Type == lexer.RPAREN {
+		parser.next_token()
+		return args
+	}
+
+	parser.next_token()
+	args = append(args, parser.parse_call_argument())
+
+	for parser.peek_token.Type == lexer.COMMA {
+		parser.next_token()
+		parser.next_token()
+		args = append(args, parser.parse_call_argument())
+	}
+
+	if !parser.expect_peek(lexer.RPAREN) {
+		return nil
+	}
+
+	return args
+}
+
+// parse_call_argument parses a single positional or named argument
+func (parser *Parser) parse_call_argument() ast.Expression {
+	if parser.current_token.Type == lexer.IDENT && parser.peek_token.Type == lexer.COLON {
+		arg := &ast.NamedArgument{Token: parser.current_token, Name: &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}}
+		parser.next_token() // move to colon
+		parser.next_token() // move to value
+		arg.Value = parser.parse_expression(LOWEST)
+		return arg
+	}
+
+	return parser.parse_expression(LOWEST)
+}
+
 func (parser *Parser) parse_index_expression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Left: left}
+	exp := &ast.IndexExpression{Token: parser.current_token, Left: left, Optional: parser.current_token.Type == lexer.OPTIONAL_INDEX}
 
 	parser.next_token()
 	exp.Index = parser.parse_expression(LOWEST)
@@ -1151,8 +1942,30 @@ func (parser *Parser) parse_index_expression(left ast.Expression) ast.Expression
 	return exp
 }
 
+// parse_pipeline_expression parses value |> f, where the right side is a function or a call
+func (parser *Parser) parse_pipeline_expression(left ast.Expression) ast.Expression {
+	exp := &ast.PipelineExpression{Token: parser.current_token, Left: left}
+
+	precedence := parser.current_precedence()
+	parser.next_token()
+	exp.Right = parser.parse_expression(precedence)
+
+	return exp
+}
+
+// parse_optional_expression parses the nil-safe a?.b and f?.() that evaluate to null when the left side is null
+func (parser *Parser) parse_optional_expression(left ast.Expression) ast.Expression {
+	if parser.peek_token.Type == lexer.LPAREN {
+		parser.next_token()
+		exp := parser.parse_call_expression(left).(*ast.CallExpression)
+		exp.Optional = true
+		return exp
+	}
+	return parser.parse_dot_expression(left)
+}
+
 func (parser *Parser) parse_dot_expression(left ast.Expression) ast.Expression {
-	exp := &ast.DotExpression{Left: left}
+	exp := &ast.DotExpression{Token: parser.current_token, Left: left, Optional: parser.current_token.Type == lexer.OPTIONAL_DOT}
 
 	// Allow both identifiers and keywords as property names
 	parser.next_token()
@@ -1163,7 +1976,7 @@ func (parser *Parser) parse_dot_expression(left ast.Expression) ast.Expression {
 		return nil
 	}
 
-	exp.Property = &ast.Identifier{Value: parser.current_token.Literal}
+	exp.Property = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 	return exp
 }
 
@@ -1201,6 +2014,7 @@ func (parser *Parser) is_identifier_like(t lexer.TokenType) bool {
 
 func (parser *Parser) parse_range_expression(left ast.Expression) ast.Expression {
 	exp := &ast.RangeExpression{
+		Token:     parser.current_token,
 		Start:     left,
 		Inclusive: parser.current_token.Type == lexer.RANGE_INCLUSIVE,
 	}
@@ -1212,8 +2026,9 @@ func (parser *Parser) parse_range_expression(left ast.Expression) ast.Expression
 	return exp
 }
 
+// parse_assignment_expression parses = and the compound assignments like += that combine with the current value
 func (parser *Parser) parse_assignment_expression(left ast.Expression) ast.Expression {
-	exp := &ast.AssignmentExpression{Left: left}
+	exp := &ast.AssignmentExpression{Token: parser.current_token, Left: left, Operator: parser.current_token.Literal}
 
 	parser.next_token()
 	exp.Value = parser.parse_expression(LOWEST)
@@ -1285,7 +2100,7 @@ func (parser *Parser) no_prefix_parse_fn_error(t lexer.TokenType) {
 
 // parse_function_literal parses function literals: (params) :: body end
 func (parser *Parser) parse_function_literal() ast.Expression {
-	lit := &ast.FunctionLiteral{}
+	lit := &ast.FunctionLiteral{Token: parser.current_token}
 
 	// We're already at LPAREN
 	lit.Parameters = parser.parse_function_parameters()
@@ -1296,14 +2111,14 @@ func (parser *Parser) parse_function_literal() ast.Expression {
 	}
 
 	parser.next_token() // consume DOUBLE_COLON
-	lit.Body = parser.parse_block_statement()
+	lit.Body, lit.Generator = parser.parse_function_body()
 
 	return lit
 }
 
 // parse_anonymous_function parses anonymous function expressions: fn() :: body end
 func (parser *Parser) parse_anonymous_function() ast.Expression {
-	lit := &ast.FunctionLiteral{}
+	lit := &ast.FunctionLiteral{Token: parser.current_token}
 
 	// We're at FN token
 	if !parser.expect_peek(lexer.LPAREN) {
@@ -1312,11 +2127,18 @@ func (parser *Parser) parse_anonymous_function() ast.Expression {
 
 	lit.Parameters = parser.parse_function_parameters()
 
+	// Optional return type
+	if parser.peek_token.Type == lexer.COLON {
+		parser.next_token()
+		parser.next_token()
+		lit.ReturnType = parser.parse_type_annotation()
+	}
+
 	if !parser.expect_peek(lexer.DOUBLE_COLON) {
 		return nil
 	}
 
-	lit.Body = parser.parse_block_statement()
+	lit.Body, lit.Generator = parser.parse_function_body()
 
 	return lit
 }
@@ -1324,10 +2146,10 @@ func (parser *Parser) parse_anonymous_function() ast.Expression {
 // parse_ui_element parses a UI element tree
 // Syntax: ElementName { property: value, property: value, ChildElement { ... } }
 func (parser *Parser) parse_ui_element() *ast.UIElement {
-	element := &ast.UIElement{}
+	element := &ast.UIElement{Token: parser.current_token}
 
 	// Current token should be the element type identifier (Window, VBox, etc.)
-	element.Type = &ast.Identifier{Value: parser.current_token.Literal}
+	element.Type = &ast.Identifier{Token: parser.current_token, Value: parser.current_token.Literal}
 	element.Properties = make(map[string]ast.Expression)
 	element.Children = []*ast.UIElement{}
 
@@ -2,6 +2,7 @@ package parser
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/vpaulo/seda/ast"
@@ -274,6 +275,67 @@ func TestCaseStatements(t *testing.T) {
 	}
 }
 
+func TestCasePatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[first, ...rest] => first", "[first, ...rest]"},
+		{`{"type": "circle", "r": r} => r`, `{"type": "circle", "r": r}`},
+		{"Point(x: 0, y) => y", "Point(x: 0, y)"},
+		{"n: number => n", "n: number"},
+		{"1 | 2 | 3 => 0", "1 | 2 | 3"},
+		{"1..10 => 0", "1..10"},
+	}
+
+	for _, tt := range tests {
+		input := "case value ::\n  " + tt.input + "\nend"
+		p := New(lexer.New(input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.CaseStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.CaseStatement. got=%T", program.Statements[0])
+		}
+		if got := stmt.Branches[0].Pattern.String(); got != tt.expected {
+			t.Errorf("pattern wrong. want %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestCaseGuards(t *testing.T) {
+	input := `case point ::
+  [x, y] if x > y => x
+  [x, y]
+    => y
+end`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.CaseStatement)
+	if len(stmt.Branches) != 2 {
+		t.Fatalf("expected 2 case branches, got %d", len(stmt.Branches))
+	}
+	if stmt.Branches[0].Guard == nil || stmt.Branches[0].Guard.String() != "(x > y)" {
+		t.Errorf("guard wrong. got %v", stmt.Branches[0].Guard)
+	}
+	if stmt.Branches[1].Guard != nil {
+		t.Errorf("expected no guard on second branch, got %s", stmt.Branches[1].Guard)
+	}
+}
+
+func TestArrayPatternSingleRest(t *testing.T) {
+	p := New(lexer.New("case items ::\n  [...a, ...b] => a\nend"))
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected a parse error for two rest elements")
+	}
+}
+
 func TestForStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -325,6 +387,217 @@ func TestForStatements(t *testing.T) {
 	}
 }
 
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x => x * 2", "(x) => (x * 2)"},
+		{"(a, b) => a + b", "(a, b) => (a + b)"},
+		{"() => 42", "() => 42"},
+		{"(n: number, step = 1): number => n + step", "(n: number, step = 1): number => (n + step)"},
+		{"a => b => a - b", "(a) => (b) => (a - b)"},
+		{"items.map(x => x.name)", "items.map((x) => x.name)"},
+		{"items.reduce((acc, x) => acc + x, 0)", "items.reduce((acc, x) => (acc + x), 0)"},
+		{"(a + b) * c", "((a + b) * c)"},
+		{"var f = ([x, y]) => x", "var f = ([x, y]) => x"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.Statements[0].String(); got != tt.expected {
+			t.Errorf("wrong string for %q. want %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestArrowInCaseBranches(t *testing.T) {
+	input := `var label = case n ::
+  x if items.any(i => i == x) => "listed"
+  (1) => "one"
+  y => y => y
+end`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	exp := program.Statements[0].(*ast.VarStatement).Value.(*ast.CaseExpression)
+	if len(exp.Branches) != 3 {
+		t.Fatalf("expected 3 branches, got %d", len(exp.Branches))
+	}
+	if _, ok := exp.Branches[0].Pattern.(*ast.Identifier); !ok {
+		t.Errorf("first pattern should be a binding, got %T", exp.Branches[0].Pattern)
+	}
+	if _, ok := exp.Branches[1].Pattern.(*ast.NumberLiteral); !ok {
+		t.Errorf("grouped pattern should stay a value, got %T", exp.Branches[1].Pattern)
+	}
+	if _, ok := exp.Branches[2].Result.(*ast.FunctionLiteral); !ok {
+		t.Errorf("a branch result may be an arrow function, got %T", exp.Branches[2].Result)
+	}
+}
+
+func TestYieldMakesGenerators(t *testing.T) {
+	input := `fn numbers(limit) ::
+  var i = 0
+  while i < limit ::
+    yield i
+    i += 1
+  end
+end
+fn plain() ::
+  var inner = fn() ::
+    yield
+  end
+  return inner
+end`
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	numbers := program.Statements[0].(*ast.FnStatement)
+	if !numbers.Generator {
+		t.Errorf("a function that yields should be a generator")
+	}
+	loop := numbers.Body.Statements[1].(*ast.WhileStatement)
+	if got := loop.Body.Statements[0].String(); got != "yield i" {
+		t.Errorf("wrong yield statement, got %q", got)
+	}
+
+	plain := program.Statements[1].(*ast.FnStatement)
+	if plain.Generator {
+		t.Errorf("yield in a nested function should not make the outer one a generator")
+	}
+	inner := plain.Body.Statements[0].(*ast.VarStatement).Value.(*ast.FunctionLiteral)
+	if !inner.Generator {
+		t.Errorf("the nested function should be a generator")
+	}
+
+	p = New(lexer.New("yield 1"))
+	p.ParseProgram()
+	if len(p.Errors()) != 1 || !strings.Contains(p.Errors()[0], "yield outside of a function") {
+		t.Errorf("expected yield outside of a function error, got %v", p.Errors())
+	}
+}
+
+func TestDestructuringPatterns(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"var [a, b, ...rest] = items", "var [a, b, ...rest] = items"},
+		{"const {name, age = 0} = person", `const {"name": name, "age": age = 0} = person`},
+		{`var {address: {city}, "full name": full} = person`, `var {"address": {"city": city}, "full name": full} = person`},
+		{"var [x, [y, z] = pair] = point", "var [x, [y, z] = pair] = point"},
+		{"for [k, v] in pairs :: k end", "for [k, v] in pairs ::\n  kend"},
+		{"for i, {name} in users :: i end", "for i, {\"name\": name} in users ::\n  iend"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if got := program.Statements[0].String(); got != tt.expected {
+			t.Errorf("wrong string. want %q, got %q", tt.expected, got)
+		}
+	}
+}
+
+func TestDestructuredParameters(t *testing.T) {
+	p := New(lexer.New("fn dist([x1, y1], {x, y} = origin) :: x end"))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.FnStatement)
+	if _, ok := stmt.Parameters[0].Pattern.(*ast.ArrayPattern); !ok {
+		t.Errorf("expected an array pattern, got %T", stmt.Parameters[0].Pattern)
+	}
+	if stmt.Parameters[0].Name.Value != "[x1, y1]" {
+		t.Errorf("parameter name wrong. want %q, got %q", "[x1, y1]", stmt.Parameters[0].Name.Value)
+	}
+	if _, ok := stmt.Parameters[1].Pattern.(*ast.MapPattern); !ok || stmt.Parameters[1].Default == nil {
+		t.Errorf("expected a map pattern with a default, got %s", stmt.Parameters[1])
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := "while count < 10 :: count = count + 1 end"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if !testInfixExpression(t, stmt.Condition, "count", "<", 10) {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("body has wrong number of statements. got=%d", len(stmt.Body.Statements))
+	}
+}
+
+func TestLabeledLoops(t *testing.T) {
+	input := `outer: for row in rows ::
+  inner: while true ::
+    continue outer
+    break
+  end
+end`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	loop, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T",
+			program.Statements[0])
+	}
+	if loop.Label == nil || loop.Label.Value != "outer" {
+		t.Fatalf("for label wrong. want 'outer', got %v", loop.Label)
+	}
+
+	inner, ok := loop.Body.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("loop body is not ast.WhileStatement. got=%T", loop.Body.Statements[0])
+	}
+	if inner.Label == nil || inner.Label.Value != "inner" {
+		t.Fatalf("while label wrong. want 'inner', got %v", inner.Label)
+	}
+
+	cont, ok := inner.Body.Statements[0].(*ast.ContinueStatement)
+	if !ok || cont.Label == nil || cont.Label.Value != "outer" {
+		t.Errorf("expected 'continue outer', got %v", inner.Body.Statements[0])
+	}
+
+	// A label on the next line is not part of the break
+	brk, ok := inner.Body.Statements[1].(*ast.BreakStatement)
+	if !ok || brk.Label != nil {
+		t.Errorf("expected unlabeled break, got %v", inner.Body.Statements[1])
+	}
+}
+
+func TestLabelWithoutLoop(t *testing.T) {
+	l := lexer.New("outer: println(1)")
+	p := New(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected a parse error for a label without a loop")
+	}
+}
+
 func TestCheckStatements(t *testing.T) {
 	input := `
 	check "arithmetic tests" ::
@@ -518,6 +791,38 @@ func TestMapLiterals(t *testing.T) {
 	}
 }
 
+func TestSetLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"{1, 2 + 3, x}", "{1, (2 + 3), x}"},
+		{`{"only"}`, `{"only"}`},
+		{"{a, b} | {c}", "({a, b} | {c})"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if program.String() != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, program.String())
+		}
+	}
+
+	// Braces without colons hold a set, empty braces are still a map
+	program := New(lexer.New("{1, 2}")).ParseProgram()
+	if _, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.SetLiteral); !ok {
+		t.Errorf("{1, 2} should parse as a set literal")
+	}
+	program = New(lexer.New("{}")).ParseProgram()
+	if _, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MapLiteral); !ok {
+		t.Errorf("{} should parse as a map literal")
+	}
+}
+
 // Helper functions for testing
 
 func testVarStatement(t *testing.T, s ast.Statement, name string, isConstant bool) bool {
@@ -745,6 +1050,33 @@ func TestOperatorPrecedence(t *testing.T) {
 		{"2 / (5 + 5)", "(2 / (5 + 5))"},
 		{"-(5 + 5)", "(-(5 + 5))"},
 		{"!(true == true)", "(!(true == true))"},
+		{"x += 1 * 2", "x += (1 * 2)"},
+		{"a = b -= 3", "a = b -= 3"},
+		{`m["k"] ^= 2`, `(m["k"]) ^= 2`},
+		{"p.count %= n + 1", "p.count %= (n + 1)"},
+		{"a?.b?.c", "a?.b?.c"},
+		{"a?[i + 1]", "(a?[(i + 1)])"},
+		{"f?.(1, 2)", "f?.(1, 2)"},
+		{"a?.b?.()", "a?.b?.()"},
+		{"a?.b.c", "a?.b.c"},
+		{"a?.b[0].c(1)", "(a?.b[0]).c(1)"},
+		{"a?.b.c ?? d", "(a?.b.c ?? d)"},
+		{"a ?? b + 1", "(a ?? (b + 1))"},
+		{"a ?? b == c", "((a ?? b) == c)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"x = a?.b ?? 0", "x = (a?.b ?? 0)"},
+		{"a |> f |> g(1)", "((a |> f) |> g(1))"},
+		{"a + 1 |> f", "((a + 1) |> f)"},
+		{"a |> f == b", "((a |> f) == b)"},
+		{"1..5 |> m.collect()", "(1..5 |> m.collect())"},
+		{"x = a |> f", "x = (a |> f)"},
+		{"a | b ~ c & d", "(a | (b ~ (c & d)))"},
+		{"a & b == c", "((a & b) == c)"},
+		{"1 << n + 1", "(1 << (n + 1))"},
+		{"a >> 2 & 3", "((a >> 2) & 3)"},
+		{"~a & -b", "((~a) & (-b))"},
+		{"a ~ b ?? c", "(a ~ (b ?? c))"},
+		{"flags |= 1 << 3", "flags |= (1 << 3)"},
 	}
 
 	for _, tt := range tests {
@@ -836,6 +1168,37 @@ func TestCallExpressions(t *testing.T) {
 	testInfixExpression(t, exp.Arguments[2], 4, "+", 5)
 }
 
+func TestNamedArguments(t *testing.T) {
+	input := "Point(1, y: 2 + 3)"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("stmt.Expression is not ast.CallExpression. got=%T",
+			stmt.Expression)
+	}
+
+	if len(exp.Arguments) != 2 {
+		t.Fatalf("wrong length of arguments. got=%d", len(exp.Arguments))
+	}
+
+	testLiteralExpression(t, exp.Arguments[0], 1)
+
+	named, ok := exp.Arguments[1].(*ast.NamedArgument)
+	if !ok {
+		t.Fatalf("exp.Arguments[1] is not ast.NamedArgument. got=%T", exp.Arguments[1])
+	}
+	if named.Name.Value != "y" {
+		t.Errorf("named argument name wrong. want 'y', got %s", named.Name.Value)
+	}
+	testInfixExpression(t, named.Value, 2, "+", 3)
+}
+
 func TestIndexExpressions(t *testing.T) {
 	input := "myArray[1 + 1]"
 
@@ -1091,6 +1454,51 @@ func TestTypeStatement(t *testing.T) {
 	}
 }
 
+func TestSumTypeStatement(t *testing.T) {
+	input := "type Shape = Circle(radius: number) | Rect(w, h)\n  | Empty"
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.TypeStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.TypeStatement. got=%T", program.Statements[0])
+	}
+	if stmt.Type != nil {
+		t.Errorf("expected no aliased type, got %s", stmt.Type)
+	}
+	if len(stmt.Variants) != 3 {
+		t.Fatalf("expected 3 variants, got %d", len(stmt.Variants))
+	}
+	if stmt.String() != "type Shape = Circle(radius: number) | Rect(w, h) | Empty" {
+		t.Errorf("wrong string. got %q", stmt.String())
+	}
+	if stmt.Variants[2].Fields != nil {
+		t.Errorf("expected Empty to have no fields, got %v", stmt.Variants[2].Fields)
+	}
+}
+
+func TestSumTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"type Shape = circle(r) | Empty", "variant name 'circle' must start with an uppercase letter"},
+		{"type Shape = Circle(r) | Circle(d)", "duplicate variant 'Circle'"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || !strings.Contains(errors[0], tt.expected) {
+			t.Errorf("input %q: expected error containing %q, got %v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
 func TestParsingErrors(t *testing.T) {
 	tests := []struct {
 		input         string
@@ -1267,6 +1675,25 @@ func TestMultipleElseIf(t *testing.T) {
 	}
 }
 
+func TestFunctionLiteralReturnType(t *testing.T) {
+	input := `var double = fn(x: number): number :: x * 2 end`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.VarStatement)
+	lit, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T", stmt.Value)
+	}
+
+	if lit.ReturnType == nil || lit.ReturnType.Name != "number" {
+		t.Errorf("return type wrong. want 'number', got %v", lit.ReturnType)
+	}
+}
+
 func TestFunctionWithoutParameters(t *testing.T) {
 	input := `
 	fn greet(): string ::
@@ -1294,6 +1721,49 @@ func TestFunctionWithoutParameters(t *testing.T) {
 	}
 }
 
+func TestParameterDefaultsAndRest(t *testing.T) {
+	input := `fn greet(name: string, greeting = "Hello", ...rest) :: name end`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.FnStatement)
+	if len(stmt.Parameters) != 3 {
+		t.Fatalf("expected 3 parameters, got %d", len(stmt.Parameters))
+	}
+
+	expected := []string{"name: string", `greeting = "Hello"`, "...rest"}
+	for i, param := range stmt.Parameters {
+		if param.String() != expected[i] {
+			t.Errorf("parameter %d wrong. want %q, got %q", i, expected[i], param.String())
+		}
+	}
+	if !stmt.Parameters[2].Rest || stmt.Parameters[2].Name.Value != "rest" {
+		t.Errorf("expected a rest parameter called rest, got %s", stmt.Parameters[2])
+	}
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn f(...rest, last) :: 1 end", "rest parameter '...rest' must be the last parameter"},
+		{"fn f(...rest = []) :: 1 end", "rest parameter '...rest' cannot have a default value"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 || !strings.Contains(errors[0], tt.expected) {
+			t.Errorf("input %q: expected error containing %q, got %v", tt.input, tt.expected, errors)
+		}
+	}
+}
+
 func TestFunctionWithWhereBlock(t *testing.T) {
 	input := `
 	fn double(x: number): number ::
@@ -1326,6 +1796,95 @@ func TestFunctionWithWhereBlock(t *testing.T) {
 	}
 }
 
+func TestTryStatement(t *testing.T) {
+	input := `
+	try ::
+		risky()
+	catch err ::
+		throw err
+	ensure ::
+		cleanup()
+	end
+	`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.TryStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if len(stmt.Body.Statements) != 1 {
+		t.Errorf("try body has wrong number of statements. got=%d", len(stmt.Body.Statements))
+	}
+
+	if stmt.ErrorName == nil || stmt.ErrorName.Value != "err" {
+		t.Fatalf("catch name wrong. want 'err', got %v", stmt.ErrorName)
+	}
+
+	if _, ok := stmt.CatchBlock.Statements[0].(*ast.ThrowStatement); !ok {
+		t.Errorf("catch block does not start with ast.ThrowStatement. got=%T", stmt.CatchBlock.Statements[0])
+	}
+
+	if stmt.EnsureBlock == nil || len(stmt.EnsureBlock.Statements) != 1 {
+		t.Errorf("ensure block not parsed")
+	}
+}
+
+func TestTryStatementNeedsHandler(t *testing.T) {
+	l := lexer.New("try ::\n  risky()\nend")
+	p := New(l)
+	p.ParseProgram()
+
+	if len(p.Errors()) == 0 {
+		t.Fatal("expected a parse error for try without catch or ensure")
+	}
+}
+
+func TestNodePositions(t *testing.T) {
+	input := `struct Point ::
+  x: number
+end
+if ready ::
+  total = 1 + count
+end`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if pos := program.Statements[0].Position(); pos.Line != 1 || pos.Column != 1 {
+		t.Errorf("struct position wrong. want 1:1, got %d:%d", pos.Line, pos.Column)
+	}
+
+	stmt, ok := program.Statements[1].(*ast.IfStatement)
+	if !ok {
+		t.Fatalf("program.Statements[1] is not ast.IfStatement. got=%T",
+			program.Statements[1])
+	}
+	if pos := stmt.Position(); pos.Line != 4 || pos.Column != 1 {
+		t.Errorf("if position wrong. want 4:1, got %d:%d", pos.Line, pos.Column)
+	}
+
+	assignment := stmt.ThenBlock.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignmentExpression)
+	infix := assignment.Value.(*ast.InfixExpression)
+	if pos := infix.Position(); pos.Line != 5 || pos.Column != 13 {
+		t.Errorf("operator position wrong. want 5:13, got %d:%d", pos.Line, pos.Column)
+	}
+	if pos := infix.Right.Position(); pos.Line != 5 || pos.Column != 15 {
+		t.Errorf("identifier position wrong. want 5:15, got %d:%d", pos.Line, pos.Column)
+	}
+}
+
 func TestComponentStatement(t *testing.T) {
 	input := `
 	component Counter(initial: Number) ::